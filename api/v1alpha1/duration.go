@@ -0,0 +1,125 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Day is the duration of the "d" unit accepted by ParseDuration
+	Day = 24 * time.Hour
+	// Week is the duration of the "w" unit accepted by ParseDuration
+	Week = 7 * Day
+
+	// DefaultAnalysisWindow is used when spec.analysisWindow is empty
+	DefaultAnalysisWindow = 7 * Day
+)
+
+// stdUnits are the units handled by time.ParseDuration
+var stdUnits = map[string]bool{"ns": true, "us": true, "µs": true, "μs": true, "ms": true, "s": true, "m": true, "h": true}
+
+// ParseDuration parses duration strings used in PodRightSizing specs. It accepts
+// everything time.ParseDuration does plus the "d" (24h) and "w" (7d) units, which
+// may be combined with other units, e.g. "7d", "2w", "1d12h" or "1.5d".
+func ParseDuration(s string) (time.Duration, error) {
+	input := s
+	if s == "" {
+		return 0, fmt.Errorf("duration must not be empty")
+	}
+
+	negative := false
+	if s[0] == '-' || s[0] == '+' {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+	if s == "0" {
+		return 0, nil
+	}
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration %q", input)
+	}
+
+	var total time.Duration
+	for s != "" {
+		// Leading number, including an optional fraction
+		i := 0
+		for i < len(s) && (s[i] == '.' || (s[i] >= '0' && s[i] <= '9')) {
+			i++
+		}
+		if i == 0 {
+			return 0, fmt.Errorf("invalid duration %q", input)
+		}
+		number := s[:i]
+
+		// Unit up to the next number
+		j := i
+		for j < len(s) && s[j] != '.' && (s[j] < '0' || s[j] > '9') {
+			j++
+		}
+		unit := s[i:j]
+		s = s[j:]
+
+		var part time.Duration
+		switch unit {
+		case "d", "w":
+			value, err := strconv.ParseFloat(number, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", input)
+			}
+			scale := Day
+			if unit == "w" {
+				scale = Week
+			}
+			if value*float64(scale) > float64(math.MaxInt64) {
+				return 0, fmt.Errorf("invalid duration %q: out of range", input)
+			}
+			part = time.Duration(value * float64(scale))
+		case "":
+			return 0, fmt.Errorf("missing unit in duration %q", input)
+		default:
+			if !stdUnits[unit] {
+				return 0, fmt.Errorf("invalid duration %q: unknown unit %q (valid units are ns, us, ms, s, m, h, d, w)",
+					input, unit)
+			}
+			var err error
+			if part, err = time.ParseDuration(number + unit); err != nil {
+				return 0, fmt.Errorf("invalid duration %q: %w", input, err)
+			}
+		}
+
+		total += part
+	}
+
+	if negative {
+		total = -total
+	}
+	return total, nil
+}
+
+// ParseAnalysisWindow returns the analysis window of a spec, falling back to
+// DefaultAnalysisWindow when it is not set
+func ParseAnalysisWindow(window string) (time.Duration, error) {
+	if strings.TrimSpace(window) == "" {
+		return DefaultAnalysisWindow, nil
+	}
+	return ParseDuration(window)
+}
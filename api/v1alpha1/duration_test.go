@@ -0,0 +1,52 @@
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "0", want: 0},
+		{input: "90s", want: 90 * time.Second},
+		{input: "168h", want: 168 * time.Hour},
+		{input: "7d", want: 7 * 24 * time.Hour},
+		{input: "2w", want: 14 * 24 * time.Hour},
+		{input: "1d12h", want: 36 * time.Hour},
+		{input: "1w2d30m", want: 9*24*time.Hour + 30*time.Minute},
+		{input: "1.5d", want: 36 * time.Hour},
+		{input: "-1d", want: -24 * time.Hour},
+		{input: "", wantErr: true},
+		{input: "7", wantErr: true},
+		{input: "d", wantErr: true},
+		{input: "5y", wantErr: true},
+		{input: "7 d", wantErr: true},
+		{input: "1..5d", wantErr: true},
+		{input: "999999999w", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDuration(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseAnalysisWindow_Default(t *testing.T) {
+	window, err := ParseAnalysisWindow("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultAnalysisWindow, window)
+}
@@ -38,6 +38,30 @@ type PodRightSizingSpec struct {
 	// Thresholds define the optimization parameters
 	Thresholds ResourceThresholds `json:"thresholds,omitempty"`
 
+	// LimitPolicy defines how limits are set relative to the recommended requests
+	LimitPolicy LimitPolicy `json:"limitPolicy,omitempty"`
+
+	// QoSClass defines the QoS class recommendations produce: "Preserve" keeps each pod's current class,
+	// "Guaranteed" or "Burstable" change it explicitly
+	// +kubebuilder:default="Preserve"
+	QoSClass QoSClassPolicy `json:"qosClass,omitempty"`
+
+	// RuntimeAwareness keeps memory recommendations above the heap configured for JVM and Node.js containers
+	RuntimeAwareness RuntimeAwareness `json:"runtimeAwareness,omitempty"`
+
+	// Recommender selects the strategy that turns usage history into recommendations
+	Recommender RecommenderSpec `json:"recommender,omitempty"`
+
+	// Classification adapts thresholds to the usage pattern of each workload
+	Classification ClassificationSpec `json:"classification,omitempty"`
+
+	// OutlierFilter removes anomalous samples and replicas before percentiles are computed
+	OutlierFilter OutlierFilter `json:"outlierFilter,omitempty"`
+
+	// AnalysisExclusions are time ranges, such as incidents or load tests, whose samples are removed
+	// before analysis
+	AnalysisExclusions []AnalysisExclusion `json:"analysisExclusions,omitempty"`
+
 	// MetricsSource defines where to collect metrics from
 	MetricsSource MetricsSourceSpec `json:"metricsSource,omitempty"`
 
@@ -48,6 +72,12 @@ type PodRightSizingSpec struct {
 	// DryRun when true, only generates recommendations without applying changes
 	// +kubebuilder:default=false
 	DryRun bool `json:"dryRun,omitempty"`
+
+	// AnalysisConcurrency defines how many workloads are analyzed in parallel; 0 uses the controller default
+	// +kubebuilder:default=4
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=32
+	AnalysisConcurrency int32 `json:"analysisConcurrency,omitempty"`
 }
 
 // TargetSpec defines which pods to target for right-sizing.
@@ -84,7 +114,7 @@ type UpdatePolicy struct {
 	// +kubebuilder:default=3
 	BackoffLimit int32 `json:"backoffLimit,omitempty"`
 
-	// MinStabilityPeriod defines minimum time to wait between updates
+	// MinStabilityPeriod defines minimum time to wait between updates (e.g., "5m", "1d")
 	// +kubebuilder:default="5m"
 	MinStabilityPeriod string `json:"minStabilityPeriod,omitempty"`
 }
@@ -101,12 +131,12 @@ const (
 
 // ResourceThresholds defines optimization parameters
 type ResourceThresholds struct {
-	// CPUUtilizationPercentile defines target CPU utilization percentile (e.g., 95)
-	// +kubebuilder:default=95
+	// CPUUtilizationPercentile defines target CPU utilization percentile (e.g., 95). Unset uses 95, or the
+	// class profile's percentile when classification is enabled.
 	CPUUtilizationPercentile int `json:"cpuUtilizationPercentile,omitempty"`
 
-	// MemoryUtilizationPercentile defines target memory utilization percentile
-	// +kubebuilder:default=95
+	// MemoryUtilizationPercentile defines target memory utilization percentile. Unset uses 95, or the
+	// class profile's percentile when classification is enabled.
 	MemoryUtilizationPercentile int `json:"memoryUtilizationPercentile,omitempty"`
 
 	// MinCPU defines minimum CPU request
@@ -115,19 +145,182 @@ type ResourceThresholds struct {
 	// MaxCPU defines maximum CPU request
 	MaxCPU resource.Quantity `json:"maxCpu,omitempty"`
 
+	// CPUThrottlingThreshold defines the percentage of throttled CFS periods above which CPU limits are
+	// raised, since usage of a throttled container is capped at its limit
+	// +kubebuilder:default=10
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	CPUThrottlingThreshold int `json:"cpuThrottlingThreshold,omitempty"`
+
 	// MinMemory defines minimum memory request
 	MinMemory resource.Quantity `json:"minMemory,omitempty"`
 
 	// MaxMemory defines maximum memory request
 	MaxMemory resource.Quantity `json:"maxMemory,omitempty"`
 
-	// SafetyMargin defines safety margin percentage for recommendations
-	// +kubebuilder:default=20
+	// EphemeralStorageUtilizationPercentile defines target ephemeral-storage utilization percentile
+	// +kubebuilder:default=95
+	EphemeralStorageUtilizationPercentile int `json:"ephemeralStorageUtilizationPercentile,omitempty"`
+
+	// MinEphemeralStorage defines minimum ephemeral-storage request
+	MinEphemeralStorage resource.Quantity `json:"minEphemeralStorage,omitempty"`
+
+	// MaxEphemeralStorage defines maximum ephemeral-storage request
+	MaxEphemeralStorage resource.Quantity `json:"maxEphemeralStorage,omitempty"`
+
+	// SafetyMargin defines safety margin percentage for recommendations. Unset uses 20, or the class
+	// profile's margin when classification is enabled.
 	SafetyMargin int `json:"safetyMargin,omitempty"`
 
 	// MinChangeThreshold defines minimum change required to trigger update (percentage)
 	// +kubebuilder:default=10
 	MinChangeThreshold int `json:"minChangeThreshold,omitempty"`
+
+	// StartupExclusion drops samples within this duration after each container start (e.g., "5m")
+	StartupExclusion string `json:"startupExclusion,omitempty"`
+}
+
+// RecommenderSpec selects a recommender strategy and its parameters
+type RecommenderSpec struct {
+	// Name of a registered recommender: "Percentile" sizes for the utilization percentiles,
+	// "Peak" for the highest observed usage, "DecayingHistogram" for the utilization percentiles of
+	// a VPA-style histogram that weighs recent usage more, "Forecast" for the utilization percentiles
+	// projected until the next analysis when usage grows. Empty selects "Percentile", or the
+	// recommender of the workload's class profile when classification is enabled.
+	Name string `json:"name,omitempty"`
+
+	// Parameters configure the recommender; the accepted keys depend on the recommender
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// ClassificationSpec configures threshold profiles per workload class
+type ClassificationSpec struct {
+	// Enabled classifies each workload as Stable, Bursty, Periodic, Growing, Shrinking or Unpredictable
+	// from its usage and applies the threshold profile of its class. A percentile or safety margin set
+	// in profiles takes precedence, then one set in thresholds, then the built-in profile of the class.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Profiles override the built-in threshold profiles, keyed by class name
+	Profiles map[string]ClassProfile `json:"profiles,omitempty"`
+}
+
+// ClassProfile defines the thresholds for workloads of one class. Unset fields keep spec.thresholds, or the
+// built-in profile when those are unset too.
+type ClassProfile struct {
+	// CPUUtilizationPercentile overrides thresholds.cpuUtilizationPercentile for the class
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	CPUUtilizationPercentile int `json:"cpuUtilizationPercentile,omitempty"`
+
+	// MemoryUtilizationPercentile overrides thresholds.memoryUtilizationPercentile for the class
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	MemoryUtilizationPercentile int `json:"memoryUtilizationPercentile,omitempty"`
+
+	// SafetyMargin overrides thresholds.safetyMargin for the class
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1000
+	SafetyMargin int `json:"safetyMargin,omitempty"`
+
+	// LimitPolicy replaces spec.limitPolicy
+	LimitPolicy *LimitPolicy `json:"limitPolicy,omitempty"`
+}
+
+// OutlierFilter configures the removal of anomalous usage before analysis
+type OutlierFilter struct {
+	// Method selects how outlying samples of each pod are detected:
+	// "None" keeps all samples,
+	// "MAD" removes samples more than threshold scaled median absolute deviations from the median,
+	// "IQR" removes samples more than threshold interquartile ranges below the first or above the third quartile
+	// +kubebuilder:default="None"
+	Method OutlierMethod `json:"method,omitempty"`
+
+	// Threshold is the distance beyond which samples are outliers (e.g., "3.5"),
+	// defaults to 3.5 for MAD and 1.5 for IQR
+	// +kubebuilder:validation:Pattern=`^([0-9]+(\.[0-9]*)?|\.[0-9]+)$`
+	Threshold string `json:"threshold,omitempty"`
+
+	// RejectReplicas skips pods whose median CPU or memory usage is an outlier among the replicas of
+	// the workload, such as a runaway replica. It needs at least three replicas.
+	RejectReplicas bool `json:"rejectReplicas,omitempty"`
+}
+
+// OutlierMethod defines how outlying samples are detected
+// +kubebuilder:validation:Enum=None;MAD;IQR
+type OutlierMethod string
+
+const (
+	OutlierMethodNone OutlierMethod = "None"
+	OutlierMethodMAD  OutlierMethod = "MAD"
+	OutlierMethodIQR  OutlierMethod = "IQR"
+)
+
+// AnalysisExclusion is a time range whose samples are removed before analysis
+type AnalysisExclusion struct {
+	// Start of the excluded range
+	Start metav1.Time `json:"start"`
+
+	// End of the excluded range
+	End metav1.Time `json:"end"`
+
+	// Reason documents why the range is excluded (e.g., "load test")
+	Reason string `json:"reason,omitempty"`
+}
+
+// LimitPolicy defines how limits are derived from usage and how they relate to requests
+type LimitPolicy struct {
+	// Mode selects how limits are set:
+	// "Default" sizes limits from the utilization percentiles and derives requests from them,
+	// "RequestsOnly" updates requests and leaves existing limits untouched,
+	// "PreserveRatio" keeps each container's existing limit/request ratio,
+	// "Percentile" sizes requests from the utilization percentiles and limits from limitPercentile
+	// +kubebuilder:default="Default"
+	Mode LimitMode `json:"mode,omitempty"`
+
+	// LimitPercentile defines the usage percentile limits are sized from in Percentile mode
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	LimitPercentile int `json:"limitPercentile,omitempty"`
+
+	// RemoveCPULimits removes CPU limits so containers can use idle CPU on the node
+	RemoveCPULimits bool `json:"removeCpuLimits,omitempty"`
+
+	// MemoryLimitEqualsRequest sets the memory limit equal to the memory request
+	MemoryLimitEqualsRequest bool `json:"memoryLimitEqualsRequest,omitempty"`
+}
+
+// LimitMode defines how limits are set
+// +kubebuilder:validation:Enum=Default;RequestsOnly;PreserveRatio;Percentile
+type LimitMode string
+
+const (
+	LimitModeDefault       LimitMode = "Default"
+	LimitModeRequestsOnly  LimitMode = "RequestsOnly"
+	LimitModePreserveRatio LimitMode = "PreserveRatio"
+	LimitModePercentile    LimitMode = "Percentile"
+)
+
+// QoSClassPolicy defines which QoS class recommendations produce
+// +kubebuilder:validation:Enum=Preserve;Guaranteed;Burstable
+type QoSClassPolicy string
+
+const (
+	QoSClassPreserve   QoSClassPolicy = "Preserve"
+	QoSClassGuaranteed QoSClassPolicy = "Guaranteed"
+	QoSClassBurstable  QoSClassPolicy = "Burstable"
+)
+
+// RuntimeAwareness configures detection of managed runtimes from container args and env
+type RuntimeAwareness struct {
+	// Enabled turns on detection of -Xmx and --max-old-space-size in container args
+	// and in JAVA_TOOL_OPTIONS, JAVA_OPTS, JDK_JAVA_OPTIONS and NODE_OPTIONS
+	Enabled bool `json:"enabled,omitempty"`
+
+	// HeapOverheadPercent is added to the heap for non-heap memory such as metaspace and thread stacks
+	// +kubebuilder:default=25
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=400
+	HeapOverheadPercent int `json:"heapOverheadPercent,omitempty"`
 }
 
 // MetricsSourceSpec defines where to collect metrics from
@@ -203,6 +396,9 @@ type PodRightSizingStatus struct {
 	// Recommendations contains the current recommendations
 	Recommendations []PodRecommendation `json:"recommendations,omitempty"`
 
+	// WorkloadAnalyses summarizes the usage behind the recommendations of each analyzed workload
+	WorkloadAnalyses []WorkloadAnalysisSummary `json:"workloadAnalyses,omitempty"`
+
 	// Conditions contains the current service state
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
@@ -237,6 +433,9 @@ type PodRecommendation struct {
 	// Confidence indicates confidence level (0-100)
 	Confidence int `json:"confidence,omitempty"`
 
+	// ConfidenceFactors break the confidence down into the factors it is the product of
+	ConfidenceFactors []ConfidenceFactor `json:"confidenceFactors,omitempty"`
+
 	// PotentialSavings estimates cost/resource savings
 	PotentialSavings ResourceSavings `json:"potentialSavings,omitempty"`
 
@@ -245,8 +444,147 @@ type PodRecommendation struct {
 
 	// AppliedTime indicates when this recommendation was applied
 	AppliedTime *metav1.Time `json:"appliedTime,omitempty"`
+
+	// Containers contains separate recommendations for each regular container of pods running more
+	// than one, sized from the container's own usage. They are applied instead of RecommendedResources,
+	// which covers the regular containers together.
+	Containers []ContainerRecommendation `json:"containers,omitempty"`
+
+	// InitContainers contains separate recommendations for init and sidecar containers.
+	// CurrentResources and RecommendedResources above only cover regular containers.
+	InitContainers []ContainerRecommendation `json:"initContainers,omitempty"`
+
+	// StartupPeak is the peak usage within thresholds.startupExclusion after container starts,
+	// for sizing a startup boost
+	StartupPeak corev1.ResourceList `json:"startupPeak,omitempty"`
+
+	// LimitFloors are limits the applied resources never fall below, whatever the limit policy,
+	// such as the memory limit containers were OOM-killed at raised by the safety margin
+	LimitFloors corev1.ResourceList `json:"limitFloors,omitempty"`
+
+	// WorkloadClass is the usage pattern class of the workload whose threshold profile was applied,
+	// empty when classification is disabled or the workload could not be classified
+	WorkloadClass string `json:"workloadClass,omitempty"`
+}
+
+// ConfidenceFactorName names a factor of the confidence of a recommendation
+// +kubebuilder:validation:Enum=Variability;Coverage;Days;Replicas;Restarts;ChangePoint
+type ConfidenceFactorName string
+
+const (
+	// ConfidenceFactorVariability scores how steady usage is, from the recommender's estimates
+	ConfidenceFactorVariability ConfidenceFactorName = "Variability"
+	// ConfidenceFactorCoverage scores the share of the analysis window the metrics cover
+	ConfidenceFactorCoverage ConfidenceFactorName = "Coverage"
+	// ConfidenceFactorDays scores the number of distinct days observed
+	ConfidenceFactorDays ConfidenceFactorName = "Days"
+	// ConfidenceFactorReplicas scores the number of replicas analyzed
+	ConfidenceFactorReplicas ConfidenceFactorName = "Replicas"
+	// ConfidenceFactorRestarts scores the container restarts and OOM kills in the window
+	ConfidenceFactorRestarts ConfidenceFactorName = "Restarts"
+	// ConfidenceFactorChangePoint scores the history left after a shift in usage, such as after a rollout
+	ConfidenceFactorChangePoint ConfidenceFactorName = "ChangePoint"
+)
+
+// ConfidenceFactor is one factor of the confidence of a recommendation
+type ConfidenceFactor struct {
+	// Name of the factor
+	Name ConfidenceFactorName `json:"name"`
+
+	// Score is the share of confidence the factor keeps (0-100)
+	Score int `json:"score"`
+
+	// Detail describes the evidence behind the score
+	Detail string `json:"detail,omitempty"`
+}
+
+// WorkloadAnalysisSummary is the usage evidence behind the recommendations of one workload
+type WorkloadAnalysisSummary struct {
+	// Namespace of the workload
+	Namespace string `json:"namespace"`
+
+	// WorkloadType is the kind of the workload (Deployment, StatefulSet, etc.)
+	WorkloadType string `json:"workloadType"`
+
+	// WorkloadName is the name of the workload
+	WorkloadName string `json:"workloadName"`
+
+	// Pods is the number of pods whose usage was analyzed
+	Pods int `json:"pods"`
+
+	// CPU summarizes CPU usage across all pods
+	CPU *ResourceUsageSummary `json:"cpu,omitempty"`
+
+	// Memory summarizes memory usage across all pods
+	Memory *ResourceUsageSummary `json:"memory,omitempty"`
+
+	// Priority is the highest priority of the workload-level recommendations: High, Medium or Low
+	Priority string `json:"priority,omitempty"`
+
+	// WorkloadClass is the usage pattern class the workload was classified as, empty when
+	// classification is disabled or the workload could not be classified
+	WorkloadClass string `json:"workloadClass,omitempty"`
+}
+
+// ResourceUsageSummary summarizes the usage of one resource across the pods of a workload
+type ResourceUsageSummary struct {
+	// DataPoints is the number of samples analyzed
+	DataPoints int `json:"dataPoints"`
+
+	// Min is the lowest observed usage
+	Min resource.Quantity `json:"min"`
+
+	// Mean is the average usage
+	Mean resource.Quantity `json:"mean"`
+
+	// P95 is the 95th percentile of usage
+	P95 resource.Quantity `json:"p95"`
+
+	// P99 is the 99th percentile of usage
+	P99 resource.Quantity `json:"p99"`
+
+	// Max is the highest observed usage
+	Max resource.Quantity `json:"max"`
+
+	// Pattern describes the variability, spikes and cycles detected in the usage of most pods,
+	// e.g. "variable with occasional spikes, daily cycle (strength 0.82, peaking 09-16h UTC)"
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// ContainerRecommendation contains resource recommendations for a single container
+type ContainerRecommendation struct {
+	// Name is the container name
+	Name string `json:"name"`
+
+	// Type is Init for init containers and Sidecar for restartable init containers, empty for regular containers
+	Type ContainerType `json:"type,omitempty"`
+
+	// CurrentResources shows current resource requests/limits of the container
+	CurrentResources corev1.ResourceRequirements `json:"currentResources,omitempty"`
+
+	// RecommendedResources shows recommended resource requests/limits of the container
+	RecommendedResources corev1.ResourceRequirements `json:"recommendedResources"`
+
+	// Reason explains why this recommendation was made
+	Reason string `json:"reason,omitempty"`
+
+	// Confidence indicates confidence level (0-100)
+	Confidence int `json:"confidence,omitempty"`
+
+	// LimitFloors are limits the applied resources of the container never fall below, whatever the
+	// limit policy, such as the memory limit it was OOM-killed at raised by the safety margin
+	LimitFloors corev1.ResourceList `json:"limitFloors,omitempty"`
 }
 
+// ContainerType distinguishes run-to-completion init containers from native sidecars
+// +kubebuilder:validation:Enum=Init;Sidecar
+type ContainerType string
+
+const (
+	ContainerTypeInit    ContainerType = "Init"
+	ContainerTypeSidecar ContainerType = "Sidecar"
+)
+
 // PodReference uniquely identifies a pod
 type PodReference struct {
 	// Name is the pod name
@@ -270,6 +608,9 @@ type ResourceSavings struct {
 	// MemorySavings estimates memory savings (in bytes)
 	MemorySavings *resource.Quantity `json:"memorySavings,omitempty"`
 
+	// EphemeralStorageSavings estimates ephemeral-storage savings (in bytes)
+	EphemeralStorageSavings *resource.Quantity `json:"ephemeralStorageSavings,omitempty"`
+
 	// CostSavings estimates cost savings (in USD per month)
 	CostSavings string `json:"costSavings,omitempty"`
 }
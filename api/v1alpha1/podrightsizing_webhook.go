@@ -18,6 +18,9 @@ package v1alpha1
 
 import (
 	"fmt"
+	"slices"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/robfig/cron/v3"
@@ -69,6 +72,42 @@ func (r *PodRightSizing) ValidatePodRightSizing() error {
 		allErrs = append(allErrs, errs...)
 	}
 
+	// Validate analysis concurrency
+	if errs := r.validateAnalysisConcurrency(); len(errs) > 0 {
+		allErrs = append(allErrs, errs...)
+	}
+
+	// Validate limit policy
+	if errs := r.validateLimitPolicy(); len(errs) > 0 {
+		allErrs = append(allErrs, errs...)
+	}
+
+	// Validate QoS class
+	if errs := r.validateQoSClass(); len(errs) > 0 {
+		allErrs = append(allErrs, errs...)
+	}
+
+	// Validate classification profiles
+	if errs := r.validateClassification(); len(errs) > 0 {
+		allErrs = append(allErrs, errs...)
+	}
+
+	// Validate outlier filtering and analysis exclusions
+	if errs := r.validateOutlierFilter(); len(errs) > 0 {
+		allErrs = append(allErrs, errs...)
+	}
+	if errs := r.validateAnalysisExclusions(); len(errs) > 0 {
+		allErrs = append(allErrs, errs...)
+	}
+
+	// Validate runtime awareness
+	if r.Spec.RuntimeAwareness.HeapOverheadPercent < 0 || r.Spec.RuntimeAwareness.HeapOverheadPercent > 400 {
+		allErrs = append(allErrs, field.Invalid(
+			field.NewPath("spec").Child("runtimeAwareness").Child("heapOverheadPercent"),
+			r.Spec.RuntimeAwareness.HeapOverheadPercent,
+			"must be between 0 and 400 (percentage)"))
+	}
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -138,6 +177,20 @@ func (r *PodRightSizing) validateThresholds() field.ErrorList {
 			"must be between 0 and 100"))
 	}
 
+	if r.Spec.Thresholds.EphemeralStorageUtilizationPercentile < 0 || r.Spec.Thresholds.EphemeralStorageUtilizationPercentile > 100 {
+		allErrs = append(allErrs, field.Invalid(
+			thresholdsPath.Child("ephemeralStorageUtilizationPercentile"),
+			r.Spec.Thresholds.EphemeralStorageUtilizationPercentile,
+			"must be between 0 and 100"))
+	}
+
+	if r.Spec.Thresholds.CPUThrottlingThreshold < 0 || r.Spec.Thresholds.CPUThrottlingThreshold > 100 {
+		allErrs = append(allErrs, field.Invalid(
+			thresholdsPath.Child("cpuThrottlingThreshold"),
+			r.Spec.Thresholds.CPUThrottlingThreshold,
+			"must be between 0 and 100 (percentage)"))
+	}
+
 	// Validate safety margin is reasonable
 	if r.Spec.Thresholds.SafetyMargin < 0 || r.Spec.Thresholds.SafetyMargin > 1000 {
 		allErrs = append(allErrs, field.Invalid(
@@ -173,6 +226,30 @@ func (r *PodRightSizing) validateThresholds() field.ErrorList {
 		}
 	}
 
+	if !r.Spec.Thresholds.MinEphemeralStorage.IsZero() && !r.Spec.Thresholds.MaxEphemeralStorage.IsZero() {
+		if r.Spec.Thresholds.MinEphemeralStorage.Cmp(r.Spec.Thresholds.MaxEphemeralStorage) > 0 {
+			allErrs = append(allErrs, field.Invalid(
+				thresholdsPath.Child("minEphemeralStorage"),
+				r.Spec.Thresholds.MinEphemeralStorage.String(),
+				"minEphemeralStorage cannot be greater than maxEphemeralStorage"))
+		}
+	}
+
+	if r.Spec.Thresholds.StartupExclusion != "" {
+		exclusion, err := ParseDuration(r.Spec.Thresholds.StartupExclusion)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(
+				thresholdsPath.Child("startupExclusion"),
+				r.Spec.Thresholds.StartupExclusion,
+				err.Error()))
+		} else if exclusion < 0 {
+			allErrs = append(allErrs, field.Invalid(
+				thresholdsPath.Child("startupExclusion"),
+				r.Spec.Thresholds.StartupExclusion,
+				"must not be negative"))
+		}
+	}
+
 	return allErrs
 }
 
@@ -185,10 +262,9 @@ func (r *PodRightSizing) validateAnalysisWindow() field.ErrorList {
 		return allErrs
 	}
 
-	duration, err := time.ParseDuration(r.Spec.AnalysisWindow)
+	duration, err := ParseDuration(r.Spec.AnalysisWindow)
 	if err != nil {
-		allErrs = append(allErrs, field.Invalid(windowPath, r.Spec.AnalysisWindow,
-			fmt.Sprintf("invalid duration format: %v", err)))
+		allErrs = append(allErrs, field.Invalid(windowPath, r.Spec.AnalysisWindow, err.Error()))
 		return allErrs
 	}
 
@@ -198,7 +274,7 @@ func (r *PodRightSizing) validateAnalysisWindow() field.ErrorList {
 			"analysis window must be at least 1 hour"))
 	}
 
-	if duration > 90*24*time.Hour {
+	if duration > 90*Day {
 		allErrs = append(allErrs, field.Invalid(windowPath, r.Spec.AnalysisWindow,
 			"analysis window must not exceed 90 days"))
 	}
@@ -253,11 +329,17 @@ func (r *PodRightSizing) validateUpdatePolicy() field.ErrorList {
 
 	// Validate stability period
 	if r.Spec.UpdatePolicy.MinStabilityPeriod != "" {
-		if _, err := time.ParseDuration(r.Spec.UpdatePolicy.MinStabilityPeriod); err != nil {
+		duration, err := ParseDuration(r.Spec.UpdatePolicy.MinStabilityPeriod)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(
+				policyPath.Child("minStabilityPeriod"),
+				r.Spec.UpdatePolicy.MinStabilityPeriod,
+				err.Error()))
+		} else if duration < 0 {
 			allErrs = append(allErrs, field.Invalid(
 				policyPath.Child("minStabilityPeriod"),
 				r.Spec.UpdatePolicy.MinStabilityPeriod,
-				fmt.Sprintf("invalid duration format: %v", err)))
+				"must be non-negative"))
 		}
 	}
 
@@ -319,3 +401,172 @@ func (r *PodRightSizing) validateMetricsSource() field.ErrorList {
 
 	return allErrs
 }
+
+// MaxAnalysisConcurrency is the upper bound for spec.analysisConcurrency
+const MaxAnalysisConcurrency = 32
+
+// validateAnalysisConcurrency validates the number of parallel workload analyses
+func (r *PodRightSizing) validateAnalysisConcurrency() field.ErrorList {
+	var allErrs field.ErrorList
+	concurrencyPath := field.NewPath("spec").Child("analysisConcurrency")
+
+	// Zero means the field was omitted and the controller default applies
+	if r.Spec.AnalysisConcurrency < 0 || r.Spec.AnalysisConcurrency > MaxAnalysisConcurrency {
+		allErrs = append(allErrs, field.Invalid(concurrencyPath, r.Spec.AnalysisConcurrency,
+			fmt.Sprintf("must be between 0 (controller default) and %d", MaxAnalysisConcurrency)))
+	}
+
+	return allErrs
+}
+
+// validateLimitPolicy validates the limit policy mode and rejects contradictory settings
+func (r *PodRightSizing) validateLimitPolicy() field.ErrorList {
+	return validateLimitPolicy(r.Spec.LimitPolicy, field.NewPath("spec").Child("limitPolicy"))
+}
+
+// validateLimitPolicy validates a limit policy at policyPath
+func validateLimitPolicy(policy LimitPolicy, policyPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	switch policy.Mode {
+	case "", LimitModeDefault, LimitModePreserveRatio:
+	case LimitModeRequestsOnly:
+		// Limits are left untouched, so toggles that change them do not apply
+		if policy.RemoveCPULimits {
+			allErrs = append(allErrs, field.Invalid(policyPath.Child("removeCpuLimits"), policy.RemoveCPULimits,
+				"cannot remove CPU limits in RequestsOnly mode"))
+		}
+		if policy.MemoryLimitEqualsRequest {
+			allErrs = append(allErrs, field.Invalid(policyPath.Child("memoryLimitEqualsRequest"), policy.MemoryLimitEqualsRequest,
+				"cannot change memory limits in RequestsOnly mode"))
+		}
+	case LimitModePercentile:
+		if policy.LimitPercentile == 0 {
+			allErrs = append(allErrs, field.Required(policyPath.Child("limitPercentile"),
+				"must be set in Percentile mode"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(policyPath.Child("mode"), policy.Mode,
+			[]string{string(LimitModeDefault), string(LimitModeRequestsOnly), string(LimitModePreserveRatio), string(LimitModePercentile)}))
+	}
+
+	if policy.LimitPercentile < 0 || policy.LimitPercentile > 100 {
+		allErrs = append(allErrs, field.Invalid(policyPath.Child("limitPercentile"), policy.LimitPercentile,
+			"must be between 1 and 100"))
+	} else if policy.LimitPercentile > 0 && policy.Mode != LimitModePercentile {
+		allErrs = append(allErrs, field.Invalid(policyPath.Child("limitPercentile"), policy.LimitPercentile,
+			"only applies in Percentile mode"))
+	}
+
+	return allErrs
+}
+
+// validateClassification validates the class names and thresholds of the classification profiles
+func (r *PodRightSizing) validateClassification() field.ErrorList {
+	var allErrs field.ErrorList
+	profilesPath := field.NewPath("spec").Child("classification").Child("profiles")
+	classes := []string{"Stable", "Bursty", "Periodic", "Growing", "Shrinking", "Unpredictable"}
+
+	names := make([]string, 0, len(r.Spec.Classification.Profiles))
+	for name := range r.Spec.Classification.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		profile := r.Spec.Classification.Profiles[name]
+		profilePath := profilesPath.Key(name)
+
+		if !slices.Contains(classes, name) {
+			allErrs = append(allErrs, field.NotSupported(profilePath, name, classes))
+		}
+		if profile.CPUUtilizationPercentile < 0 || profile.CPUUtilizationPercentile > 100 {
+			allErrs = append(allErrs, field.Invalid(profilePath.Child("cpuUtilizationPercentile"),
+				profile.CPUUtilizationPercentile, "must be between 1 and 100"))
+		}
+		if profile.MemoryUtilizationPercentile < 0 || profile.MemoryUtilizationPercentile > 100 {
+			allErrs = append(allErrs, field.Invalid(profilePath.Child("memoryUtilizationPercentile"),
+				profile.MemoryUtilizationPercentile, "must be between 1 and 100"))
+		}
+		if profile.SafetyMargin < 0 || profile.SafetyMargin > 1000 {
+			allErrs = append(allErrs, field.Invalid(profilePath.Child("safetyMargin"),
+				profile.SafetyMargin, "must be between 1 and 1000 (percentage)"))
+		}
+		if profile.LimitPolicy != nil {
+			allErrs = append(allErrs, validateLimitPolicy(*profile.LimitPolicy, profilePath.Child("limitPolicy"))...)
+		}
+	}
+
+	return allErrs
+}
+
+// validateOutlierFilter validates the outlier method and threshold
+func (r *PodRightSizing) validateOutlierFilter() field.ErrorList {
+	var allErrs field.ErrorList
+	filterPath := field.NewPath("spec").Child("outlierFilter")
+	filter := r.Spec.OutlierFilter
+
+	switch filter.Method {
+	case "", OutlierMethodNone, OutlierMethodMAD, OutlierMethodIQR:
+	default:
+		allErrs = append(allErrs, field.NotSupported(filterPath.Child("method"), filter.Method,
+			[]string{string(OutlierMethodNone), string(OutlierMethodMAD), string(OutlierMethodIQR)}))
+	}
+
+	if filter.Threshold != "" {
+		threshold, err := strconv.ParseFloat(filter.Threshold, 64)
+		if err != nil || threshold <= 0 {
+			allErrs = append(allErrs, field.Invalid(filterPath.Child("threshold"), filter.Threshold,
+				"must be a positive number"))
+		}
+	}
+
+	return allErrs
+}
+
+// validateAnalysisExclusions validates that every excluded range ends after it starts
+func (r *PodRightSizing) validateAnalysisExclusions() field.ErrorList {
+	var allErrs field.ErrorList
+	exclusionsPath := field.NewPath("spec").Child("analysisExclusions")
+
+	for i, exclusion := range r.Spec.AnalysisExclusions {
+		exclusionPath := exclusionsPath.Index(i)
+		if exclusion.Start.IsZero() {
+			allErrs = append(allErrs, field.Required(exclusionPath.Child("start"), "must be set"))
+		}
+		if exclusion.End.IsZero() {
+			allErrs = append(allErrs, field.Required(exclusionPath.Child("end"), "must be set"))
+		}
+		if !exclusion.Start.IsZero() && !exclusion.End.After(exclusion.Start.Time) {
+			allErrs = append(allErrs, field.Invalid(exclusionPath.Child("end"), exclusion.End,
+				"must be after start"))
+		}
+	}
+
+	return allErrs
+}
+
+// validateQoSClass validates the QoS class and rejects limit policies that cannot produce it
+func (r *PodRightSizing) validateQoSClass() field.ErrorList {
+	var allErrs field.ErrorList
+	qosPath := field.NewPath("spec").Child("qosClass")
+
+	switch r.Spec.QoSClass {
+	case "", QoSClassPreserve, QoSClassBurstable:
+	case QoSClassGuaranteed:
+		// Guaranteed pods need CPU and memory limits equal to their requests
+		if r.Spec.LimitPolicy.RemoveCPULimits {
+			allErrs = append(allErrs, field.Invalid(qosPath, r.Spec.QoSClass,
+				"Guaranteed requires CPU limits, which limitPolicy.removeCpuLimits removes"))
+		}
+		if r.Spec.LimitPolicy.Mode == LimitModeRequestsOnly {
+			allErrs = append(allErrs, field.Invalid(qosPath, r.Spec.QoSClass,
+				"Guaranteed may need to set limits, which limitPolicy mode RequestsOnly leaves untouched"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(qosPath, r.Spec.QoSClass,
+			[]string{string(QoSClassPreserve), string(QoSClassGuaranteed), string(QoSClassBurstable)}))
+	}
+
+	return allErrs
+}
@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	"testing"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -142,6 +143,296 @@ func TestPodRightSizing_ValidatePodRightSizing(t *testing.T) {
 			},
 			wantError: true,
 		},
+		{
+			name: "invalid - min ephemeral storage greater than max",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				Thresholds: ResourceThresholds{
+					MinEphemeralStorage: resource.MustParse("2Gi"),
+					MaxEphemeralStorage: resource.MustParse("1Gi"),
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "valid - analysis window in days",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				AnalysisWindow: "30d",
+			},
+			wantError: false,
+		},
+		{
+			name: "invalid - analysis window too long",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				AnalysisWindow: "13w",
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid - min stability period unit",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				UpdatePolicy: UpdatePolicy{
+					MinStabilityPeriod: "5y",
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "valid - analysis concurrency",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				AnalysisConcurrency: 8,
+			},
+			wantError: false,
+		},
+		{
+			name: "valid - analysis concurrency omitted",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				AnalysisConcurrency: 0,
+			},
+			wantError: false,
+		},
+		{
+			name: "invalid - negative analysis concurrency",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				AnalysisConcurrency: -1,
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid - analysis concurrency too high",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				AnalysisConcurrency: MaxAnalysisConcurrency + 1,
+			},
+			wantError: true,
+		},
+		{
+			name: "valid - percentile limit policy",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				LimitPolicy: LimitPolicy{
+					Mode:                     LimitModePercentile,
+					LimitPercentile:          99,
+					RemoveCPULimits:          true,
+					MemoryLimitEqualsRequest: true,
+				},
+			},
+			wantError: false,
+		},
+		{
+			name: "invalid - percentile limit policy without percentile",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				LimitPolicy: LimitPolicy{
+					Mode: LimitModePercentile,
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid - requests only limit policy removing CPU limits",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				LimitPolicy: LimitPolicy{
+					Mode:            LimitModeRequestsOnly,
+					RemoveCPULimits: true,
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "valid - explicit Guaranteed QoS class",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				QoSClass: QoSClassGuaranteed,
+			},
+			wantError: false,
+		},
+		{
+			name: "invalid - Guaranteed QoS class without CPU limits",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				QoSClass: QoSClassGuaranteed,
+				LimitPolicy: LimitPolicy{
+					RemoveCPULimits: true,
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "valid - startup exclusion",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				Thresholds: ResourceThresholds{
+					StartupExclusion: "5m",
+				},
+			},
+			wantError: false,
+		},
+		{
+			name: "invalid - startup exclusion",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				Thresholds: ResourceThresholds{
+					StartupExclusion: "five minutes",
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid - cpu throttling threshold above 100",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				Thresholds: ResourceThresholds{
+					CPUThrottlingThreshold: 150,
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "valid - classification profiles",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				Classification: ClassificationSpec{
+					Enabled: true,
+					Profiles: map[string]ClassProfile{
+						"Bursty": {SafetyMargin: 40, LimitPolicy: &LimitPolicy{Mode: LimitModePercentile, LimitPercentile: 99}},
+						"Stable": {CPUUtilizationPercentile: 90},
+					},
+				},
+			},
+			wantError: false,
+		},
+		{
+			name: "invalid - unknown classification profile",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				Classification: ClassificationSpec{
+					Profiles: map[string]ClassProfile{"Spiky": {SafetyMargin: 40}},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid - classification profile limit policy",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				Classification: ClassificationSpec{
+					Profiles: map[string]ClassProfile{
+						"Bursty": {LimitPolicy: &LimitPolicy{Mode: LimitModePercentile}},
+					},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "valid - outlier filter and analysis exclusions",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				OutlierFilter: OutlierFilter{Method: OutlierMethodIQR, Threshold: "3", RejectReplicas: true},
+				AnalysisExclusions: []AnalysisExclusion{{
+					Start:  metav1.NewTime(time.Date(2025, 1, 10, 14, 0, 0, 0, time.UTC)),
+					End:    metav1.NewTime(time.Date(2025, 1, 10, 16, 0, 0, 0, time.UTC)),
+					Reason: "load test",
+				}},
+			},
+			wantError: false,
+		},
+		{
+			name: "invalid - outlier threshold",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				OutlierFilter: OutlierFilter{Method: OutlierMethodMAD, Threshold: "0"},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid - analysis exclusion ends before it starts",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				AnalysisExclusions: []AnalysisExclusion{{
+					Start: metav1.NewTime(time.Date(2025, 1, 10, 16, 0, 0, 0, time.UTC)),
+					End:   metav1.NewTime(time.Date(2025, 1, 10, 14, 0, 0, 0, time.UTC)),
+				}},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid - negative heap overhead",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				RuntimeAwareness: RuntimeAwareness{
+					Enabled:             true,
+					HeapOverheadPercent: -5,
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid - limit percentile outside Percentile mode",
+			spec: PodRightSizingSpec{
+				Target: TargetSpec{
+					Namespace: "test-namespace",
+				},
+				LimitPolicy: LimitPolicy{
+					Mode:            LimitModePreserveRatio,
+					LimitPercentile: 99,
+				},
+			},
+			wantError: true,
+		},
 	}
 
 	for _, tt := range tests {
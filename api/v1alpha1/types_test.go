@@ -1,12 +1,16 @@
 package v1alpha1
 
 import (
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
 )
 
 func TestPodRightSizing_Creation(t *testing.T) {
@@ -193,3 +197,17 @@ func TestAuthTypes(t *testing.T) {
 		assert.Equal(t, expectedTypes[i], string(authType))
 	}
 }
+
+func TestAnalysisConcurrencySchemaMatchesWebhook(t *testing.T) {
+	data, err := os.ReadFile("../../config/crd/bases/rightsizing.k8s-rightsizer.io_podrightsizings.yaml")
+	require.NoError(t, err)
+	var crd apiextensionsv1.CustomResourceDefinition
+	require.NoError(t, yaml.Unmarshal(data, &crd))
+
+	// The API server must let through every value the webhook accepts, including 0 for the default
+	schema := crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"].Properties["analysisConcurrency"]
+	require.NotNil(t, schema.Minimum)
+	require.NotNil(t, schema.Maximum)
+	assert.Zero(t, *schema.Minimum)
+	assert.Equal(t, float64(MaxAnalysisConcurrency), *schema.Maximum)
+}
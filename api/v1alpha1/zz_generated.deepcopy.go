@@ -27,6 +27,23 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AnalysisExclusion) DeepCopyInto(out *AnalysisExclusion) {
+	*out = *in
+	in.Start.DeepCopyInto(&out.Start)
+	in.End.DeepCopyInto(&out.End)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AnalysisExclusion.
+func (in *AnalysisExclusion) DeepCopy() *AnalysisExclusion {
+	if in == nil {
+		return nil
+	}
+	out := new(AnalysisExclusion)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AuthConfig) DeepCopyInto(out *AuthConfig) {
 	*out = *in
@@ -47,6 +64,102 @@ func (in *AuthConfig) DeepCopy() *AuthConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClassProfile) DeepCopyInto(out *ClassProfile) {
+	*out = *in
+	if in.LimitPolicy != nil {
+		in, out := &in.LimitPolicy, &out.LimitPolicy
+		*out = new(LimitPolicy)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClassProfile.
+func (in *ClassProfile) DeepCopy() *ClassProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(ClassProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClassificationSpec) DeepCopyInto(out *ClassificationSpec) {
+	*out = *in
+	if in.Profiles != nil {
+		in, out := &in.Profiles, &out.Profiles
+		*out = make(map[string]ClassProfile, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClassificationSpec.
+func (in *ClassificationSpec) DeepCopy() *ClassificationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClassificationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfidenceFactor) DeepCopyInto(out *ConfidenceFactor) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfidenceFactor.
+func (in *ConfidenceFactor) DeepCopy() *ConfidenceFactor {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfidenceFactor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerRecommendation) DeepCopyInto(out *ContainerRecommendation) {
+	*out = *in
+	in.CurrentResources.DeepCopyInto(&out.CurrentResources)
+	in.RecommendedResources.DeepCopyInto(&out.RecommendedResources)
+	if in.LimitFloors != nil {
+		in, out := &in.LimitFloors, &out.LimitFloors
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerRecommendation.
+func (in *ContainerRecommendation) DeepCopy() *ContainerRecommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerRecommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LimitPolicy) DeepCopyInto(out *LimitPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LimitPolicy.
+func (in *LimitPolicy) DeepCopy() *LimitPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(LimitPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetricsSourceSpec) DeepCopyInto(out *MetricsSourceSpec) {
 	*out = *in
@@ -67,17 +180,65 @@ func (in *MetricsSourceSpec) DeepCopy() *MetricsSourceSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutlierFilter) DeepCopyInto(out *OutlierFilter) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutlierFilter.
+func (in *OutlierFilter) DeepCopy() *OutlierFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(OutlierFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodRecommendation) DeepCopyInto(out *PodRecommendation) {
 	*out = *in
 	out.PodReference = in.PodReference
 	in.CurrentResources.DeepCopyInto(&out.CurrentResources)
 	in.RecommendedResources.DeepCopyInto(&out.RecommendedResources)
+	if in.ConfidenceFactors != nil {
+		in, out := &in.ConfidenceFactors, &out.ConfidenceFactors
+		*out = make([]ConfidenceFactor, len(*in))
+		copy(*out, *in)
+	}
 	in.PotentialSavings.DeepCopyInto(&out.PotentialSavings)
 	if in.AppliedTime != nil {
 		in, out := &in.AppliedTime, &out.AppliedTime
 		*out = (*in).DeepCopy()
 	}
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]ContainerRecommendation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InitContainers != nil {
+		in, out := &in.InitContainers, &out.InitContainers
+		*out = make([]ContainerRecommendation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StartupPeak != nil {
+		in, out := &in.StartupPeak, &out.StartupPeak
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.LimitFloors != nil {
+		in, out := &in.LimitFloors, &out.LimitFloors
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodRecommendation.
@@ -170,6 +331,18 @@ func (in *PodRightSizingSpec) DeepCopyInto(out *PodRightSizingSpec) {
 	in.Target.DeepCopyInto(&out.Target)
 	in.UpdatePolicy.DeepCopyInto(&out.UpdatePolicy)
 	in.Thresholds.DeepCopyInto(&out.Thresholds)
+	out.LimitPolicy = in.LimitPolicy
+	out.RuntimeAwareness = in.RuntimeAwareness
+	in.Recommender.DeepCopyInto(&out.Recommender)
+	in.Classification.DeepCopyInto(&out.Classification)
+	out.OutlierFilter = in.OutlierFilter
+	if in.AnalysisExclusions != nil {
+		in, out := &in.AnalysisExclusions, &out.AnalysisExclusions
+		*out = make([]AnalysisExclusion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.MetricsSource.DeepCopyInto(&out.MetricsSource)
 }
 
@@ -201,6 +374,13 @@ func (in *PodRightSizingStatus) DeepCopyInto(out *PodRightSizingStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.WorkloadAnalyses != nil {
+		in, out := &in.WorkloadAnalyses, &out.WorkloadAnalyses
+		*out = make([]WorkloadAnalysisSummary, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make([]v1.Condition, len(*in))
@@ -240,6 +420,28 @@ func (in *PrometheusConfig) DeepCopy() *PrometheusConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecommenderSpec) DeepCopyInto(out *RecommenderSpec) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecommenderSpec.
+func (in *RecommenderSpec) DeepCopy() *RecommenderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RecommenderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ResourceSavings) DeepCopyInto(out *ResourceSavings) {
 	*out = *in
@@ -253,6 +455,11 @@ func (in *ResourceSavings) DeepCopyInto(out *ResourceSavings) {
 		x := (*in).DeepCopy()
 		*out = &x
 	}
+	if in.EphemeralStorageSavings != nil {
+		in, out := &in.EphemeralStorageSavings, &out.EphemeralStorageSavings
+		x := (*in).DeepCopy()
+		*out = &x
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceSavings.
@@ -272,6 +479,8 @@ func (in *ResourceThresholds) DeepCopyInto(out *ResourceThresholds) {
 	out.MaxCPU = in.MaxCPU.DeepCopy()
 	out.MinMemory = in.MinMemory.DeepCopy()
 	out.MaxMemory = in.MaxMemory.DeepCopy()
+	out.MinEphemeralStorage = in.MinEphemeralStorage.DeepCopy()
+	out.MaxEphemeralStorage = in.MaxEphemeralStorage.DeepCopy()
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceThresholds.
@@ -284,6 +493,41 @@ func (in *ResourceThresholds) DeepCopy() *ResourceThresholds {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceUsageSummary) DeepCopyInto(out *ResourceUsageSummary) {
+	*out = *in
+	out.Min = in.Min.DeepCopy()
+	out.Mean = in.Mean.DeepCopy()
+	out.P95 = in.P95.DeepCopy()
+	out.P99 = in.P99.DeepCopy()
+	out.Max = in.Max.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceUsageSummary.
+func (in *ResourceUsageSummary) DeepCopy() *ResourceUsageSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceUsageSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RuntimeAwareness) DeepCopyInto(out *RuntimeAwareness) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RuntimeAwareness.
+func (in *RuntimeAwareness) DeepCopy() *RuntimeAwareness {
+	if in == nil {
+		return nil
+	}
+	out := new(RuntimeAwareness)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TargetSpec) DeepCopyInto(out *TargetSpec) {
 	*out = *in
@@ -343,3 +587,28 @@ func (in *UpdatePolicy) DeepCopy() *UpdatePolicy {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadAnalysisSummary) DeepCopyInto(out *WorkloadAnalysisSummary) {
+	*out = *in
+	if in.CPU != nil {
+		in, out := &in.CPU, &out.CPU
+		*out = new(ResourceUsageSummary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		*out = new(ResourceUsageSummary)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadAnalysisSummary.
+func (in *WorkloadAnalysisSummary) DeepCopy() *WorkloadAnalysisSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadAnalysisSummary)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -19,10 +19,13 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -33,6 +36,7 @@ import (
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -41,6 +45,7 @@ import (
 
 	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
 	"github.com/wesleyemery/k8s-pod-rightsizer/internal/controller"
+	"github.com/wesleyemery/k8s-pod-rightsizer/internal/sharding"
 	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/analyzer"
 	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
 	// +kubebuilder:scaffold:imports
@@ -58,23 +63,49 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "simulate" {
+		os.Exit(runSimulate(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	var metricsAddr string
 	var metricsCertPath, metricsCertName, metricsCertKey string
 	var webhookCertPath, webhookCertName, webhookCertKey string
 	var enableLeaderElection bool
+	var enableSharding bool
+	var shardLeaseNamespace string
+	var shardIdentity string
+	var shardLeaseDuration time.Duration
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
 	var prometheusURL string
 	var useMockMetrics bool
+	var metricsQPS float64
+	var metricsBurst int
+	var metricsCacheTTL time.Duration
+	var metricsCacheMaxSamples int
 	var tlsOpts []func(*tls.Config)
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false, "Enable leader election for controller manager.")
+	flag.BoolVar(&enableSharding, "enable-sharding", false,
+		"Split PodRightSizing objects between all replicas instead of electing a single leader.")
+	flag.StringVar(&shardLeaseNamespace, "shard-lease-namespace", "",
+		"Namespace holding the shard Leases (defaults to POD_NAMESPACE or the service account namespace).")
+	flag.StringVar(&shardIdentity, "shard-identity", "", "Unique replica identity for sharding (defaults to the hostname).")
+	flag.DurationVar(&shardLeaseDuration, "shard-lease-duration", 30*time.Second,
+		"How long a replica remains a shard member without renewing its Lease.")
 	flag.BoolVar(&secureMetrics, "metrics-secure", true, "If set, the metrics endpoint is served securely via HTTPS.")
 	flag.StringVar(&prometheusURL, "prometheus-url", "", "Prometheus server URL (can also be set via PROMETHEUS_URL env var)")
 	flag.BoolVar(&useMockMetrics, "use-mock-metrics", false, "Use mock metrics client for testing")
+	flag.Float64Var(&metricsQPS, "metrics-qps", 10,
+		"Maximum Prometheus queries per second shared by all workload analysis workers.")
+	flag.IntVar(&metricsBurst, "metrics-burst", 20, "Maximum burst of Prometheus queries above metrics-qps.")
+	flag.DurationVar(&metricsCacheTTL, "metrics-cache-ttl", time.Hour,
+		"How long cached Prometheus samples are reused before a full refetch. Set to 0 to disable the cache.")
+	flag.IntVar(&metricsCacheMaxSamples, "metrics-cache-max-samples", 2000000,
+		"Maximum number of usage samples held by the metrics cache (roughly 48 bytes each).")
 	flag.StringVar(&webhookCertPath, "webhook-cert-path", "", "The directory that contains the webhook certificate.")
 	flag.StringVar(&webhookCertName, "webhook-cert-name", "tls.crt", "The name of the webhook certificate file.")
 	flag.StringVar(&webhookCertKey, "webhook-cert-key", "tls.key", "The name of the webhook key file.")
@@ -161,6 +192,12 @@ func main() {
 		})
 	}
 
+	// Sharding replaces leader election: every replica works on its own slice of objects
+	if enableSharding && enableLeaderElection {
+		setupLog.Info("Sharding enabled, disabling leader election")
+		enableLeaderElection = false
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		Metrics:                metricsServerOptions,
@@ -176,6 +213,7 @@ func main() {
 
 	// Initialize metrics client
 	var metricsClient analyzer.MetricsClientInterface
+	usePrometheus := false
 
 	if useMockMetrics {
 		setupLog.Info("Using mock metrics client for testing")
@@ -192,18 +230,30 @@ func main() {
 		metricsClient = mockClient
 	} else if prometheusURL != "" {
 		setupLog.Info("Using Prometheus metrics client", "url", prometheusURL)
-		prometheusClient, err := metrics.NewPrometheusClient(prometheusURL, http.DefaultTransport)
+		// Share a single rate limiter across all reconciles so parallel analysis cannot overload Prometheus.
+		// Every query takes a token, so one workload fetch counts as the queries it sends.
+		roundTripper := metrics.NewRateLimitedRoundTripper(http.DefaultTransport, metricsQPS, metricsBurst)
+		setupLog.Info("Rate limiting Prometheus queries", "qps", metricsQPS, "burst", metricsBurst)
+		prometheusClient, err := metrics.NewPrometheusClient(prometheusURL, roundTripper)
 		if err != nil {
 			setupLog.Error(err, "unable to create Prometheus client, falling back to mock")
 			metricsClient = metrics.NewMockMetricsClient()
 		} else {
 			metricsClient = prometheusClient
+			usePrometheus = true
 		}
 	} else {
 		setupLog.Info("No Prometheus URL configured, using mock metrics client")
 		metricsClient = metrics.NewMockMetricsClient()
 	}
 
+	// Cache Prometheus results in front of the limiter so cache hits do not consume tokens.
+	// The mock client generates new pod names on every call, so caching it would only accumulate noise.
+	if usePrometheus && metricsCacheTTL > 0 {
+		metricsClient = metrics.NewCachingClient(metricsClient, metricsCacheTTL, metricsCacheMaxSamples)
+		setupLog.Info("Caching metrics results", "ttl", metricsCacheTTL, "maxSamples", metricsCacheMaxSamples)
+	}
+
 	// Initialize recommendation engine
 	recommendEngine := analyzer.NewRecommendationEngine()
 
@@ -222,12 +272,31 @@ func main() {
 		}
 	}
 
-	if err = (&controller.PodRightSizingReconciler{
+	reconciler := &controller.PodRightSizingReconciler{
 		Client:          mgr.GetClient(),
 		Scheme:          mgr.GetScheme(),
 		MetricsClient:   metricsClient,
 		RecommendEngine: recommendEngine,
-	}).SetupWithManager(mgr); err != nil {
+	}
+
+	if enableSharding {
+		coordinator, err := newShardCoordinator(mgr, shardLeaseNamespace, shardIdentity, shardLeaseDuration)
+		if err != nil {
+			setupLog.Error(err, "unable to set up sharding")
+			os.Exit(1)
+		}
+		reconciler.Sharder = coordinator
+		reconciler.ShardEvents = make(chan event.GenericEvent, 1024)
+		coordinator.OnRebalance = reconciler.RequeueAll
+
+		if err := mgr.Add(coordinator); err != nil {
+			setupLog.Error(err, "unable to add shard coordinator to manager")
+			os.Exit(1)
+		}
+		setupLog.Info("Sharding enabled", "identity", coordinator.Identity, "namespace", coordinator.Namespace)
+	}
+
+	if err = reconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PodRightSizing")
 		os.Exit(1)
 	}
@@ -266,3 +335,35 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// newShardCoordinator builds the Lease-based shard coordinator, resolving defaults from the pod environment
+func newShardCoordinator(
+	mgr ctrl.Manager,
+	namespace, identity string,
+	leaseDuration time.Duration,
+) (*sharding.Coordinator, error) {
+	if namespace == "" {
+		namespace = os.Getenv("POD_NAMESPACE")
+	}
+	if namespace == "" {
+		data, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+		if err != nil {
+			return nil, fmt.Errorf("shard lease namespace not set and not running in a pod: %w", err)
+		}
+		namespace = strings.TrimSpace(string(data))
+	}
+
+	if identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine shard identity: %w", err)
+		}
+		identity = hostname
+	}
+
+	coordinator := sharding.NewCoordinator(mgr.GetClient(), mgr.GetAPIReader(), namespace, identity)
+	if leaseDuration > 0 {
+		coordinator.LeaseDuration = leaseDuration
+	}
+	return coordinator, nil
+}
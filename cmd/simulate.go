@@ -0,0 +1,309 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/internal/controller"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/analyzer"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+// runSimulate implements the "simulate" subcommand. It fetches the metrics of one workload, runs the
+// recommendation engine with the PodRightSizing's thresholds and each variant, and prints the results.
+func runSimulate(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var specFile, variantsFile, workload, namespace, prometheusURL, output string
+	var useMockMetrics bool
+	fs.StringVar(&specFile, "f", "", "PodRightSizing manifest whose spec is simulated.")
+	fs.StringVar(&variantsFile, "variants", "",
+		"YAML or JSON list of alternative thresholds, each with a name and a thresholds object.")
+	fs.StringVar(&workload, "workload", "", "Workload to simulate as Kind/name, e.g. Deployment/api.")
+	fs.StringVar(&namespace, "namespace", "", "Workload namespace (defaults to spec.target.namespace).")
+	fs.StringVar(&prometheusURL, "prometheus-url", "", "Prometheus server URL (can also be set via PROMETHEUS_URL env var)")
+	fs.BoolVar(&useMockMetrics, "use-mock-metrics", false, "Use mock metrics client for testing")
+	fs.StringVar(&output, "o", "table", "Output format: table or json.")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	fail := func(err error) int {
+		_, _ = fmt.Fprintf(stderr, "simulate: %v\n", err)
+		return 1
+	}
+
+	if specFile == "" || workload == "" {
+		return fail(fmt.Errorf("-f and -workload are required"))
+	}
+	if output != "table" && output != "json" {
+		return fail(fmt.Errorf("unsupported output format %q", output))
+	}
+	workloadType, workloadName, found := strings.Cut(workload, "/")
+	if !found || workloadName == "" {
+		return fail(fmt.Errorf("workload %q must be Kind/name", workload))
+	}
+
+	var prs rightsizingv1alpha1.PodRightSizing
+	if err := decodeFile(specFile, &prs); err != nil {
+		return fail(err)
+	}
+	var variants []analyzer.SimulationVariant
+	if variantsFile != "" {
+		if err := decodeFile(variantsFile, &variants); err != nil {
+			return fail(err)
+		}
+	}
+	if namespace == "" {
+		namespace = prs.Spec.Target.Namespace
+	}
+	if namespace == "" {
+		return fail(fmt.Errorf("-namespace is required when spec.target.namespace is empty"))
+	}
+
+	window, err := rightsizingv1alpha1.ParseAnalysisWindow(prs.Spec.AnalysisWindow)
+	if err != nil {
+		return fail(fmt.Errorf("invalid analysis window: %w", err))
+	}
+
+	if prometheusURL == "" {
+		prometheusURL = os.Getenv("PROMETHEUS_URL")
+	}
+	var metricsClient metrics.Client
+	switch {
+	case useMockMetrics:
+		metricsClient = metrics.NewMockMetricsClient()
+	case prometheusURL != "":
+		metricsClient, err = metrics.NewPrometheusClient(prometheusURL, http.DefaultTransport)
+		if err != nil {
+			return fail(fmt.Errorf("unable to create Prometheus client: %w", err))
+		}
+	default:
+		return fail(fmt.Errorf("-prometheus-url or -use-mock-metrics is required"))
+	}
+
+	ctx := context.Background()
+	workloadMetrics, err := metricsClient.GetWorkloadMetrics(ctx, namespace, workloadName, workloadType, window)
+	if err != nil {
+		return fail(fmt.Errorf("failed to get workload metrics: %w", err))
+	}
+	if len(workloadMetrics.Pods) == 0 {
+		return fail(fmt.Errorf("no metrics found for %s in namespace %s", workload, namespace))
+	}
+
+	// Like the controller, size for the next analysis and complete the recommendations against the pod
+	// template. The template and rollouts only the cluster knows.
+	opts := analyzer.SimulationOptions{Horizon: controller.RecommendationHorizon(&prs)}
+	c, template, err := workloadTemplate(ctx, namespace, workloadType, workloadName)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "simulate: resource policy not applied and savings not estimated: %v\n", err)
+	} else {
+		pod := &corev1.Pod{ObjectMeta: template.ObjectMeta, Spec: template.Spec}
+		pod.Namespace = namespace
+		opts.Complete = func(recommendation *rightsizingv1alpha1.PodRecommendation) bool {
+			return controller.CompleteRecommendation(&prs, pod, recommendation)
+		}
+		opts.Rollouts, err = controller.WorkloadRollouts(ctx, c, namespace, workloadType, workloadName,
+			time.Now().Add(-window))
+		if err != nil {
+			_, _ = fmt.Fprintf(stderr, "simulate: detecting change points without rollouts: %v\n", err)
+		}
+	}
+
+	engine := analyzer.NewRecommendationEngine()
+	results, err := engine.Simulate(ctx, workloadMetrics, prs.Spec, variants, opts)
+	if err != nil {
+		return fail(err)
+	}
+
+	if output == "json" {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			return fail(err)
+		}
+		return 0
+	}
+	// The usage analysis shows the evidence shared by all variants
+	analysis, err := (&analyzer.AdvancedAnalyzer{RecommendationEngine: engine}).AnalyzeWorkloadPatterns(workloadMetrics)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "simulate: usage not analyzed: %v\n", err)
+	} else if err := writeAnalysisTable(stdout, analysis.Summary()); err != nil {
+		return fail(err)
+	}
+	if err := writeSimulationTable(stdout, results); err != nil {
+		return fail(err)
+	}
+	return 0
+}
+
+// decodeFile decodes a YAML or JSON file into out
+func decodeFile(path string, out interface{}) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = file.Close() }()
+
+	if err := utilyaml.NewYAMLOrJSONDecoder(file, 4096).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// workloadTemplate returns a client for the cluster and the pod template of a workload
+func workloadTemplate(
+	ctx context.Context,
+	namespace, workloadType, workloadName string,
+) (client.Client, *corev1.PodTemplateSpec, error) {
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key := types.NamespacedName{Namespace: namespace, Name: workloadName}
+	var template corev1.PodTemplateSpec
+	switch workloadType {
+	case "Deployment":
+		var deployment appsv1.Deployment
+		err = c.Get(ctx, key, &deployment)
+		template = deployment.Spec.Template
+	case "StatefulSet":
+		var statefulSet appsv1.StatefulSet
+		err = c.Get(ctx, key, &statefulSet)
+		template = statefulSet.Spec.Template
+	case "DaemonSet":
+		var daemonSet appsv1.DaemonSet
+		err = c.Get(ctx, key, &daemonSet)
+		template = daemonSet.Spec.Template
+	default:
+		return nil, nil, fmt.Errorf("unsupported workload type: %s", workloadType)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, &template, nil
+}
+
+// writeAnalysisTable prints the usage statistics and pattern of each resource of the workload,
+// followed by the priority of its workload-level recommendations
+func writeAnalysisTable(out io.Writer, summary rightsizingv1alpha1.WorkloadAnalysisSummary) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "RESOURCE\tDATA POINTS\tMIN\tMEAN\tP95\tP99\tMAX\tPATTERN")
+	for _, r := range []struct {
+		name  string
+		usage *rightsizingv1alpha1.ResourceUsageSummary
+	}{{"cpu", summary.CPU}, {"memory", summary.Memory}} {
+		if r.usage == nil {
+			continue
+		}
+		u := r.usage
+		pattern := u.Pattern
+		if pattern == "" {
+			pattern = "-"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.name, u.DataPoints, u.Min.String(), u.Mean.String(), u.P95.String(), u.P99.String(),
+			u.Max.String(), pattern)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(out, "\n%s/%s: %d pods analyzed, priority %s\n\n",
+		summary.WorkloadType, summary.WorkloadName, summary.Pods, summary.Priority)
+	return err
+}
+
+// writeSimulationTable prints one row per pod and variant, so the variants of a pod appear next to
+// each other, followed by a summary per variant
+func writeSimulationTable(out io.Writer, results []analyzer.SimulationResult) error {
+	type row struct {
+		pod     string
+		variant int
+		rec     rightsizingv1alpha1.PodRecommendation
+	}
+	var rows []row
+	for i, result := range results {
+		for _, rec := range result.Recommendations {
+			rows = append(rows, row{pod: rec.PodReference.Name, variant: i, rec: rec})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].pod != rows[j].pod {
+			return rows[i].pod < rows[j].pod
+		}
+		return rows[i].variant < rows[j].variant
+	})
+
+	quantity := func(list corev1.ResourceList, name corev1.ResourceName) string {
+		if q, ok := list[name]; ok {
+			return q.String()
+		}
+		return "-"
+	}
+	orDash := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "POD\tVARIANT\tCPU REQUEST\tCPU LIMIT\tMEMORY REQUEST\tMEMORY LIMIT\tCONFIDENCE\tSAVINGS")
+	for _, r := range rows {
+		resources := r.rec.RecommendedResources
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
+			r.pod, results[r.variant].Name,
+			quantity(resources.Requests, corev1.ResourceCPU), quantity(resources.Limits, corev1.ResourceCPU),
+			quantity(resources.Requests, corev1.ResourceMemory), quantity(resources.Limits, corev1.ResourceMemory),
+			r.rec.Confidence, orDash(r.rec.PotentialSavings.CostSavings))
+	}
+	_, _ = fmt.Fprintln(w)
+
+	_, _ = fmt.Fprintln(w, "VARIANT\tRECOMMENDATIONS\tSKIPPED PODS\tCPU SAVINGS\tMEMORY SAVINGS\tMONTHLY SAVINGS\tERROR")
+	for _, result := range results {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\n",
+			result.Name, len(result.Recommendations), result.SkippedPods,
+			orDash(result.Savings.TotalCPUSavings), orDash(result.Savings.TotalMemorySavings),
+			orDash(result.Savings.EstimatedMonthlySavings), orDash(result.Error))
+	}
+	return w.Flush()
+}
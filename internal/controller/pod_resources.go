@@ -0,0 +1,448 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/analyzer"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+// resourcePolicy holds the spec settings that shape the resources written to containers
+type resourcePolicy struct {
+	limits  rightsizingv1alpha1.LimitPolicy
+	qos     rightsizingv1alpha1.QoSClassPolicy
+	runtime rightsizingv1alpha1.RuntimeAwareness
+	// qosTarget is the QoS class resolved by forPod; empty leaves the class alone
+	qosTarget corev1.PodQOSClass
+	// mainContainer is the first regular container of the pod, set by forPod. A single container without
+	// Guaranteed resources makes a pod Burstable, so a change to Burstable only applies to this one.
+	mainContainer string
+}
+
+// newResourcePolicy returns the resource policy of a PodRightSizing for workloads of class, whose
+// threshold profile may replace the limit policy
+func newResourcePolicy(prs *rightsizingv1alpha1.PodRightSizing, class string) resourcePolicy {
+	opts := analyzer.RecommendationOptions{LimitPolicy: prs.Spec.LimitPolicy}
+	if prs.Spec.Classification.Enabled {
+		opts = opts.WithClassProfile(analyzer.WorkloadClass(class), prs.Spec.Classification)
+	}
+	return resourcePolicy{
+		limits:  opts.LimitPolicy,
+		qos:     prs.Spec.QoSClass,
+		runtime: prs.Spec.RuntimeAwareness,
+	}
+}
+
+// forPod resolves the QoS class a pod should end up in from its current resources
+func (p resourcePolicy) forPod(spec *corev1.PodSpec) resourcePolicy {
+	if len(spec.Containers) > 0 {
+		p.mainContainer = spec.Containers[0].Name
+	}
+	switch p.qos {
+	case rightsizingv1alpha1.QoSClassGuaranteed:
+		p.qosTarget = corev1.PodQOSGuaranteed
+	case rightsizingv1alpha1.QoSClassBurstable:
+		p.qosTarget = corev1.PodQOSBurstable
+	default:
+		p.qosTarget = analyzer.PodQOSClass(spec)
+		// Guaranteed needs CPU limits, so removing them is an explicit change to Burstable
+		if p.qosTarget == corev1.PodQOSGuaranteed && p.limits.RemoveCPULimits {
+			p.qosTarget = corev1.PodQOSBurstable
+		}
+	}
+	return p
+}
+
+// desired returns the resources the named container ends up with when a recommendation is applied to it.
+// Limits below floors are raised to them, whatever the limit policy. A change to Burstable only removes
+// the CPU limit of the main container; other containers keep their limits.
+func (p resourcePolicy) desired(
+	container string,
+	current, recommended corev1.ResourceRequirements,
+	floors corev1.ResourceList,
+) corev1.ResourceRequirements {
+	resources := analyzer.ApplyLimitPolicy(p.limits, current, recommended, floors)
+	if p.qosTarget == corev1.PodQOSBurstable && container != p.mainContainer {
+		return resources
+	}
+	return analyzer.ApplyQoSClass(p.qosTarget, resources)
+}
+
+// applyHeapFloor keeps a memory recommendation at or above the largest heap configured in the containers
+// it applies to, plus overhead. When the floor applies it also returns a heap-resize suggestion for the reason.
+func (p resourcePolicy) applyHeapFloor(
+	recommended corev1.ResourceRequirements,
+	containers []corev1.Container,
+) (corev1.ResourceRequirements, string) {
+	if !p.runtime.Enabled {
+		return recommended, ""
+	}
+
+	overhead := analyzer.DefaultHeapOverheadPercent
+	if p.runtime.HeapOverheadPercent > 0 {
+		overhead = p.runtime.HeapOverheadPercent
+	}
+
+	var heap *analyzer.RuntimeHeap
+	var containerName string
+	for _, container := range containers {
+		if detected := analyzer.DetectRuntimeHeap(container); detected != nil && (heap == nil || detected.Bytes > heap.Bytes) {
+			heap, containerName = detected, container.Name
+		}
+	}
+	if heap == nil {
+		return recommended, ""
+	}
+
+	// Size the memory would have without the heap, the limit when there is one
+	target, ok := recommended.Limits[corev1.ResourceMemory]
+	if !ok {
+		if target, ok = recommended.Requests[corev1.ResourceMemory]; !ok {
+			return recommended, ""
+		}
+	}
+
+	floor := resource.NewQuantity(heap.MemoryFloor(overhead), resource.BinarySI)
+	result := *recommended.DeepCopy()
+	raised := false
+	for _, list := range []corev1.ResourceList{result.Requests, result.Limits} {
+		if quantity, ok := list[corev1.ResourceMemory]; ok && quantity.Cmp(*floor) < 0 {
+			list[corev1.ResourceMemory] = floor.DeepCopy()
+			raised = true
+		}
+	}
+	if !raised {
+		return recommended, ""
+	}
+
+	note := fmt.Sprintf(" Memory kept at %s for the %s heap (%s) of container %s plus %d%% overhead.",
+		floor.String(), heap.Runtime, heap.Flag, containerName, overhead)
+	if suggested := target.Value() * 100 / int64(100+overhead); suggested >= 1024*1024 && suggested < heap.Bytes {
+		note += fmt.Sprintf(" Usage suggests reducing the heap to %s, which would allow %s.",
+			heap.FlagFor(suggested), target.String())
+	}
+	return result, note
+}
+
+// describeQOSChange returns a note for the recommendation reason when applying it changes the pod's QoS class
+func (r *PodRightSizingReconciler) describeQOSChange(
+	pod *corev1.Pod,
+	recommendation rightsizingv1alpha1.PodRecommendation,
+	policy resourcePolicy,
+) string {
+	spec := pod.Spec.DeepCopy()
+	r.updatePodSpecResources(spec, recommendation, policy, logr.Discard(), "pod", pod.Name)
+
+	from, to := analyzer.PodQOSClass(&pod.Spec), analyzer.PodQOSClass(spec)
+	if from == to {
+		return ""
+	}
+	return fmt.Sprintf(" QoS class changes from %s to %s.", from, to)
+}
+
+// isSidecar reports whether an init container is a native sidecar that keeps running next to the regular containers
+func isSidecar(container corev1.Container) bool {
+	return container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways
+}
+
+// initContainerType returns the recommendation type of an init container
+func initContainerType(container corev1.Container) rightsizingv1alpha1.ContainerType {
+	if isSidecar(container) {
+		return rightsizingv1alpha1.ContainerTypeSidecar
+	}
+	return rightsizingv1alpha1.ContainerTypeInit
+}
+
+// effectiveRequests computes the pod's effective requests the way the scheduler does. Init containers
+// run one at a time next to the sidecars started before them, and sidecars keep running next to the
+// regular containers, so the result is the maximum of the init phase peak and the steady state.
+// Pod overhead is left out because it is the same before and after a resize.
+func effectiveRequests(appRequests corev1.ResourceList, initContainers []corev1.Container) corev1.ResourceList {
+	sidecars := corev1.ResourceList{}
+	initPeak := corev1.ResourceList{}
+
+	for _, container := range initContainers {
+		if isSidecar(container) {
+			addResourceList(sidecars, container.Resources.Requests)
+			maxResourceList(initPeak, sidecars)
+			continue
+		}
+
+		running := sidecars.DeepCopy()
+		addResourceList(running, container.Resources.Requests)
+		maxResourceList(initPeak, running)
+	}
+
+	steady := appRequests.DeepCopy()
+	if steady == nil {
+		steady = corev1.ResourceList{}
+	}
+	addResourceList(steady, sidecars)
+	maxResourceList(steady, initPeak)
+
+	return steady
+}
+
+// addResourceList adds every quantity in source to total
+func addResourceList(total, source corev1.ResourceList) {
+	for name, quantity := range source {
+		if existing, ok := total[name]; ok {
+			existing.Add(quantity)
+			total[name] = existing
+		} else {
+			total[name] = quantity.DeepCopy()
+		}
+	}
+}
+
+// maxResourceList raises every quantity in total to at least the one in source
+func maxResourceList(total, source corev1.ResourceList) {
+	for name, quantity := range source {
+		if existing, ok := total[name]; !ok || quantity.Cmp(existing) > 0 {
+			total[name] = quantity.DeepCopy()
+		}
+	}
+}
+
+// calculatePodSavings estimates savings from the change in the pod's effective requests,
+// covering regular, init and sidecar containers
+func (r *PodRightSizingReconciler) calculatePodSavings(
+	pod *corev1.Pod,
+	recommendation rightsizingv1alpha1.PodRecommendation,
+) rightsizingv1alpha1.ResourceSavings {
+	recommendedInit := make([]corev1.Container, len(pod.Spec.InitContainers))
+	for i, container := range pod.Spec.InitContainers {
+		recommendedInit[i] = *container.DeepCopy()
+		for _, containerRec := range recommendation.InitContainers {
+			if containerRec.Name == container.Name {
+				recommendedInit[i].Resources = containerRec.RecommendedResources
+			}
+		}
+	}
+
+	// Resources not covered by the recommendation keep their current requests
+	withRecommended := func(current, recommended corev1.ResourceList) corev1.ResourceList {
+		requests := current.DeepCopy()
+		if requests == nil {
+			requests = corev1.ResourceList{}
+		}
+		for name, quantity := range recommended {
+			requests[name] = quantity
+		}
+		return requests
+	}
+	var recommendedApp corev1.ResourceList
+	if len(pod.Spec.Containers) > 1 {
+		// Only the per-container recommendations are applied to pods with several regular containers
+		recommendedApp = corev1.ResourceList{}
+		for _, container := range pod.Spec.Containers {
+			requests := container.Resources.Requests
+			for _, containerRec := range recommendation.Containers {
+				if containerRec.Name == container.Name {
+					requests = withRecommended(requests, containerRec.RecommendedResources.Requests)
+				}
+			}
+			addResourceList(recommendedApp, requests)
+		}
+	} else {
+		recommendedApp = withRecommended(recommendation.CurrentResources.Requests, recommendation.RecommendedResources.Requests)
+	}
+
+	current := corev1.ResourceRequirements{
+		Requests: effectiveRequests(recommendation.CurrentResources.Requests, pod.Spec.InitContainers),
+	}
+	recommended := corev1.ResourceRequirements{
+		Requests: effectiveRequests(recommendedApp, recommendedInit),
+	}
+
+	return analyzer.NewCostCalculator().CalculateSavings(current, recommended)
+}
+
+// completeContainerRecommendations fills in the current resources of the recommendations for containers,
+// and their type when containerType is set, applies the heap floor, limit policy and QoS class and keeps
+// those that meet the change threshold
+func (r *PodRightSizingReconciler) completeContainerRecommendations(
+	containers []corev1.Container,
+	recommendations []rightsizingv1alpha1.ContainerRecommendation,
+	containerType func(corev1.Container) rightsizingv1alpha1.ContainerType,
+	policy resourcePolicy,
+	thresholdPercent int,
+) []rightsizingv1alpha1.ContainerRecommendation {
+	var completed []rightsizingv1alpha1.ContainerRecommendation
+	for _, recommendation := range recommendations {
+		for _, container := range containers {
+			if container.Name != recommendation.Name {
+				continue
+			}
+
+			if containerType != nil {
+				recommendation.Type = containerType(container)
+			}
+			recommendation.CurrentResources = *container.Resources.DeepCopy()
+			floored, note := policy.applyHeapFloor(recommendation.RecommendedResources, []corev1.Container{container})
+			recommendation.RecommendedResources = policy.desired(container.Name, recommendation.CurrentResources, floored,
+				recommendation.LimitFloors)
+			recommendation.Reason += note
+			if r.meetsChangeThreshold(recommendation.CurrentResources, recommendation.RecommendedResources, thresholdPercent) {
+				completed = append(completed, recommendation)
+			}
+			break
+		}
+	}
+	return completed
+}
+
+// updatePodSpecResources applies a recommendation to a pod template and returns whether anything changed.
+// The pod-level resources only apply to a single regular container, since they cover the regular containers
+// together. Templates with several are sized from the per-container recommendations, and containers without
+// one are left alone. The limit policy is applied against each container's own resources, and the QoS class
+// is resolved from the pod template.
+func (r *PodRightSizingReconciler) updatePodSpecResources(
+	spec *corev1.PodSpec,
+	recommendation rightsizingv1alpha1.PodRecommendation,
+	policy resourcePolicy,
+	logger logr.Logger,
+	workloadType, name string,
+) bool {
+	policy = policy.forPod(spec)
+
+	updated := false
+	if len(spec.Containers) > 1 {
+		updated = r.applyContainerRecommendations(spec.Containers, recommendation.Containers, policy, logger, workloadType, name)
+	} else if len(recommendation.RecommendedResources.Requests) > 0 || len(recommendation.RecommendedResources.Limits) > 0 {
+		updated = r.updateContainerResources(spec.Containers, recommendation.RecommendedResources, recommendation.LimitFloors,
+			policy, logger, workloadType, name)
+	}
+
+	if r.applyContainerRecommendations(spec.InitContainers, recommendation.InitContainers, policy, logger, workloadType, name) {
+		updated = true
+	}
+
+	return updated
+}
+
+// applyContainerRecommendations applies per-container recommendations to the containers they name and
+// returns whether anything changed
+func (r *PodRightSizingReconciler) applyContainerRecommendations(
+	containers []corev1.Container,
+	recommendations []rightsizingv1alpha1.ContainerRecommendation,
+	policy resourcePolicy,
+	logger logr.Logger,
+	workloadType, name string,
+) bool {
+	updated := false
+	for _, containerRec := range recommendations {
+		for i := range containers {
+			container := &containers[i]
+			if container.Name != containerRec.Name {
+				continue
+			}
+			desired := policy.desired(container.Name, container.Resources, containerRec.RecommendedResources,
+				containerRec.LimitFloors)
+			if r.resourcesEqual(container.Resources, desired) {
+				continue
+			}
+
+			logger.Info("Updating container resources",
+				workloadType, name,
+				"container", container.Name,
+				"type", containerRec.Type)
+			container.Resources = desired
+			updated = true
+		}
+	}
+	return updated
+}
+
+// addContainerHistoryFromStatus adds the start times and OOM kills of the regular containers and native
+// sidecars found in pod status, covering the current and the previous run of each container. OOM kills
+// before the analysis window are left out.
+func addContainerHistoryFromStatus(workloadMetrics *metrics.WorkloadMetrics, pods []corev1.Pod) {
+	podsByName := make(map[string]*corev1.Pod, len(pods))
+	for i := range pods {
+		podsByName[pods[i].Name] = &pods[i]
+	}
+
+	for i := range workloadMetrics.Pods {
+		podMetrics := &workloadMetrics.Pods[i]
+		pod, ok := podsByName[podMetrics.PodName]
+		if !ok {
+			continue
+		}
+
+		statuses := append([]corev1.ContainerStatus{}, pod.Status.ContainerStatuses...)
+		for _, status := range pod.Status.InitContainerStatuses {
+			// Sidecars restart like regular containers, other init containers run to completion once
+			if sidecarNamed(pod, status.Name) {
+				statuses = append(statuses, status)
+			}
+		}
+
+		for _, status := range statuses {
+			if running := status.State.Running; running != nil && !running.StartedAt.IsZero() {
+				podMetrics.ContainerStarts = metrics.AddContainerStart(podMetrics.ContainerStarts,
+					metrics.ContainerStart{Time: running.StartedAt.Time, Container: status.Name})
+			}
+			terminated := status.LastTerminationState.Terminated
+			if terminated == nil {
+				continue
+			}
+			if !terminated.StartedAt.IsZero() {
+				podMetrics.ContainerStarts = metrics.AddContainerStart(podMetrics.ContainerStarts,
+					metrics.ContainerStart{Time: terminated.StartedAt.Time, Container: status.Name})
+			}
+			if terminated.Reason == "OOMKilled" && !terminated.FinishedAt.Time.Before(workloadMetrics.StartTime) {
+				podMetrics.OOMKills = metrics.AddOOMKill(podMetrics.OOMKills, metrics.OOMKill{
+					Time:        terminated.FinishedAt.Time,
+					Container:   status.Name,
+					MemoryLimit: containerMemoryLimit(pod, status.Name),
+				})
+			}
+		}
+	}
+}
+
+// sidecarNamed reports whether the named init container of a pod is a native sidecar
+func sidecarNamed(pod *corev1.Pod, name string) bool {
+	for _, container := range pod.Spec.InitContainers {
+		if container.Name == name {
+			return isSidecar(container)
+		}
+	}
+	return false
+}
+
+// containerMemoryLimit returns the memory limit of a regular or init container in bytes, zero when it
+// has none
+func containerMemoryLimit(pod *corev1.Pod, name string) float64 {
+	for _, container := range append(append([]corev1.Container{}, pod.Spec.Containers...), pod.Spec.InitContainers...) {
+		if container.Name != name {
+			continue
+		}
+		if limit, ok := container.Resources.Limits[corev1.ResourceMemory]; ok {
+			return limit.AsApproximateFloat64()
+		}
+	}
+	return 0
+}
@@ -0,0 +1,572 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/analyzer"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+// requirements returns resource requirements with the given CPU and memory requests and memory limit,
+// leaving out empty values
+func requirements(cpuRequest, memoryRequest, memoryLimit string) corev1.ResourceRequirements {
+	resources := corev1.ResourceRequirements{Requests: corev1.ResourceList{}, Limits: corev1.ResourceList{}}
+	if cpuRequest != "" {
+		resources.Requests[corev1.ResourceCPU] = resource.MustParse(cpuRequest)
+	}
+	if memoryRequest != "" {
+		resources.Requests[corev1.ResourceMemory] = resource.MustParse(memoryRequest)
+	}
+	if memoryLimit != "" {
+		resources.Limits[corev1.ResourceMemory] = resource.MustParse(memoryLimit)
+	}
+	return resources
+}
+
+func TestUpdatePodSpecResources_Containers(t *testing.T) {
+	r := &PodRightSizingReconciler{}
+	policy := resourcePolicy{}
+	recommendation := rightsizingv1alpha1.PodRecommendation{
+		RecommendedResources: requirements("600m", "500Mi", "600Mi"),
+		Containers: []rightsizingv1alpha1.ContainerRecommendation{
+			{Name: "app", RecommendedResources: requirements("500m", "400Mi", "480Mi")},
+			{
+				Name:                 "proxy",
+				RecommendedResources: requirements("50m", "40Mi", "48Mi"),
+				LimitFloors:          corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("77Mi")},
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		containers []corev1.Container
+		want       map[string]corev1.ResourceRequirements
+	}{
+		{
+			name:       "a single container gets the pod total",
+			containers: []corev1.Container{{Name: "app", Resources: requirements("1", "1Gi", "1Gi")}},
+			want:       map[string]corev1.ResourceRequirements{"app": requirements("600m", "500Mi", "600Mi")},
+		},
+		{
+			name: "several containers get their own recommendations",
+			containers: []corev1.Container{
+				{Name: "app", Resources: requirements("1", "1Gi", "1Gi")},
+				{Name: "proxy", Resources: requirements("100m", "64Mi", "64Mi")},
+			},
+			want: map[string]corev1.ResourceRequirements{
+				"app":   requirements("500m", "400Mi", "480Mi"),
+				"proxy": requirements("50m", "40Mi", "77Mi"),
+			},
+		},
+		{
+			name: "containers without a recommendation are left alone",
+			containers: []corev1.Container{
+				{Name: "app", Resources: requirements("1", "1Gi", "1Gi")},
+				{Name: "logger", Resources: requirements("100m", "64Mi", "64Mi")},
+			},
+			want: map[string]corev1.ResourceRequirements{
+				"app":    requirements("500m", "400Mi", "480Mi"),
+				"logger": requirements("100m", "64Mi", "64Mi"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &corev1.PodSpec{Containers: tt.containers}
+			assert.True(t, r.updatePodSpecResources(spec, recommendation, policy, logr.Discard(), "deployment", "web"))
+			for _, container := range spec.Containers {
+				assert.True(t, r.resourcesEqual(tt.want[container.Name], container.Resources),
+					"container %s got %v", container.Name, container.Resources)
+			}
+		})
+	}
+}
+
+func TestUpdatePodSpecResources_Burstable(t *testing.T) {
+	r := &PodRightSizingReconciler{}
+	guaranteed := func(cpu, memory string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{
+			Requests: requirements(cpu, memory, "").Requests,
+			Limits:   requirements(cpu, memory, "").Requests,
+		}
+	}
+	mesh := initContainer("mesh", "", "", true)
+	mesh.Resources = guaranteed("100m", "64Mi")
+	spec := &corev1.PodSpec{
+		InitContainers: []corev1.Container{mesh},
+		Containers: []corev1.Container{
+			{Name: "app", Resources: guaranteed("1", "1Gi")},
+			{Name: "proxy", Resources: guaranteed("100m", "64Mi")},
+		},
+	}
+	require.Equal(t, corev1.PodQOSGuaranteed, analyzer.PodQOSClass(spec))
+
+	recommendation := rightsizingv1alpha1.PodRecommendation{
+		Containers: []rightsizingv1alpha1.ContainerRecommendation{
+			{Name: "app", RecommendedResources: guaranteed("500m", "512Mi")},
+			{Name: "proxy", RecommendedResources: guaranteed("50m", "48Mi")},
+		},
+		InitContainers: []rightsizingv1alpha1.ContainerRecommendation{
+			{Name: "mesh", RecommendedResources: guaranteed("50m", "48Mi")},
+		},
+	}
+	policy := resourcePolicy{qos: rightsizingv1alpha1.QoSClassBurstable}
+	assert.True(t, r.updatePodSpecResources(spec, recommendation, policy, logr.Discard(), "deployment", "web"))
+
+	// Only the main container gives up its CPU limit, which is enough to make the pod Burstable
+	assert.Equal(t, corev1.PodQOSBurstable, analyzer.PodQOSClass(spec))
+	_, appLimit := spec.Containers[0].Resources.Limits[corev1.ResourceCPU]
+	assert.False(t, appLimit)
+	assert.True(t, r.resourcesEqual(guaranteed("50m", "48Mi"), spec.Containers[1].Resources),
+		"proxy got %v", spec.Containers[1].Resources)
+	assert.True(t, r.resourcesEqual(guaranteed("50m", "48Mi"), spec.InitContainers[0].Resources),
+		"mesh got %v", spec.InitContainers[0].Resources)
+}
+
+// initContainer returns an init container with the given requests, a native sidecar when sidecar is set
+func initContainer(name, cpuRequest, memoryRequest string, sidecar bool) corev1.Container {
+	container := corev1.Container{Name: name, Resources: requirements(cpuRequest, memoryRequest, "")}
+	if sidecar {
+		always := corev1.ContainerRestartPolicyAlways
+		container.RestartPolicy = &always
+	}
+	return container
+}
+
+// assertQuantity asserts that quantity equals want, or is nil when want is empty
+func assertQuantity(t *testing.T, want string, quantity *resource.Quantity, name string) {
+	t.Helper()
+	if want == "" {
+		assert.Nil(t, quantity, name)
+		return
+	}
+	if assert.NotNil(t, quantity, name) {
+		assert.Zero(t, quantity.Cmp(resource.MustParse(want)), "%s: want %s, got %s", name, want, quantity.String())
+	}
+}
+
+func TestEffectiveRequests(t *testing.T) {
+	tests := []struct {
+		name           string
+		app            corev1.ResourceList
+		initContainers []corev1.Container
+		wantCPU        string
+		wantMemory     string
+	}{
+		{
+			name:       "regular containers only",
+			app:        requirements("500m", "256Mi", "").Requests,
+			wantCPU:    "500m",
+			wantMemory: "256Mi",
+		},
+		{
+			name:           "sidecars run next to the regular containers",
+			app:            requirements("500m", "256Mi", "").Requests,
+			initContainers: []corev1.Container{initContainer("proxy", "100m", "64Mi", true)},
+			wantCPU:        "600m",
+			wantMemory:     "320Mi",
+		},
+		{
+			name: "an init container after a sidecar runs next to it",
+			app:  requirements("500m", "256Mi", "").Requests,
+			initContainers: []corev1.Container{
+				initContainer("proxy", "100m", "64Mi", true),
+				initContainer("migrate", "1", "512Mi", false),
+			},
+			wantCPU:    "1100m",
+			wantMemory: "576Mi",
+		},
+		{
+			name: "an init container before a sidecar runs alone",
+			app:  requirements("500m", "256Mi", "").Requests,
+			initContainers: []corev1.Container{
+				initContainer("migrate", "1", "512Mi", false),
+				initContainer("proxy", "100m", "64Mi", true),
+			},
+			wantCPU:    "1",
+			wantMemory: "512Mi",
+		},
+		{
+			name: "an init container smaller than the steady state does not count",
+			app:  requirements("500m", "256Mi", "").Requests,
+			initContainers: []corev1.Container{
+				initContainer("proxy", "100m", "64Mi", true),
+				initContainer("wait", "50m", "32Mi", false),
+			},
+			wantCPU:    "600m",
+			wantMemory: "320Mi",
+		},
+		{
+			name: "each resource peaks on its own",
+			app:  requirements("500m", "256Mi", "").Requests,
+			initContainers: []corev1.Container{
+				initContainer("compile", "2", "64Mi", false),
+				initContainer("restore", "100m", "1Gi", false),
+			},
+			wantCPU:    "2",
+			wantMemory: "1Gi",
+		},
+		{
+			name: "missing requests count as zero",
+			initContainers: []corev1.Container{
+				initContainer("proxy", "100m", "", true),
+				initContainer("wait", "", "", false),
+			},
+			wantCPU: "100m",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := effectiveRequests(tt.app, tt.initContainers)
+			for name, want := range map[corev1.ResourceName]string{
+				corev1.ResourceCPU:    tt.wantCPU,
+				corev1.ResourceMemory: tt.wantMemory,
+			} {
+				var quantity *resource.Quantity
+				if value, ok := requests[name]; ok {
+					quantity = &value
+				}
+				assertQuantity(t, want, quantity, string(name))
+			}
+		})
+	}
+}
+
+func TestCalculatePodSavings(t *testing.T) {
+	r := &PodRightSizingReconciler{}
+
+	tests := []struct {
+		name           string
+		pod            corev1.PodSpec
+		recommendation rightsizingv1alpha1.PodRecommendation
+		wantCPU        string
+		wantMemory     string
+	}{
+		{
+			name: "a single container",
+			pod:  corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Resources: requirements("1", "1Gi", "")}}},
+			recommendation: rightsizingv1alpha1.PodRecommendation{
+				CurrentResources:     requirements("1", "1Gi", ""),
+				RecommendedResources: requirements("500m", "512Mi", ""),
+			},
+			wantCPU:    "500m",
+			wantMemory: "512Mi",
+		},
+		{
+			name: "sidecars before and after an init container",
+			pod: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					initContainer("proxy", "100m", "64Mi", true),
+					initContainer("migrate", "500m", "256Mi", false),
+					initContainer("logger", "50m", "32Mi", true),
+				},
+				Containers: []corev1.Container{{Name: "app", Resources: requirements("1", "1Gi", "")}},
+			},
+			recommendation: rightsizingv1alpha1.PodRecommendation{
+				CurrentResources:     requirements("1", "1Gi", ""),
+				RecommendedResources: requirements("500m", "512Mi", ""),
+			},
+			// The steady state of 1150m and 1120Mi drops to 650m and 608Mi, above the 600m and 320Mi of the init phase
+			wantCPU:    "500m",
+			wantMemory: "512Mi",
+		},
+		{
+			name: "an init container larger than the app and sidecars",
+			pod: corev1.PodSpec{
+				InitContainers: []corev1.Container{
+					initContainer("proxy", "100m", "64Mi", true),
+					initContainer("migrate", "2", "2Gi", false),
+				},
+				Containers: []corev1.Container{{Name: "app", Resources: requirements("1", "1Gi", "")}},
+			},
+			recommendation: rightsizingv1alpha1.PodRecommendation{
+				CurrentResources:     requirements("1", "1Gi", ""),
+				RecommendedResources: requirements("500m", "512Mi", ""),
+			},
+			// The init phase sets the effective requests either way
+		},
+		{
+			name: "a recommended init container",
+			pod: corev1.PodSpec{
+				InitContainers: []corev1.Container{initContainer("migrate", "2", "2Gi", false)},
+				Containers:     []corev1.Container{{Name: "app", Resources: requirements("1", "1Gi", "")}},
+			},
+			recommendation: rightsizingv1alpha1.PodRecommendation{
+				CurrentResources:     requirements("1", "1Gi", ""),
+				RecommendedResources: requirements("500m", "512Mi", ""),
+				InitContainers: []rightsizingv1alpha1.ContainerRecommendation{
+					{Name: "migrate", RecommendedResources: requirements("250m", "256Mi", "")},
+				},
+			},
+			wantCPU:    "1500m",
+			wantMemory: "1536Mi",
+		},
+		{
+			name: "missing requests",
+			pod:  corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Resources: requirements("1", "", "")}}},
+			recommendation: rightsizingv1alpha1.PodRecommendation{
+				CurrentResources:     requirements("1", "", ""),
+				RecommendedResources: requirements("", "512Mi", ""),
+			},
+			// The CPU request is kept and there is no memory request to save on
+		},
+		{
+			name: "several regular containers",
+			pod: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Resources: requirements("1", "1Gi", "")},
+				{Name: "proxy", Resources: requirements("250m", "128Mi", "")},
+			}},
+			recommendation: rightsizingv1alpha1.PodRecommendation{
+				CurrentResources:     requirements("1250m", "1152Mi", ""),
+				RecommendedResources: requirements("100m", "100Mi", ""),
+				Containers: []rightsizingv1alpha1.ContainerRecommendation{
+					{Name: "app", RecommendedResources: requirements("500m", "512Mi", "")},
+				},
+			},
+			// Only the per-container recommendations apply, the proxy keeps its requests
+			wantCPU:    "500m",
+			wantMemory: "512Mi",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			savings := r.calculatePodSavings(&corev1.Pod{Spec: tt.pod}, tt.recommendation)
+			assertQuantity(t, tt.wantCPU, savings.CPUSavings, "cpu")
+			assertQuantity(t, tt.wantMemory, savings.MemorySavings, "memory")
+		})
+	}
+}
+
+func TestDescribeQOSChange(t *testing.T) {
+	r := &PodRightSizingReconciler{}
+	guaranteed := corev1.ResourceRequirements{
+		Requests: requirements("1", "1Gi", "").Requests,
+		Limits:   requirements("1", "1Gi", "").Requests,
+	}
+	smaller := corev1.ResourceRequirements{
+		Requests: requirements("500m", "512Mi", "").Requests,
+		Limits:   requirements("500m", "512Mi", "").Requests,
+	}
+
+	tests := []struct {
+		name           string
+		resources      corev1.ResourceRequirements
+		recommendation corev1.ResourceRequirements
+		qos            rightsizingv1alpha1.QoSClassPolicy
+		want           string
+	}{
+		{
+			name:           "the class is preserved",
+			resources:      guaranteed,
+			recommendation: smaller,
+		},
+		{
+			name:           "Guaranteed to Burstable",
+			resources:      guaranteed,
+			recommendation: smaller,
+			qos:            rightsizingv1alpha1.QoSClassBurstable,
+			want:           " QoS class changes from Guaranteed to Burstable.",
+		},
+		{
+			name:           "Burstable to Guaranteed",
+			resources:      requirements("1", "1Gi", ""),
+			recommendation: requirements("500m", "512Mi", ""),
+			qos:            rightsizingv1alpha1.QoSClassGuaranteed,
+			want:           " QoS class changes from Burstable to Guaranteed.",
+		},
+		{
+			name:           "BestEffort is preserved",
+			recommendation: requirements("500m", "512Mi", ""),
+		},
+		{
+			name:           "BestEffort to Burstable",
+			recommendation: requirements("500m", "512Mi", ""),
+			qos:            rightsizingv1alpha1.QoSClassBurstable,
+			want:           " QoS class changes from BestEffort to Burstable.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Resources: tt.resources}}}}
+			recommendation := rightsizingv1alpha1.PodRecommendation{
+				CurrentResources:     tt.resources,
+				RecommendedResources: tt.recommendation,
+			}
+			assert.Equal(t, tt.want, r.describeQOSChange(pod, recommendation, resourcePolicy{qos: tt.qos}))
+		})
+	}
+}
+
+func TestApplyHeapFloor(t *testing.T) {
+	java := corev1.Container{
+		Name: "app",
+		Env:  []corev1.EnvVar{{Name: "JAVA_TOOL_OPTIONS", Value: "-XX:+UseG1GC -Xmx1g"}},
+	}
+	node := corev1.Container{Name: "worker", Args: []string{"node --max-old-space-size=512 server.js"}}
+	enabled := rightsizingv1alpha1.RuntimeAwareness{Enabled: true}
+
+	tests := []struct {
+		name        string
+		runtime     rightsizingv1alpha1.RuntimeAwareness
+		containers  []corev1.Container
+		recommended corev1.ResourceRequirements
+		want        corev1.ResourceRequirements
+		wantNote    string
+	}{
+		{
+			name:        "runtime awareness disabled",
+			containers:  []corev1.Container{java},
+			recommended: requirements("", "512Mi", "768Mi"),
+			want:        requirements("", "512Mi", "768Mi"),
+		},
+		{
+			name:        "no heap configured",
+			runtime:     enabled,
+			containers:  []corev1.Container{{Name: "app"}},
+			recommended: requirements("", "512Mi", "768Mi"),
+			want:        requirements("", "512Mi", "768Mi"),
+		},
+		{
+			name:        "memory below the heap plus overhead",
+			runtime:     enabled,
+			containers:  []corev1.Container{java},
+			recommended: requirements("", "512Mi", "768Mi"),
+			want:        requirements("", "1280Mi", "1280Mi"),
+			wantNote: " Memory kept at 1280Mi for the JVM heap (-Xmx1g) of container app plus 25% overhead." +
+				" Usage suggests reducing the heap to -Xmx614m, which would allow 768Mi.",
+		},
+		{
+			name:        "memory above the floor",
+			runtime:     enabled,
+			containers:  []corev1.Container{java},
+			recommended: requirements("", "1536Mi", "2Gi"),
+			want:        requirements("", "1536Mi", "2Gi"),
+		},
+		{
+			name:        "the largest heap with a custom overhead",
+			runtime:     rightsizingv1alpha1.RuntimeAwareness{Enabled: true, HeapOverheadPercent: 50},
+			containers:  []corev1.Container{node, java},
+			recommended: requirements("", "1Gi", ""),
+			want:        requirements("", "1536Mi", ""),
+			wantNote: " Memory kept at 1536Mi for the JVM heap (-Xmx1g) of container app plus 50% overhead." +
+				" Usage suggests reducing the heap to -Xmx682m, which would allow 1Gi.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recommended, note := resourcePolicy{runtime: tt.runtime}.applyHeapFloor(tt.recommended, tt.containers)
+			assert.Equal(t, tt.wantNote, note)
+			assert.True(t, (&PodRightSizingReconciler{}).resourcesEqual(tt.want, recommended), "got %v", recommended)
+		})
+	}
+}
+
+func TestCompleteRecommendation(t *testing.T) {
+	java := corev1.Container{
+		Name:      "app",
+		Env:       []corev1.EnvVar{{Name: "JAVA_TOOL_OPTIONS", Value: "-Xmx1g"}},
+		Resources: requirements("2", "2Gi", "2Gi"),
+	}
+	pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{java}}}
+	prs := &rightsizingv1alpha1.PodRightSizing{Spec: rightsizingv1alpha1.PodRightSizingSpec{
+		RuntimeAwareness: rightsizingv1alpha1.RuntimeAwareness{Enabled: true},
+	}}
+
+	// The heap floor and the limit policy apply, and savings are estimated against the pod
+	recommendation := rightsizingv1alpha1.PodRecommendation{RecommendedResources: requirements("500m", "512Mi", "768Mi")}
+	assert.True(t, CompleteRecommendation(prs, pod, &recommendation))
+	assert.Equal(t, java.Resources, recommendation.CurrentResources)
+	memory := recommendation.RecommendedResources.Requests[corev1.ResourceMemory]
+	assert.Equal(t, "1280Mi", memory.String())
+	assert.Contains(t, recommendation.Reason, "for the JVM heap (-Xmx1g)")
+	assert.NotEmpty(t, recommendation.PotentialSavings.CostSavings)
+
+	// Changes below the change threshold are reported as such
+	recommendation = rightsizingv1alpha1.PodRecommendation{RecommendedResources: requirements("2", "2Gi", "2Gi")}
+	assert.False(t, CompleteRecommendation(prs, pod, &recommendation))
+}
+
+func TestAddContainerHistoryFromStatus(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	oomKilled := func(name string, finished time.Time) corev1.ContainerStatus {
+		return corev1.ContainerStatus{
+			Name:  name,
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(finished.Add(time.Second))}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				Reason:     "OOMKilled",
+				StartedAt:  metav1.NewTime(finished.Add(-time.Hour)),
+				FinishedAt: metav1.NewTime(finished),
+			}},
+		}
+	}
+	mesh := initContainer("mesh", "100m", "64Mi", true)
+	mesh.Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("128Mi")}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{initContainer("migrate", "100m", "64Mi", false), mesh},
+			Containers:     []corev1.Container{{Name: "app", Resources: requirements("1", "1Gi", "1Gi")}},
+		},
+		Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{
+				oomKilled("migrate", now.Add(-3*time.Hour)),
+				oomKilled("mesh", now.Add(-2*time.Hour)),
+			},
+			ContainerStatuses: []corev1.ContainerStatus{oomKilled("app", now.Add(-time.Hour))},
+		},
+	}
+	workloadMetrics := &metrics.WorkloadMetrics{
+		StartTime: now.Add(-24 * time.Hour),
+		Pods:      []metrics.PodMetrics{{PodName: "web-0"}},
+	}
+
+	addContainerHistoryFromStatus(workloadMetrics, []corev1.Pod{pod})
+
+	// Sidecars are covered like regular containers, init containers that ran once are not
+	kills := workloadMetrics.Pods[0].OOMKills
+	require.Len(t, kills, 2)
+	byContainer := map[string]metrics.OOMKill{}
+	for _, kill := range kills {
+		byContainer[kill.Container] = kill
+	}
+	assert.InDelta(t, 128*1024*1024, byContainer["mesh"].MemoryLimit, 1e-9)
+	assert.True(t, byContainer["mesh"].Time.Equal(now.Add(-2*time.Hour)))
+	assert.InDelta(t, 1024*1024*1024, byContainer["app"].MemoryLimit, 1e-9)
+
+	var started []string
+	for _, start := range workloadMetrics.Pods[0].ContainerStarts {
+		started = append(started, start.Container)
+	}
+	assert.ElementsMatch(t, []string{"mesh", "mesh", "app", "app"}, started)
+}
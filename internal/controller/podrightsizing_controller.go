@@ -20,7 +20,9 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -41,6 +43,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
 	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/analyzer"
@@ -52,12 +55,25 @@ const (
 	WorkloadTypeDaemonSet   = "DaemonSet"
 )
 
+// defaultAnalysisConcurrency is used when spec.analysisConcurrency is not set
+const defaultAnalysisConcurrency = 4
+
+// Sharder decides which PodRightSizing objects this replica reconciles
+type Sharder interface {
+	Owns(key string) bool
+}
+
 // PodRightSizingReconciler reconciles a PodRightSizing object
 type PodRightSizingReconciler struct {
 	client.Client
 	Scheme          *runtime.Scheme
 	MetricsClient   analyzer.MetricsClientInterface // Use interface
 	RecommendEngine *analyzer.RecommendationEngine
+
+	// Sharder is optional; when set, objects owned by other replicas are skipped
+	Sharder Sharder
+	// ShardEvents re-enqueues all objects after shard membership changes
+	ShardEvents chan event.GenericEvent
 }
 
 //+kubebuilder:rbac:groups=rightsizing.k8s-rightsizer.io,resources=podrightsizings,verbs=get;list;watch;create;update;patch;delete
@@ -70,6 +86,12 @@ type PodRightSizingReconciler struct {
 // Reconcile handles PodRightSizing custom resources
 func (r *PodRightSizingReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
+	// Skip objects that belong to another replica in sharded mode
+	if r.Sharder != nil && !r.Sharder.Owns(req.NamespacedName.String()) {
+		logger.V(1).Info("Skipping PodRightSizing owned by another shard", "podrightsizing", req.NamespacedName)
+		return ctrl.Result{}, nil
+	}
+
 	logger.Info("Starting reconciliation", "podrightsizing", req.NamespacedName)
 
 	// Fetch the PodRightSizing instance
@@ -89,6 +111,22 @@ func (r *PodRightSizingReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return r.requeueAfter(&podRightSizing), nil
 	}
 
+	// An invalid window or recommender cannot be fixed by retrying; the spec update triggers the next reconcile
+	if _, err := rightsizingv1alpha1.ParseAnalysisWindow(podRightSizing.Spec.AnalysisWindow); err != nil {
+		logger.Error(err, "Invalid analysis window")
+		if updateErr := r.updatePhase(ctx, &podRightSizing, rightsizingv1alpha1.PhaseError, fmt.Sprintf("Invalid analysis window: %v", err)); updateErr != nil {
+			logger.Error(updateErr, "Failed to update phase to error")
+		}
+		return ctrl.Result{}, nil
+	}
+	if _, err := r.RecommendEngine.NewRecommender(podRightSizing.Spec.Recommender); err != nil {
+		logger.Error(err, "Invalid recommender")
+		if updateErr := r.updatePhase(ctx, &podRightSizing, rightsizingv1alpha1.PhaseError, fmt.Sprintf("Invalid recommender: %v", err)); updateErr != nil {
+			logger.Error(updateErr, "Failed to update phase to error")
+		}
+		return ctrl.Result{}, nil
+	}
+
 	// Update phase to analyzing
 	if err := r.updatePhase(ctx, &podRightSizing, rightsizingv1alpha1.PhaseAnalyzing, "Starting resource analysis"); err != nil {
 		return ctrl.Result{}, err
@@ -127,22 +165,11 @@ func (r *PodRightSizingReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	}
 
 	// Generate recommendations for each workload
-	var allRecommendations []rightsizingv1alpha1.PodRecommendation
-
-	for workloadKey, pods := range workloadGroups {
-		logger.Info("Processing workload", "workload", workloadKey, "pods", len(pods))
-
-		recommendations, err := r.generateWorkloadRecommendations(ctx, &podRightSizing, workloadKey, pods)
-		if err != nil {
-			logger.Error(err, "Failed to generate recommendations", "workload", workloadKey)
-			continue
-		}
-
-		allRecommendations = append(allRecommendations, recommendations...)
-	}
+	allRecommendations, workloadAnalyses := r.analyzeWorkloads(ctx, &podRightSizing, workloadGroups)
 
-	// Update recommendations in status
+	// Update recommendations and the analyses behind them in status
 	podRightSizing.Status.Recommendations = allRecommendations
+	podRightSizing.Status.WorkloadAnalyses = workloadAnalyses
 	podRightSizing.Status.LastAnalysisTime = &metav1.Time{Time: time.Now()}
 
 	// Apply recommendations if not in dry-run mode
@@ -178,6 +205,80 @@ func (r *PodRightSizingReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	return r.requeueAfter(&podRightSizing), nil
 }
 
+// workloadResult holds the recommendations and usage analysis generated for one workload
+type workloadResult struct {
+	recommendations []rightsizingv1alpha1.PodRecommendation
+	analysis        *rightsizingv1alpha1.WorkloadAnalysisSummary
+}
+
+// analyzeWorkloads generates recommendations and usage analyses for all workload groups using a bounded
+// worker pool
+func (r *PodRightSizingReconciler) analyzeWorkloads(
+	ctx context.Context,
+	prs *rightsizingv1alpha1.PodRightSizing,
+	workloadGroups map[string][]corev1.Pod,
+) ([]rightsizingv1alpha1.PodRecommendation, []rightsizingv1alpha1.WorkloadAnalysisSummary) {
+	logger := log.FromContext(ctx)
+
+	workers := int(prs.Spec.AnalysisConcurrency)
+	if workers <= 0 {
+		workers = defaultAnalysisConcurrency
+	}
+	if workers > len(workloadGroups) {
+		workers = len(workloadGroups)
+	}
+
+	// Sort keys so that status recommendations are stable between runs
+	workloadKeys := make([]string, 0, len(workloadGroups))
+	for workloadKey := range workloadGroups {
+		workloadKeys = append(workloadKeys, workloadKey)
+	}
+	sort.Strings(workloadKeys)
+
+	results := make([]workloadResult, len(workloadKeys))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				workloadKey := workloadKeys[i]
+				pods := workloadGroups[workloadKey]
+				logger.Info("Processing workload", "workload", workloadKey, "pods", len(pods))
+
+				recommendations, analysis, err := r.generateWorkloadRecommendations(ctx, prs, workloadKey, pods)
+				if err != nil {
+					logger.Error(err, "Failed to generate recommendations", "workload", workloadKey)
+					continue
+				}
+				results[i] = workloadResult{recommendations: recommendations, analysis: analysis}
+			}
+		}()
+	}
+
+	for i := range workloadKeys {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var allRecommendations []rightsizingv1alpha1.PodRecommendation
+	var workloadAnalyses []rightsizingv1alpha1.WorkloadAnalysisSummary
+	for _, result := range results {
+		allRecommendations = append(allRecommendations, result.recommendations...)
+		if result.analysis != nil {
+			workloadAnalyses = append(workloadAnalyses, *result.analysis)
+		}
+	}
+
+	return allRecommendations, workloadAnalyses
+}
+
 // shouldRunAnalysis determines if analysis should run based on schedule
 func (r *PodRightSizingReconciler) shouldRunAnalysis(prs *rightsizingv1alpha1.PodRightSizing) bool {
 	// Always run if no previous analysis
@@ -199,6 +300,21 @@ func (r *PodRightSizingReconciler) shouldRunAnalysis(prs *rightsizingv1alpha1.Po
 	return time.Now().After(nextRun) || time.Now().Equal(nextRun)
 }
 
+// RecommendationHorizon returns how long recommendations must hold: until the next scheduled analysis,
+// plus the minimum stability period before they may be replaced
+func RecommendationHorizon(prs *rightsizingv1alpha1.PodRightSizing) time.Duration {
+	horizon := time.Hour
+	if schedule, err := cron.ParseStandard(prs.Spec.Schedule); err == nil {
+		horizon = time.Until(schedule.Next(time.Now()))
+	}
+
+	// The webhook rejects invalid periods, so a parse error only means there is none
+	if stability, err := rightsizingv1alpha1.ParseDuration(prs.Spec.UpdatePolicy.MinStabilityPeriod); err == nil {
+		horizon += stability
+	}
+	return horizon
+}
+
 // requeueAfter calculates when to requeue based on cron schedule
 func (r *PodRightSizingReconciler) requeueAfter(prs *rightsizingv1alpha1.PodRightSizing) ctrl.Result {
 	// Parse the cron schedule
@@ -401,29 +517,82 @@ func (r *PodRightSizingReconciler) getWorkloadName(ctx context.Context, pod *cor
 	return pod.Name
 }
 
-// generateWorkloadRecommendations generates recommendations for a workload
+// deploymentRevisionAnnotation holds the revision of a Deployment's ReplicaSet
+const deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+
+// WorkloadRollouts returns the pod template changes of a workload since the given time, oldest first.
+// Deployments roll out a new ReplicaSet per template; other workload types report none. Only the
+// ReplicaSets matching the Deployment's selector are listed, and of those the ones it controls count.
+func WorkloadRollouts(
+	ctx context.Context,
+	c client.Reader,
+	namespace, workloadType, workloadName string,
+	since time.Time,
+) ([]analyzer.Rollout, error) {
+	if workloadType != WorkloadTypeDeployment {
+		return nil, nil
+	}
+
+	var deployment appsv1.Deployment
+	if err := c.Get(ctx, types.NamespacedName{Name: workloadName, Namespace: namespace}, &deployment); err != nil {
+		return nil, fmt.Errorf("failed to get deployment %s/%s: %w", namespace, workloadName, err)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector of deployment %s/%s: %w", namespace, workloadName, err)
+	}
+
+	var replicaSets appsv1.ReplicaSetList
+	if err := c.List(ctx, &replicaSets, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("failed to list replica sets of deployment %s/%s: %w", namespace, workloadName, err)
+	}
+
+	var rollouts []analyzer.Rollout
+	for _, rs := range replicaSets.Items {
+		owner := metav1.GetControllerOf(&rs)
+		if owner == nil || owner.UID != deployment.UID {
+			continue
+		}
+		if !rs.CreationTimestamp.Time.After(since) {
+			continue
+		}
+
+		rollout := analyzer.Rollout{
+			Time:     rs.CreationTimestamp.Time,
+			Revision: rs.Annotations[deploymentRevisionAnnotation],
+		}
+		for _, container := range rs.Spec.Template.Spec.Containers {
+			rollout.Images = append(rollout.Images, container.Image)
+		}
+		rollouts = append(rollouts, rollout)
+	}
+	sort.Slice(rollouts, func(i, j int) bool { return rollouts[i].Time.Before(rollouts[j].Time) })
+	return rollouts, nil
+}
+
+// generateWorkloadRecommendations generates recommendations for a workload along with a summary of the
+// usage analysis behind them. The summary is nil when the usage could not be analyzed.
 func (r *PodRightSizingReconciler) generateWorkloadRecommendations(
 	ctx context.Context,
 	prs *rightsizingv1alpha1.PodRightSizing,
 	workloadKey string,
 	pods []corev1.Pod,
-) ([]rightsizingv1alpha1.PodRecommendation, error) {
+) ([]rightsizingv1alpha1.PodRecommendation, *rightsizingv1alpha1.WorkloadAnalysisSummary, error) {
 
 	logger := log.FromContext(ctx)
 
 	// Parse workload key
 	parts := r.splitWorkloadKey(workloadKey)
 	if len(parts) != 3 {
-		return nil, fmt.Errorf("invalid workload key: %s", workloadKey)
+		return nil, nil, fmt.Errorf("invalid workload key: %s", workloadKey)
 	}
 
 	namespace, workloadType, workloadName := parts[0], parts[1], parts[2]
 
 	// Parse analysis window
-	window, err := time.ParseDuration(prs.Spec.AnalysisWindow)
+	window, err := rightsizingv1alpha1.ParseAnalysisWindow(prs.Spec.AnalysisWindow)
 	if err != nil {
-		window = 7 * 24 * time.Hour // Default to 7 days
-		logger.Info("Using default analysis window", "window", window)
+		return nil, nil, fmt.Errorf("invalid analysis window: %w", err)
 	}
 
 	// Collect metrics for the workload
@@ -431,35 +600,76 @@ func (r *PodRightSizingReconciler) generateWorkloadRecommendations(
 	workloadMetrics, err := r.MetricsClient.GetWorkloadMetrics(ctx, namespace, workloadName, workloadType, window)
 	if err != nil {
 		logger.Error(err, "Failed to get workload metrics", "workload", workloadKey)
-		return nil, err
+		return nil, nil, err
 	}
 
 	if len(workloadMetrics.Pods) == 0 {
 		logger.Info("No metrics found for workload", "workload", workloadKey)
-		return nil, fmt.Errorf("no metrics found for workload %s", workloadKey)
+		return nil, nil, fmt.Errorf("no metrics found for workload %s", workloadKey)
+	}
+
+	// Pod status knows about restarts and OOM kills the metrics backend may not report
+	addContainerHistoryFromStatus(workloadMetrics, pods)
+
+	opts := analyzer.RecommendationOptions{
+		Thresholds:    prs.Spec.Thresholds,
+		LimitPolicy:   prs.Spec.LimitPolicy,
+		Recommender:   prs.Spec.Recommender,
+		Horizon:       RecommendationHorizon(prs),
+		OutlierFilter: prs.Spec.OutlierFilter,
+		Exclusions:    prs.Spec.AnalysisExclusions,
+	}
+
+	// Usage shifts at a rollout reset the history, so a release is not sized on the usage of the last one
+	rollouts, err := WorkloadRollouts(ctx, r.Client, namespace, workloadType, workloadName, time.Now().Add(-window))
+	if err != nil {
+		logger.Info("Detecting change points without rollouts", "workload", workloadKey, "reason", err.Error())
+	}
+	opts.Rollouts = rollouts
+
+	// Adapt the thresholds to the workload's usage pattern
+	var workloadClass, classNote string
+	opts, classification, err := opts.Classify(workloadMetrics, prs.Spec.Classification)
+	if err != nil {
+		logger.Info("Using spec thresholds, workload could not be classified", "workload", workloadKey, "reason", err.Error())
+	} else if classification != nil {
+		workloadClass = string(classification.Class)
+		classNote = " " + analyzer.DescribeClassProfile(classification, opts) + "."
+		logger.Info("Classified workload", "workload", workloadKey,
+			"class", classification.Class, "confidence", classification.Confidence)
+	}
+
+	// Summarize the usage behind the recommendations for the status
+	var analysisSummary *rightsizingv1alpha1.WorkloadAnalysisSummary
+	advancedAnalyzer := &analyzer.AdvancedAnalyzer{RecommendationEngine: r.RecommendEngine}
+	if analysis, err := advancedAnalyzer.AnalyzeWorkloadPatterns(workloadMetrics); err != nil {
+		logger.Info("Skipping workload analysis summary", "workload", workloadKey, "reason", err.Error())
+	} else {
+		summary := analysis.Summary()
+		summary.WorkloadClass = workloadClass
+		analysisSummary = &summary
 	}
 
 	// Generate recommendations using the recommendation engine
 	logger.Info("Calling recommendation engine", "workload", workloadKey, "podCount", len(workloadMetrics.Pods))
-	recommendations, err := r.RecommendEngine.GenerateRecommendations(ctx, workloadMetrics, prs.Spec.Thresholds)
+	recommendations, err := r.RecommendEngine.GenerateRecommendationsWithOptions(ctx, workloadMetrics, opts)
 	if err != nil {
 		logger.Error(err, "Failed to generate recommendations", "workload", workloadKey)
-		return nil, err
+		return nil, nil, err
 	}
 	logger.Info("Raw recommendations from engine", "workload", workloadKey, "count", len(recommendations))
 
 	// Enhance recommendations with workload information and filter based on change threshold
 	var filteredRecommendations []rightsizingv1alpha1.PodRecommendation
-	minChangeThreshold := 10 // default 10%
-	if prs.Spec.Thresholds.MinChangeThreshold > 0 {
-		minChangeThreshold = prs.Spec.Thresholds.MinChangeThreshold
-	}
+	minChangeThreshold := r.changeThreshold(prs)
 
 	// For mock data, when pod names don't match, use the first available pod as a representative sample
 	// This is a temporary workaround for testing purposes
 	for i := range recommendations {
 		recommendations[i].PodReference.WorkloadType = workloadType
 		recommendations[i].PodReference.WorkloadName = workloadName
+		recommendations[i].WorkloadClass = workloadClass
+		recommendations[i].Reason += classNote
 
 		// Try to find matching pod by name first
 		var matchedPod *corev1.Pod
@@ -479,11 +689,7 @@ func (r *PodRightSizingReconciler) generateWorkloadRecommendations(
 		}
 
 		if matchedPod != nil {
-			currentResources := r.getCurrentResources(matchedPod)
-			recommendations[i].CurrentResources = currentResources
-
-			// Check if the recommendation meets the minimum change threshold
-			if r.meetsChangeThreshold(currentResources, recommendations[i].RecommendedResources, minChangeThreshold) {
+			if r.completeRecommendation(prs, matchedPod, &recommendations[i], minChangeThreshold) {
 				logger.Info("Recommendation meets change threshold", "pod", recommendations[i].PodReference.Name, "threshold", minChangeThreshold)
 				filteredRecommendations = append(filteredRecommendations, recommendations[i])
 			} else {
@@ -497,7 +703,57 @@ func (r *PodRightSizingReconciler) generateWorkloadRecommendations(
 	recommendations = filteredRecommendations
 
 	logger.Info("Generated recommendations", "workload", workloadKey, "count", len(recommendations))
-	return recommendations, nil
+	return recommendations, analysisSummary, nil
+}
+
+// CompleteRecommendation applies the resource policy of prs to an engine recommendation for pod the way
+// an analysis does: it fills in the current resources, applies heap floors, the limit policy and the QoS
+// class, completes the container recommendations and estimates savings. It reports whether the
+// recommendation meets the change threshold.
+func CompleteRecommendation(
+	prs *rightsizingv1alpha1.PodRightSizing,
+	pod *corev1.Pod,
+	recommendation *rightsizingv1alpha1.PodRecommendation,
+) bool {
+	r := &PodRightSizingReconciler{}
+	return r.completeRecommendation(prs, pod, recommendation, r.changeThreshold(prs))
+}
+
+// completeRecommendation implements CompleteRecommendation with the given change threshold
+func (r *PodRightSizingReconciler) completeRecommendation(
+	prs *rightsizingv1alpha1.PodRightSizing,
+	pod *corev1.Pod,
+	recommendation *rightsizingv1alpha1.PodRecommendation,
+	minChangeThreshold int,
+) bool {
+	currentResources := r.getCurrentResources(pod)
+	recommendation.CurrentResources = currentResources
+	policy := newResourcePolicy(prs, recommendation.WorkloadClass).forPod(&pod.Spec)
+	floored, heapNote := policy.applyHeapFloor(recommendation.RecommendedResources, pod.Spec.Containers)
+	// The pod-level resources only apply to pods with a single regular container, the main one
+	recommendation.RecommendedResources = policy.desired(policy.mainContainer, currentResources, floored,
+		recommendation.LimitFloors)
+	recommendation.Reason += heapNote
+	recommendation.Containers = r.completeContainerRecommendations(
+		pod.Spec.Containers, recommendation.Containers, nil, policy, minChangeThreshold)
+	recommendation.InitContainers = r.completeContainerRecommendations(
+		pod.Spec.InitContainers, recommendation.InitContainers, initContainerType, policy, minChangeThreshold)
+	recommendation.Reason += r.describeQOSChange(pod, *recommendation, policy)
+	recommendation.PotentialSavings = r.calculatePodSavings(pod, *recommendation)
+
+	// Check if the recommendation meets the minimum change threshold for any container. The pod-level
+	// resources only count for pods with a single regular container, the only ones they apply to.
+	return (len(pod.Spec.Containers) == 1 &&
+		r.meetsChangeThreshold(currentResources, recommendation.RecommendedResources, minChangeThreshold)) ||
+		len(recommendation.Containers) > 0 || len(recommendation.InitContainers) > 0
+}
+
+// changeThreshold returns the minimum change percentage required to update resources
+func (r *PodRightSizingReconciler) changeThreshold(prs *rightsizingv1alpha1.PodRightSizing) int {
+	if prs.Spec.Thresholds.MinChangeThreshold > 0 {
+		return prs.Spec.Thresholds.MinChangeThreshold
+	}
+	return 10 // default 10%
 }
 
 // meetsChangeThreshold checks if the recommended resources differ from current resources by at least the threshold percentage
@@ -507,40 +763,24 @@ func (r *PodRightSizingReconciler) meetsChangeThreshold(
 ) bool {
 	threshold := float64(thresholdPercent) / 100.0
 
-	// Check CPU request change
-	currentCPU := current.Requests[corev1.ResourceCPU]
-	recommendedCPU := recommended.Requests[corev1.ResourceCPU]
+	for _, resourceName := range []corev1.ResourceName{
+		corev1.ResourceCPU,
+		corev1.ResourceMemory,
+		corev1.ResourceEphemeralStorage,
+	} {
+		currentQuantity := current.Requests[resourceName]
+		recommendedQuantity := recommended.Requests[resourceName]
 
-	// Handle missing/zero resources more gracefully
-	if currentCPU.IsZero() && recommendedCPU.IsZero() {
-	} else if currentCPU.IsZero() && !recommendedCPU.IsZero() {
-		return true
-	} else if !currentCPU.IsZero() && recommendedCPU.IsZero() {
-		return true
-	} else if !currentCPU.IsZero() && !recommendedCPU.IsZero() {
-		currentValue := currentCPU.AsApproximateFloat64()
-		recommendedValue := recommendedCPU.AsApproximateFloat64()
-		if currentValue > 0 {
-			change := abs((recommendedValue - currentValue) / currentValue)
-			if change >= threshold {
-				return true
-			}
+		// Handle missing/zero resources more gracefully
+		if currentQuantity.IsZero() && recommendedQuantity.IsZero() {
+			continue
+		}
+		if currentQuantity.IsZero() || recommendedQuantity.IsZero() {
+			return true
 		}
-	}
-
-	// Check Memory request change
-	currentMem := current.Requests[corev1.ResourceMemory]
-	recommendedMem := recommended.Requests[corev1.ResourceMemory]
 
-	// Handle missing/zero resources more gracefully
-	if currentMem.IsZero() && recommendedMem.IsZero() {
-	} else if currentMem.IsZero() && !recommendedMem.IsZero() {
-		return true
-	} else if !currentMem.IsZero() && recommendedMem.IsZero() {
-		return true
-	} else if !currentMem.IsZero() && !recommendedMem.IsZero() {
-		currentValue := currentMem.AsApproximateFloat64()
-		recommendedValue := recommendedMem.AsApproximateFloat64()
+		currentValue := currentQuantity.AsApproximateFloat64()
+		recommendedValue := recommendedQuantity.AsApproximateFloat64()
 		if currentValue > 0 {
 			change := abs((recommendedValue - currentValue) / currentValue)
 			if change >= threshold {
@@ -560,7 +800,8 @@ func abs(x float64) float64 {
 	return x
 }
 
-// getCurrentResources extracts current resource requirements from a pod
+// getCurrentResources extracts current resource requirements of the regular containers from a pod.
+// Init and sidecar containers are reported separately in PodRecommendation.InitContainers.
 func (r *PodRightSizingReconciler) getCurrentResources(pod *corev1.Pod) corev1.ResourceRequirements {
 	totalRequests := make(corev1.ResourceList)
 	totalLimits := make(corev1.ResourceList)
@@ -568,8 +809,10 @@ func (r *PodRightSizingReconciler) getCurrentResources(pod *corev1.Pod) corev1.R
 	for _, container := range pod.Spec.Containers {
 		r.addResourceToTotal(totalRequests, container.Resources.Requests, corev1.ResourceCPU)
 		r.addResourceToTotal(totalRequests, container.Resources.Requests, corev1.ResourceMemory)
+		r.addResourceToTotal(totalRequests, container.Resources.Requests, corev1.ResourceEphemeralStorage)
 		r.addResourceToTotal(totalLimits, container.Resources.Limits, corev1.ResourceCPU)
 		r.addResourceToTotal(totalLimits, container.Resources.Limits, corev1.ResourceMemory)
+		r.addResourceToTotal(totalLimits, container.Resources.Limits, corev1.ResourceEphemeralStorage)
 	}
 
 	return corev1.ResourceRequirements{
@@ -653,14 +896,20 @@ func (r *PodRightSizingReconciler) applyWorkloadRecommendations(
 	// Calculate average recommended resources across all pods in the workload
 	avgRecommendation := r.calculateAverageRecommendation(recommendations)
 
+	// Recommendations kept only for their per-container recommendations leave the pod-level resources alone
+	if !r.meetsChangeThreshold(avgRecommendation.CurrentResources, avgRecommendation.RecommendedResources, r.changeThreshold(prs)) {
+		avgRecommendation.RecommendedResources = corev1.ResourceRequirements{}
+	}
+
 	// Apply based on workload type
+	policy := newResourcePolicy(prs, avgRecommendation.WorkloadClass)
 	switch workloadType {
 	case "Deployment":
-		return r.updateDeployment(ctx, namespace, workloadName, avgRecommendation)
+		return r.updateDeployment(ctx, namespace, workloadName, avgRecommendation, policy)
 	case "StatefulSet":
-		return r.updateStatefulSet(ctx, namespace, workloadName, avgRecommendation)
+		return r.updateStatefulSet(ctx, namespace, workloadName, avgRecommendation, policy)
 	case "DaemonSet":
-		return r.updateDaemonSet(ctx, namespace, workloadName, avgRecommendation)
+		return r.updateDaemonSet(ctx, namespace, workloadName, avgRecommendation, policy)
 	default:
 		logger.Info("Workload type not supported for automatic updates", "type", workloadType)
 		return 0, nil
@@ -668,18 +917,20 @@ func (r *PodRightSizingReconciler) applyWorkloadRecommendations(
 }
 
 // calculateAverageRecommendation calculates average resource recommendations
-func (r *PodRightSizingReconciler) calculateAverageRecommendation(recommendations []rightsizingv1alpha1.PodRecommendation) corev1.ResourceRequirements {
+func (r *PodRightSizingReconciler) calculateAverageRecommendation(
+	recommendations []rightsizingv1alpha1.PodRecommendation,
+) rightsizingv1alpha1.PodRecommendation {
 	if len(recommendations) == 0 {
-		return corev1.ResourceRequirements{}
+		return rightsizingv1alpha1.PodRecommendation{}
 	}
 
 	// For simplicity, use the first recommendation as the template
 	// In a more sophisticated implementation, you might average across all pods
-	return recommendations[0].RecommendedResources
+	return *recommendations[0].DeepCopy()
 }
 
 // updateDeployment updates a Deployment with new resource recommendations.
-func (r *PodRightSizingReconciler) updateDeployment(ctx context.Context, namespace, name string, resources corev1.ResourceRequirements) (int, error) {
+func (r *PodRightSizingReconciler) updateDeployment(ctx context.Context, namespace, name string, recommendation rightsizingv1alpha1.PodRecommendation, policy resourcePolicy) (int, error) {
 	logger := log.FromContext(ctx)
 
 	var deployment appsv1.Deployment
@@ -688,7 +939,7 @@ func (r *PodRightSizingReconciler) updateDeployment(ctx context.Context, namespa
 	}
 
 	// Update container resources using helper
-	updated := r.updateContainerResources(deployment.Spec.Template.Spec.Containers, resources, logger, "deployment", name)
+	updated := r.updatePodSpecResources(&deployment.Spec.Template.Spec, recommendation, policy, logger, "deployment", name)
 
 	if !updated {
 		logger.Info("No resource changes needed", "deployment", name)
@@ -705,31 +956,31 @@ func (r *PodRightSizingReconciler) updateDeployment(ctx context.Context, namespa
 }
 
 // updateStatefulSet updates a StatefulSet with new resource recommendations.
-func (r *PodRightSizingReconciler) updateStatefulSet(ctx context.Context, namespace, name string, resources corev1.ResourceRequirements) (int, error) {
+func (r *PodRightSizingReconciler) updateStatefulSet(ctx context.Context, namespace, name string, recommendation rightsizingv1alpha1.PodRecommendation, policy resourcePolicy) (int, error) {
 	var statefulSet appsv1.StatefulSet
 	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &statefulSet); err != nil {
 		return 0, fmt.Errorf("failed to get statefulset %s/%s: %w", namespace, name, err)
 	}
 
-	return r.updateWorkloadResources(ctx, &statefulSet, statefulSet.Spec.Template.Spec.Containers, resources, "statefulset", name)
+	return r.updateWorkloadResources(ctx, &statefulSet, &statefulSet.Spec.Template.Spec, recommendation, policy, "statefulset", name)
 }
 
 // updateDaemonSet updates a DaemonSet with new resource recommendations.
-func (r *PodRightSizingReconciler) updateDaemonSet(ctx context.Context, namespace, name string, resources corev1.ResourceRequirements) (int, error) {
+func (r *PodRightSizingReconciler) updateDaemonSet(ctx context.Context, namespace, name string, recommendation rightsizingv1alpha1.PodRecommendation, policy resourcePolicy) (int, error) {
 	var daemonSet appsv1.DaemonSet
 	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, &daemonSet); err != nil {
 		return 0, fmt.Errorf("failed to get daemonset %s/%s: %w", namespace, name, err)
 	}
 
-	return r.updateWorkloadResources(ctx, &daemonSet, daemonSet.Spec.Template.Spec.Containers, resources, "daemonset", name)
+	return r.updateWorkloadResources(ctx, &daemonSet, &daemonSet.Spec.Template.Spec, recommendation, policy, "daemonset", name)
 }
 
 // updateWorkloadResources is a generic helper for updating workload resources.
-func (r *PodRightSizingReconciler) updateWorkloadResources(ctx context.Context, obj client.Object, containers []corev1.Container, resources corev1.ResourceRequirements, workloadType, name string) (int, error) {
+func (r *PodRightSizingReconciler) updateWorkloadResources(ctx context.Context, obj client.Object, spec *corev1.PodSpec, recommendation rightsizingv1alpha1.PodRecommendation, policy resourcePolicy, workloadType, name string) (int, error) {
 	logger := log.FromContext(ctx)
 
 	// Update container resources using helper
-	updated := r.updateContainerResources(containers, resources, logger, workloadType, name)
+	updated := r.updatePodSpecResources(spec, recommendation, policy, logger, workloadType, name)
 
 	if !updated {
 		return 0, nil
@@ -745,16 +996,17 @@ func (r *PodRightSizingReconciler) updateWorkloadResources(ctx context.Context,
 }
 
 // updateContainerResources updates container resources and returns whether any updates were made.
-func (r *PodRightSizingReconciler) updateContainerResources(containers []corev1.Container, resources corev1.ResourceRequirements, logger logr.Logger, workloadType, name string) bool {
+func (r *PodRightSizingReconciler) updateContainerResources(containers []corev1.Container, resources corev1.ResourceRequirements, floors corev1.ResourceList, policy resourcePolicy, logger logr.Logger, workloadType, name string) bool {
 	updated := false
 	for i := range containers {
 		container := &containers[i]
-		if !r.resourcesEqual(container.Resources, resources) {
+		desired := policy.desired(container.Name, container.Resources, resources, floors)
+		if !r.resourcesEqual(container.Resources, desired) {
 			logger.Info("Updating container resources",
 				workloadType, name,
 				"container", container.Name)
 
-			container.Resources = resources
+			container.Resources = desired
 			updated = true
 		}
 	}
@@ -805,9 +1057,35 @@ func (r *PodRightSizingReconciler) splitWorkloadKey(key string) []string {
 	return strings.SplitN(key, "/", 3)
 }
 
+// RequeueAll enqueues every PodRightSizing so that a replica picks up objects it gained after a rebalance
+func (r *PodRightSizingReconciler) RequeueAll(ctx context.Context) {
+	if r.ShardEvents == nil {
+		return
+	}
+
+	var rightSizingList rightsizingv1alpha1.PodRightSizingList
+	if err := r.List(ctx, &rightSizingList); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list PodRightSizings for rebalance")
+		return
+	}
+
+	for i := range rightSizingList.Items {
+		select {
+		case r.ShardEvents <- event.GenericEvent{Object: &rightSizingList.Items[i]}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager
 func (r *PodRightSizingReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	bldr := ctrl.NewControllerManagedBy(mgr)
+	if r.ShardEvents != nil {
+		bldr = bldr.WatchesRawSource(source.Channel(r.ShardEvents, &handler.EnqueueRequestForObject{}))
+	}
+
+	return bldr.
 		For(&rightsizingv1alpha1.PodRightSizing{}).
 		Owns(&corev1.Pod{}).
 		Watches(
@@ -818,7 +1096,8 @@ func (r *PodRightSizingReconciler) SetupWithManager(mgr ctrl.Manager) error {
 					// Only trigger on resource changes
 					oldPod := e.ObjectOld.(*corev1.Pod)
 					newPod := e.ObjectNew.(*corev1.Pod)
-					return !r.containerResourcesEqual(oldPod.Spec.Containers, newPod.Spec.Containers)
+					return !r.containerResourcesEqual(oldPod.Spec.Containers, newPod.Spec.Containers) ||
+						!r.containerResourcesEqual(oldPod.Spec.InitContainers, newPod.Spec.InitContainers)
 				},
 				CreateFunc: func(_ event.CreateEvent) bool {
 					// Trigger on new pod creation
@@ -841,6 +1120,9 @@ func (r *PodRightSizingReconciler) SetupWithManager(mgr ctrl.Manager) error {
 					return !r.containerResourcesEqual(
 						oldDep.Spec.Template.Spec.Containers,
 						newDep.Spec.Template.Spec.Containers,
+					) || !r.containerResourcesEqual(
+						oldDep.Spec.Template.Spec.InitContainers,
+						newDep.Spec.Template.Spec.InitContainers,
 					)
 				},
 			}),
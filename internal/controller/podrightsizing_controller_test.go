@@ -18,11 +18,19 @@ package controller
 
 import (
 	"context"
+	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2" //nolint:revive
 	. "github.com/onsi/gomega"    //nolint:revive
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -82,3 +90,108 @@ var _ = Describe("PodRightSizing Controller", func() {
 		})
 	})
 })
+
+func TestWorkloadRollouts(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", UID: "web-uid"},
+		Spec:       appsv1.DeploymentSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+	}
+	replicaSet := func(name, app, ownerUID, revision string, created time.Time) client.Object {
+		rs := &appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				Labels:            map[string]string{"app": app},
+				Annotations:       map[string]string{deploymentRevisionAnnotation: revision},
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Spec: appsv1.ReplicaSetSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "app", Image: app + ":" + revision}},
+			}}},
+		}
+		if ownerUID != "" {
+			controller := true
+			rs.OwnerReferences = []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: types.UID(ownerUID), Controller: &controller},
+			}
+		}
+		return rs
+	}
+	r := &PodRightSizingReconciler{Client: fake.NewClientBuilder().WithObjects(
+		deployment,
+		replicaSet("web-1", "web", "web-uid", "1", now.Add(-10*24*time.Hour)),
+		replicaSet("web-3", "web", "web-uid", "3", now.Add(-time.Hour)),
+		replicaSet("web-2", "web", "web-uid", "2", now.Add(-48*time.Hour)),
+		// Another workload, a ReplicaSet left behind by a deleted Deployment of the same name and an orphan
+		replicaSet("api-1", "api", "api-uid", "1", now.Add(-time.Hour)),
+		replicaSet("web-old", "web", "old-web-uid", "9", now.Add(-time.Hour)),
+		replicaSet("web-orphan", "web", "", "1", now.Add(-time.Hour)),
+	).Build()}
+
+	tests := []struct {
+		name         string
+		workloadType string
+		workloadName string
+		want         []string
+		wantErr      bool
+	}{
+		{name: "rollouts of the deployment in the window, oldest first", workloadType: WorkloadTypeDeployment,
+			workloadName: "web", want: []string{"2", "3"}},
+		{name: "other workload types report none", workloadType: "StatefulSet", workloadName: "web"},
+		{name: "missing deployment", workloadType: WorkloadTypeDeployment, workloadName: "missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rollouts, err := WorkloadRollouts(context.Background(), r.Client, "default", tt.workloadType, tt.workloadName,
+				now.Add(-7*24*time.Hour))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			var revisions []string
+			for _, rollout := range rollouts {
+				revisions = append(revisions, rollout.Revision)
+			}
+			assert.Equal(t, tt.want, revisions)
+		})
+	}
+
+	rollouts, err := WorkloadRollouts(context.Background(), r.Client, "default", WorkloadTypeDeployment, "web", now.Add(-7*24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, rollouts, 2)
+	assert.True(t, rollouts[0].Time.Equal(now.Add(-48*time.Hour)))
+	assert.Equal(t, []string{"web:2"}, rollouts[0].Images)
+}
+
+func TestRecommendationHorizon(t *testing.T) {
+	tests := []struct {
+		name      string
+		schedule  string
+		stability string
+		min, max  time.Duration
+	}{
+		{name: "until the next hourly analysis", schedule: "0 * * * *", min: 0, max: time.Hour},
+		{name: "until the next daily analysis", schedule: "0 0 * * *", min: 0, max: 24 * time.Hour},
+		{name: "plus the stability period", schedule: "0 * * * *", stability: "1d", min: 24 * time.Hour, max: 25 * time.Hour},
+		{name: "an hour without a valid schedule", schedule: "hourly-ish", stability: "30m",
+			min: 90 * time.Minute, max: 90 * time.Minute},
+		{name: "an invalid stability period is ignored", schedule: "not a schedule", stability: "soon",
+			min: time.Hour, max: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prs := &rightsizingv1alpha1.PodRightSizing{Spec: rightsizingv1alpha1.PodRightSizingSpec{
+				Schedule:     tt.schedule,
+				UpdatePolicy: rightsizingv1alpha1.UpdatePolicy{MinStabilityPeriod: tt.stability},
+			}}
+			horizon := RecommendationHorizon(prs)
+			assert.GreaterOrEqual(t, horizon, tt.min)
+			assert.LessOrEqual(t, horizon, tt.max)
+		})
+	}
+}
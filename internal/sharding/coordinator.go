@@ -0,0 +1,244 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// ShardGroupLabel marks the Lease objects that belong to one group of replicas
+	ShardGroupLabel = "rightsizing.k8s-rightsizer.io/shard-group"
+
+	defaultShardGroup    = "pod-rightsizer"
+	defaultLeaseDuration = 30 * time.Second
+	leaseNamePrefix      = "pod-rightsizer-shard-"
+)
+
+// Coordinator keeps a Lease for this replica alive, watches the Leases of its
+// peers and maintains a consistent hash ring of the live replicas. It runs on
+// every replica, independently of leader election.
+type Coordinator struct {
+	// Client is used to create, renew and release this replica's Lease
+	Client client.Client
+	// Reader lists peer Leases; an uncached reader avoids a cluster-wide Lease informer
+	Reader client.Reader
+	// Namespace holds the shard Leases
+	Namespace string
+	// Identity uniquely identifies this replica, usually the pod name
+	Identity string
+	// Group separates independent controller deployments sharing a namespace
+	Group string
+	// LeaseDuration is how long a replica stays a member without renewing
+	LeaseDuration time.Duration
+	// OnRebalance is called after the set of live replicas changes
+	OnRebalance func(ctx context.Context)
+
+	mu        sync.RWMutex
+	ring      *Ring
+	lastRenew time.Time
+	now       func() time.Time
+}
+
+// NewCoordinator creates a coordinator with default group and lease duration
+func NewCoordinator(c client.Client, reader client.Reader, namespace, identity string) *Coordinator {
+	return &Coordinator{
+		Client:        c,
+		Reader:        reader,
+		Namespace:     namespace,
+		Identity:      identity,
+		Group:         defaultShardGroup,
+		LeaseDuration: defaultLeaseDuration,
+		now:           time.Now,
+	}
+}
+
+// Owns reports whether this replica is responsible for key. Nothing is owned
+// until the first membership sync so that a starting replica does not duplicate work.
+func (c *Coordinator) Owns(key string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.ring != nil && c.ring.Owner(key) == c.Identity
+}
+
+// Members returns the live replicas known at the last sync
+func (c *Coordinator) Members() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.ring == nil {
+		return nil
+	}
+	return c.ring.Members()
+}
+
+// NeedLeaderElection lets every replica run the coordinator
+func (c *Coordinator) NeedLeaderElection() bool {
+	return false
+}
+
+// Start renews the Lease and refreshes membership until ctx is cancelled
+func (c *Coordinator) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("sharding").WithValues("identity", c.Identity)
+	ctx = log.IntoContext(ctx, logger)
+
+	// Renew three times per lease duration so a single missed renewal does not drop membership
+	ticker := time.NewTicker(c.LeaseDuration / 3)
+	defer ticker.Stop()
+
+	for {
+		if err := c.sync(ctx); err != nil {
+			logger.Error(err, "Failed to sync shard membership")
+		}
+
+		select {
+		case <-ctx.Done():
+			c.release(logger)
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync renews this replica's Lease and rebuilds the ring from the live Leases
+func (c *Coordinator) sync(ctx context.Context) error {
+	if err := c.renew(ctx); err != nil {
+		// Peers drop this replica once its Lease expires, so stop claiming keys as well
+		c.mu.Lock()
+		if c.ring != nil && c.now().Sub(c.lastRenew) > c.LeaseDuration {
+			c.ring = nil
+		}
+		c.mu.Unlock()
+		return fmt.Errorf("failed to renew shard lease: %w", err)
+	}
+
+	var leases coordinationv1.LeaseList
+	if err := c.Reader.List(ctx, &leases,
+		client.InNamespace(c.Namespace),
+		client.MatchingLabels{ShardGroupLabel: c.Group},
+	); err != nil {
+		return fmt.Errorf("failed to list shard leases: %w", err)
+	}
+
+	now := c.now()
+	members := []string{c.Identity}
+	for _, lease := range leases.Items {
+		if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == c.Identity {
+			continue
+		}
+		if !c.leaseAlive(&lease, now) {
+			continue
+		}
+		members = append(members, *lease.Spec.HolderIdentity)
+	}
+	slices.Sort(members)
+
+	c.mu.Lock()
+	c.lastRenew = now
+	changed := c.ring == nil || !slices.Equal(c.ring.Members(), members)
+	if changed {
+		c.ring = NewRing(members)
+	}
+	c.mu.Unlock()
+
+	if changed {
+		log.FromContext(ctx).Info("Shard membership changed", "members", members)
+		if c.OnRebalance != nil {
+			c.OnRebalance(ctx)
+		}
+	}
+
+	return nil
+}
+
+// leaseAlive reports whether a peer renewed its Lease within its duration
+func (c *Coordinator) leaseAlive(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil {
+		return false
+	}
+
+	duration := c.LeaseDuration
+	if lease.Spec.LeaseDurationSeconds != nil {
+		duration = time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	}
+	return now.Before(lease.Spec.RenewTime.Add(duration))
+}
+
+// renew creates or refreshes this replica's Lease
+func (c *Coordinator) renew(ctx context.Context) error {
+	renewTime := metav1.NewMicroTime(c.now())
+	durationSeconds := int32(c.LeaseDuration / time.Second)
+
+	var lease coordinationv1.Lease
+	err := c.Reader.Get(ctx, types.NamespacedName{Namespace: c.Namespace, Name: c.leaseName()}, &lease)
+	if errors.IsNotFound(err) {
+		lease = coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      c.leaseName(),
+				Namespace: c.Namespace,
+				Labels:    map[string]string{ShardGroupLabel: c.Group},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       ptr.To(c.Identity),
+				LeaseDurationSeconds: ptr.To(durationSeconds),
+				AcquireTime:          &renewTime,
+				RenewTime:            &renewTime,
+			},
+		}
+		return c.Client.Create(ctx, &lease)
+	}
+	if err != nil {
+		return err
+	}
+
+	lease.Spec.HolderIdentity = ptr.To(c.Identity)
+	lease.Spec.LeaseDurationSeconds = ptr.To(durationSeconds)
+	lease.Spec.RenewTime = &renewTime
+	return c.Client.Update(ctx, &lease)
+}
+
+// release deletes this replica's Lease so peers rebalance without waiting for it to expire
+func (c *Coordinator) release(logger logr.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: c.leaseName(), Namespace: c.Namespace},
+	}
+	if err := c.Client.Delete(ctx, lease); err != nil && !errors.IsNotFound(err) {
+		logger.Error(err, "Failed to release shard lease")
+	}
+}
+
+// leaseName returns the name of this replica's Lease
+func (c *Coordinator) leaseName() string {
+	return leaseNamePrefix + c.Identity
+}
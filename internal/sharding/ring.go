@@ -0,0 +1,95 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sharding splits PodRightSizing objects between controller replicas.
+package sharding
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// defaultVirtualNodes spreads each member over the ring so keys stay balanced with few replicas
+const defaultVirtualNodes = 128
+
+// Ring is an immutable consistent hash ring. When a member joins or leaves only
+// the keys owned by that member move.
+type Ring struct {
+	members []string
+	hashes  []uint64
+	owners  map[uint64]string
+}
+
+// NewRing builds a ring for the given members
+func NewRing(members []string) *Ring {
+	ring := &Ring{
+		members: append([]string(nil), members...),
+		owners:  make(map[uint64]string, len(members)*defaultVirtualNodes),
+	}
+	sort.Strings(ring.members)
+
+	for _, member := range ring.members {
+		for i := 0; i < defaultVirtualNodes; i++ {
+			h := hashKey(member + "#" + strconv.Itoa(i))
+			// On the (unlikely) collision keep the smallest member so every replica agrees
+			if existing, ok := ring.owners[h]; ok && existing < member {
+				continue
+			}
+			if _, ok := ring.owners[h]; !ok {
+				ring.hashes = append(ring.hashes, h)
+			}
+			ring.owners[h] = member
+		}
+	}
+	sort.Slice(ring.hashes, func(i, j int) bool { return ring.hashes[i] < ring.hashes[j] })
+
+	return ring
+}
+
+// Owner returns the member responsible for key, or "" for an empty ring
+func (r *Ring) Owner(key string) string {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+
+	h := hashKey(key)
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.owners[r.hashes[i]]
+}
+
+// Members returns the sorted ring members
+func (r *Ring) Members() []string {
+	return append([]string(nil), r.members...)
+}
+
+// hashKey hashes a string onto the ring. FNV alone clusters keys that differ
+// only in a short suffix, so the result is passed through a 64-bit finalizer.
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRing_BalancesKeys(t *testing.T) {
+	ring := NewRing([]string{"replica-a", "replica-b", "replica-c"})
+
+	counts := map[string]int{}
+	for i := 0; i < 3000; i++ {
+		counts[ring.Owner(fmt.Sprintf("namespace-%d/rightsizing", i))]++
+	}
+
+	require.Len(t, counts, 3)
+	for member, count := range counts {
+		assert.InDelta(t, 1000, count, 250, "member %s owns %d keys", member, count)
+	}
+}
+
+func TestRing_MovesOnlyKeysOfChangedMember(t *testing.T) {
+	before := NewRing([]string{"replica-a", "replica-b", "replica-c"})
+	after := NewRing([]string{"replica-a", "replica-b", "replica-c", "replica-d"})
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("default/rightsizing-%d", i)
+		if owner := after.Owner(key); owner != "replica-d" {
+			assert.Equal(t, before.Owner(key), owner, "key %s moved between existing members", key)
+		}
+	}
+}
+
+func TestRing_Empty(t *testing.T) {
+	assert.Equal(t, "", NewRing(nil).Owner("default/rightsizing"))
+}
+
+func TestCoordinator_TracksLiveLeases(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, coordinationv1.AddToScheme(scheme))
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	peerLease := func(name string, renewed time.Time) *coordinationv1.Lease {
+		return &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      leaseNamePrefix + name,
+				Namespace: "system",
+				Labels:    map[string]string{ShardGroupLabel: defaultShardGroup},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				HolderIdentity:       ptr.To(name),
+				LeaseDurationSeconds: ptr.To(int32(30)),
+				RenewTime:            ptr.To(metav1.NewMicroTime(renewed)),
+			},
+		}
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		peerLease("replica-b", now.Add(-10*time.Second)),
+		peerLease("replica-c", now.Add(-time.Minute)), // expired
+	).Build()
+
+	coordinator := NewCoordinator(c, c, "system", "replica-a")
+	coordinator.now = func() time.Time { return now }
+
+	rebalances := 0
+	coordinator.OnRebalance = func(context.Context) { rebalances++ }
+
+	assert.False(t, coordinator.Owns("default/rightsizing"), "nothing is owned before the first sync")
+
+	ctx := context.Background()
+	require.NoError(t, coordinator.sync(ctx))
+	assert.Equal(t, []string{"replica-a", "replica-b"}, coordinator.Members())
+	assert.Equal(t, 1, rebalances)
+
+	// Own Lease was created
+	var own coordinationv1.Lease
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "system", Name: leaseNamePrefix + "replica-a"}, &own))
+	assert.Equal(t, "replica-a", *own.Spec.HolderIdentity)
+
+	// A second sync without membership change does not rebalance
+	require.NoError(t, coordinator.sync(ctx))
+	assert.Equal(t, 1, rebalances)
+
+	// Exactly one member owns each key
+	ring := NewRing(coordinator.Members())
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("default/rightsizing-%d", i)
+		assert.Equal(t, ring.Owner(key) == "replica-a", coordinator.Owns(key))
+	}
+}
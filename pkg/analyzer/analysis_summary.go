@@ -0,0 +1,119 @@
+// pkg/analyzer/analysis_summary.go
+package analyzer
+
+import (
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+)
+
+// Pattern and priority values from most to least pressing, used to break ties when summarizing
+var (
+	patternTypes  = []string{"variable", "moderate", "steady"}
+	spikePatterns = []string{"frequent", "occasional", "none"}
+	priorities    = []string{"High", "Medium", "Low"}
+)
+
+// Summary condenses the analysis into the compact form persisted in the PodRightSizing status: the
+// usage statistics and the pattern most pods show for each resource, and the highest priority of the
+// workload-level recommendations
+func (w *WorkloadAnalysis) Summary() rightsizingv1alpha1.WorkloadAnalysisSummary {
+	summary := rightsizingv1alpha1.WorkloadAnalysisSummary{
+		Namespace:    w.Namespace,
+		WorkloadType: w.WorkloadType,
+		WorkloadName: w.WorkloadName,
+		Pods:         w.TotalPods,
+		CPU:          w.summarizeResource(corev1.ResourceCPU, w.CPUAnalysis),
+		Memory:       w.summarizeResource(corev1.ResourceMemory, w.MemoryAnalysis),
+	}
+
+	recommendationPriorities := make([]string, len(w.Recommendations))
+	for i, recommendation := range w.Recommendations {
+		recommendationPriorities[i] = recommendation.Priority
+	}
+	summary.Priority = mostPressing(priorities, recommendationPriorities)
+
+	return summary
+}
+
+// summarizeResource converts the statistics of one resource to quantities and describes its pattern
+func (w *WorkloadAnalysis) summarizeResource(
+	name corev1.ResourceName,
+	analysis *ResourceAnalysis,
+) *rightsizingv1alpha1.ResourceUsageSummary {
+	if analysis == nil {
+		return nil
+	}
+
+	return &rightsizingv1alpha1.ResourceUsageSummary{
+		DataPoints: analysis.TotalDataPoints,
+		Min:        *usageQuantity(name, analysis.WorkloadMin),
+		Mean:       *usageQuantity(name, analysis.WorkloadMean),
+		P95:        *usageQuantity(name, analysis.WorkloadP95),
+		P99:        *usageQuantity(name, analysis.WorkloadP99),
+		Max:        *usageQuantity(name, analysis.WorkloadMax),
+		Pattern:    w.describePattern(analysis.ResourceType),
+	}
+}
+
+// describePattern combines the variability and spikes most pods show in their usage of a resource with
+// the strongest cycle any pod shows, e.g. "variable with occasional spikes, daily cycle (strength 0.82)".
+// It returns an empty string when no pod has enough data points for pattern detection.
+func (w *WorkloadAnalysis) describePattern(resourceType string) string {
+	var types, spikes []string
+	var strongest *Cycle
+	for _, pattern := range w.UsagePatterns {
+		if pattern.ResourceType != resourceType {
+			continue
+		}
+		types = append(types, pattern.PatternType)
+		spikes = append(spikes, pattern.SpikePattern)
+		for i, cycle := range pattern.Cycles {
+			if strongest == nil || cycle.Strength > strongest.Strength {
+				strongest = &pattern.Cycles[i]
+			}
+		}
+	}
+	if len(types) == 0 {
+		return ""
+	}
+
+	spike := mostCommon(spikePatterns, spikes)
+	if spike == "none" {
+		spike = "no"
+	}
+	parts := []string{mostCommon(patternTypes, types) + " with " + spike + " spikes"}
+	if strongest != nil {
+		parts = append(parts, strongest.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// mostCommon returns the most frequent of values, preferring the earlier of known on a tie
+func mostCommon(known, values []string) string {
+	counts := make(map[string]int, len(values))
+	for _, value := range values {
+		counts[value]++
+	}
+
+	best := ""
+	for _, value := range known {
+		if counts[value] > counts[best] {
+			best = value
+		}
+	}
+	return best
+}
+
+// mostPressing returns the first of known present in values, or an empty string when none is
+func mostPressing(known, values []string) string {
+	for _, value := range known {
+		if slices.Contains(values, value) {
+			return value
+		}
+	}
+	return ""
+}
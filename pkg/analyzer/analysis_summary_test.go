@@ -0,0 +1,79 @@
+package analyzer
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+func TestWorkloadAnalysisSummary(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	workloadMetrics := &metrics.WorkloadMetrics{
+		Namespace:    "default",
+		WorkloadType: "Deployment",
+		WorkloadName: "webapp",
+	}
+	for _, name := range []string{"webapp-a", "webapp-b"} {
+		workloadMetrics.Pods = append(workloadMetrics.Pods, metrics.PodMetrics{
+			PodName:         name,
+			Namespace:       "default",
+			CPUUsageHistory: usageHistory(72*12, 5*time.Minute, dailyWave(random, 5*time.Minute), "cores"),
+			MemUsageHistory: usageHistory(72*12, 5*time.Minute, steady(128*1024*1024), "bytes"),
+		})
+	}
+
+	analysis, err := NewAdvancedAnalyzer().AnalyzeWorkloadPatterns(workloadMetrics)
+	require.NoError(t, err)
+	summary := analysis.Summary()
+
+	assert.Equal(t, "default", summary.Namespace)
+	assert.Equal(t, "Deployment", summary.WorkloadType)
+	assert.Equal(t, "webapp", summary.WorkloadName)
+	assert.Equal(t, 2, summary.Pods)
+	assert.Equal(t, "Medium", summary.Priority)
+
+	require.NotNil(t, summary.CPU)
+	assert.Equal(t, 2*864, summary.CPU.DataPoints)
+	assert.Less(t, summary.CPU.Min.MilliValue(), summary.CPU.Mean.MilliValue())
+	assert.LessOrEqual(t, summary.CPU.Mean.MilliValue(), summary.CPU.P95.MilliValue())
+	assert.LessOrEqual(t, summary.CPU.P95.MilliValue(), summary.CPU.P99.MilliValue())
+	assert.LessOrEqual(t, summary.CPU.P99.MilliValue(), summary.CPU.Max.MilliValue())
+	assert.InDelta(t, 1000, summary.CPU.Mean.MilliValue(), 50)
+	assert.Regexp(t, `^variable with no spikes, daily cycle \(strength [01]\.\d+, peaking \d\d-\d\dh UTC\)$`, summary.CPU.Pattern)
+
+	require.NotNil(t, summary.Memory)
+	assert.True(t, summary.Memory.P99.Equal(resource.MustParse("128Mi")), summary.Memory.P99.String())
+	assert.Equal(t, "128Mi", summary.Memory.Min.String())
+	assert.Equal(t, "steady with no spikes", summary.Memory.Pattern)
+}
+
+func TestWorkloadAnalysisSummary_Partial(t *testing.T) {
+	analysis := &WorkloadAnalysis{
+		WorkloadName: "batch",
+		TotalPods:    1,
+		CPUAnalysis:  &ResourceAnalysis{ResourceType: "CPU", TotalDataPoints: 3, WorkloadMin: 0.0101, WorkloadP99: 0.5},
+		UsagePatterns: []UsagePattern{
+			{ResourceType: "CPU", PatternType: "steady", SpikePattern: "none"},
+			{ResourceType: "CPU", PatternType: "variable", SpikePattern: "frequent"},
+			{ResourceType: "Memory", PatternType: "steady", SpikePattern: "none"},
+		},
+		Recommendations: []WorkloadRecommendation{{Priority: "Low"}, {Priority: "High"}, {Priority: "Medium"}},
+	}
+
+	summary := analysis.Summary()
+	require.NotNil(t, summary.CPU)
+	assert.Nil(t, summary.Memory)
+	assert.Equal(t, "11m", summary.CPU.Min.String(), "usage is rounded up")
+	assert.Equal(t, "500m", summary.CPU.P99.String())
+	assert.Equal(t, "variable with frequent spikes", summary.CPU.Pattern, "ties go to the more pressing pattern")
+	assert.Equal(t, "High", summary.Priority)
+
+	assert.Empty(t, (&WorkloadAnalysis{}).Summary().Priority)
+}
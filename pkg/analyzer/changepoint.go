@@ -0,0 +1,225 @@
+// pkg/analyzer/changepoint.go
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+// Change point detection constants
+const (
+	// detectedChangeEffect is the standardized mean difference between the samples before and after a
+	// split that marks a change point without a rollout to explain it. Daily cycles rarely exceed it.
+	detectedChangeEffect = 1.0
+	// rolloutChangeEffect is the standardized mean difference that marks a change point at a rollout
+	rolloutChangeEffect = 0.5
+	// minChangeSegmentSpan is how long each side of a detected change point must span, so a shift is not
+	// mistaken for part of a daily cycle. Shorter series only reset at rollouts.
+	minChangeSegmentSpan = 24 * time.Hour
+	// changeConfidenceWeight is the share of confidence lost right after a change point
+	changeConfidenceWeight = 0.5
+)
+
+// Rollout is a change of a workload's pod template, such as a new image version
+type Rollout struct {
+	// Time is when the new template was rolled out
+	Time time.Time
+	// Revision identifies the template, e.g. the revision of a Deployment's ReplicaSet
+	Revision string
+	// Images are the container images of the template
+	Images []string
+}
+
+// changePoint is a lasting shift in the mean usage of a resource
+type changePoint struct {
+	Time     time.Time
+	Resource corev1.ResourceName
+	// Shift is the relative change of the mean usage, e.g. 0.5 for a 50% increase
+	Shift float64
+	// Rollout is the rollout the shift coincides with, nil when it was detected in the usage alone
+	Rollout *Rollout
+}
+
+// String describes the change point for recommendation reasons
+func (c *changePoint) String() string {
+	description := fmt.Sprintf("%s usage shifted %+.0f%% at %s", c.Resource, c.Shift*100, c.Time.UTC().Format(time.RFC3339))
+	if c.Rollout != nil {
+		description += fmt.Sprintf(" with the rollout of revision %s", c.Rollout.Revision)
+		if len(c.Rollout.Images) > 0 {
+			description += fmt.Sprintf(" (%s)", strings.Join(c.Rollout.Images, ", "))
+		}
+	}
+	return description
+}
+
+// resetAtChangePoint drops the samples before the latest change point in the CPU or memory usage of a pod,
+// since usage from before a release or a shift in load says little about the usage to come. Splits at
+// rollouts are tested with a lower bar than splits found in the usage alone. It returns the remaining
+// metrics, the change point, nil when there was none, and how many samples were dropped.
+func (r *RecommendationEngine) resetAtChangePoint(
+	podMetrics metrics.PodMetrics,
+	rollouts []Rollout,
+) (metrics.PodMetrics, *changePoint, int) {
+	if r.MinChangePointShift <= 0 {
+		return podMetrics, nil, 0
+	}
+
+	var latest *changePoint
+	for _, series := range []struct {
+		name    corev1.ResourceName
+		history []metrics.ResourceUsage
+	}{
+		{corev1.ResourceCPU, podMetrics.CPUUsageHistory},
+		{corev1.ResourceMemory, podMetrics.MemUsageHistory},
+	} {
+		change := r.detectChangePoint(series.history, rollouts)
+		if change == nil {
+			continue
+		}
+		change.Resource = series.name
+		if latest == nil || change.Time.After(latest.Time) ||
+			(change.Time.Equal(latest.Time) && math.Abs(change.Shift) > math.Abs(latest.Shift)) {
+			latest = change
+		}
+	}
+	if latest == nil {
+		return podMetrics, nil, 0
+	}
+
+	before := countUsageSamples(podMetrics)
+	podMetrics = mapUsageHistories(podMetrics, func(history []metrics.ResourceUsage) []metrics.ResourceUsage {
+		kept := make([]metrics.ResourceUsage, 0, len(history))
+		for _, usage := range history {
+			if !usage.Timestamp.Before(latest.Time) {
+				kept = append(kept, usage)
+			}
+		}
+		return kept
+	})
+	return podMetrics, latest, before - countUsageSamples(podMetrics)
+}
+
+// detectChangePoint finds the latest significant shift in the mean of a time ordered usage series. A
+// rollout within the series is a change point when the usage after it differs from the usage before by
+// at least rolloutChangeEffect. Otherwise the split with the largest standardized mean difference is a
+// change point when it reaches detectedChangeEffect and fits the series better than a linear trend, so
+// steady growth such as a memory leak is left to the recommenders. Either way the means must differ by at
+// least MinChangePointShift relative to the mean before.
+func (r *RecommendationEngine) detectChangePoint(history []metrics.ResourceUsage, rollouts []Rollout) *changePoint {
+	n := len(history)
+	minSegment := max(r.MinDataPoints, 2)
+	if n < 2*minSegment {
+		return nil
+	}
+	if !sort.SliceIsSorted(history, func(i, j int) bool { return history[i].Timestamp.Before(history[j].Timestamp) }) {
+		history = append([]metrics.ResourceUsage(nil), history...)
+		sort.Slice(history, func(i, j int) bool { return history[i].Timestamp.Before(history[j].Timestamp) })
+	}
+
+	// Prefix sums give the mean and variance of both sides of every split in constant time
+	sums := make([]float64, n+1)
+	squares := make([]float64, n+1)
+	for i, usage := range history {
+		sums[i+1] = sums[i] + usage.Value
+		squares[i+1] = squares[i] + usage.Value*usage.Value
+	}
+	// residual returns the squared error of the series around the means of both sides of split k
+	residual := func(k int) (meanBefore, meanAfter, sse float64) {
+		before, after := float64(k), float64(n-k)
+		meanBefore = sums[k] / before
+		meanAfter = (sums[n] - sums[k]) / after
+		sse = squares[k] - before*meanBefore*meanBefore + (squares[n] - squares[k]) - after*meanAfter*meanAfter
+		return meanBefore, meanAfter, math.Max(sse, 0)
+	}
+	split := func(k int) (effect, shift float64) {
+		meanBefore, meanAfter, sse := residual(k)
+		if meanBefore <= 0 {
+			return 0, 0
+		}
+		shift = (meanAfter - meanBefore) / meanBefore
+		if sse == 0 {
+			// A flat series on both sides makes any shift significant
+			return math.Inf(1), shift
+		}
+		return math.Abs(meanAfter-meanBefore) / math.Sqrt(sse/float64(n-2)), shift
+	}
+	significant := func(effect, shift, threshold float64) bool {
+		return effect >= threshold && math.Abs(shift) >= r.MinChangePointShift
+	}
+
+	// The latest rollout followed by a shift wins, since it explains the shift
+	var change *changePoint
+	for i := range rollouts {
+		rollout := &rollouts[i]
+		k := firstSampleAtOrAfter(history, rollout.Time)
+		if k < minSegment || n-k < 2 {
+			continue
+		}
+		if effect, shift := split(k); significant(effect, shift, rolloutChangeEffect) {
+			if change == nil || rollout.Time.After(change.Rollout.Time) {
+				change = &changePoint{Time: history[k].Timestamp, Shift: shift, Rollout: rollout}
+			}
+		}
+	}
+	if change != nil {
+		return change
+	}
+
+	first, last := history[0].Timestamp, history[n-1].Timestamp
+	bestEffect, bestShift, bestSplit := 0.0, 0.0, -1
+	for k := minSegment; k <= n-minSegment; k++ {
+		if history[k].Timestamp.Sub(first) < minChangeSegmentSpan || last.Sub(history[k].Timestamp) < minChangeSegmentSpan {
+			continue
+		}
+		if effect, shift := split(k); effect > bestEffect {
+			bestEffect, bestShift, bestSplit = effect, shift, k
+		}
+	}
+	if bestSplit < 0 || !significant(bestEffect, bestShift, detectedChangeEffect) {
+		return nil
+	}
+	if _, _, sse := residual(bestSplit); sse >= trendResidual(history) {
+		return nil
+	}
+	return &changePoint{Time: history[bestSplit].Timestamp, Shift: bestShift}
+}
+
+// firstSampleAtOrAfter returns the index of the first sample at or after timestamp, len(history) when
+// there is none
+func firstSampleAtOrAfter(history []metrics.ResourceUsage, timestamp time.Time) int {
+	for i, usage := range history {
+		if !usage.Timestamp.Before(timestamp) {
+			return i
+		}
+	}
+	return len(history)
+}
+
+// trendResidual returns the squared error of a usage series around its least squares line over the
+// sample index
+func trendResidual(history []metrics.ResourceUsage) float64 {
+	n := float64(len(history))
+	var sumX, sumY, sumXX, sumXY, sumYY float64
+	for i, usage := range history {
+		x := float64(i)
+		sumX += x
+		sumY += usage.Value
+		sumXX += x * x
+		sumXY += x * usage.Value
+		sumYY += usage.Value * usage.Value
+	}
+	sxx := sumXX - sumX*sumX/n
+	sxy := sumXY - sumX*sumY/n
+	syy := sumYY - sumY*sumY/n
+	if sxx == 0 {
+		return math.Max(syy, 0)
+	}
+	return math.Max(syy-sxy*sxy/sxx, 0)
+}
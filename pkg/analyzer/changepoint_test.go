@@ -0,0 +1,143 @@
+package analyzer
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+func TestDetectChangePoint(t *testing.T) {
+	engine := NewRecommendationEngine()
+	start := usageStart
+	random := rand.New(rand.NewSource(1))
+	noise := func(amplitude float64) float64 { return (random.Float64()*2 - 1) * amplitude }
+
+	// Load that more than doubled two days into a four-day window
+	step := usageHistory(4*24*12, 5*time.Minute, func(i int) float64 {
+		if i < 2*24*12 {
+			return 0.2 + noise(0.02)
+		}
+		return 0.5 + noise(0.02)
+	}, "cores")
+	change := engine.detectChangePoint(step, nil)
+	require.NotNil(t, change)
+	assert.Equal(t, start.Add(48*time.Hour), change.Time)
+	assert.InDelta(t, 1.5, change.Shift, 0.1)
+	assert.Nil(t, change.Rollout)
+
+	// A shift needs a day on both sides to be told from a daily cycle
+	assert.Nil(t, engine.detectChangePoint(step[:60*12], nil))
+
+	// Three days of a daily cycle are no change
+	daily := usageHistory(3*24*12, 5*time.Minute, dailyWave(random, 5*time.Minute), "cores")
+	assert.Nil(t, engine.detectChangePoint(daily, nil))
+
+	// Nor is a steady leak
+	leak := usageHistory(3*24*60, time.Minute, func(i int) float64 { return 100 + float64(i) + noise(5) }, "cores")
+	assert.Nil(t, engine.detectChangePoint(leak, nil))
+
+	// Shifts below MinChangePointShift are ignored however clear they are
+	small := usageHistory(3*24*60, time.Minute, func(i int) float64 {
+		if i < 2*24*60 {
+			return 0.5
+		}
+		return 0.55
+	}, "cores")
+	assert.Nil(t, engine.detectChangePoint(small, nil))
+}
+
+func TestDetectChangePoint_Rollouts(t *testing.T) {
+	engine := NewRecommendationEngine()
+	start := usageStart
+	random := rand.New(rand.NewSource(1))
+
+	// A 25% shift hidden in noisy usage is only significant at a rollout
+	noisy := usageHistory(600, time.Minute, func(i int) float64 {
+		value := 0.4
+		if i >= 400 {
+			value = 0.5
+		}
+		return value + (random.Float64()*2-1)*0.2
+	}, "cores")
+	assert.Nil(t, engine.detectChangePoint(noisy, nil))
+
+	rollouts := []Rollout{
+		{Time: start.Add(100 * time.Minute), Revision: "2"},
+		{Time: start.Add(399*time.Minute + 30*time.Second), Revision: "3", Images: []string{"app:1.4.0"}},
+		// Rollouts after the last sample are ignored
+		{Time: start.Add(time.Hour * 24), Revision: "4"},
+	}
+	change := engine.detectChangePoint(noisy, rollouts)
+	require.NotNil(t, change)
+	assert.Equal(t, start.Add(400*time.Minute), change.Time)
+	assert.InDelta(t, 0.25, change.Shift, 0.05)
+	require.NotNil(t, change.Rollout)
+	assert.Equal(t, "3", change.Rollout.Revision)
+
+	change.Resource, change.Shift = corev1.ResourceCPU, 0.25
+	assert.Equal(t, "cpu usage shifted +25% at 2025-01-01T06:40:00Z with the rollout of revision 3 (app:1.4.0)",
+		change.String())
+}
+
+func TestGenerateRecommendations_ChangePoint(t *testing.T) {
+	engine := NewRecommendationEngine()
+	engine.FullConfidenceReplicas = 1
+	engine.DefaultConfidenceThreshold = 0
+	ctx := context.Background()
+	start := usageStart
+	end := start.Add(7 * 24 * time.Hour)
+
+	// Memory usage doubled with a release six hours ago
+	samples := 7 * 24 * 12
+	release := end.Add(-6 * time.Hour)
+	podMetrics := metrics.PodMetrics{
+		PodName:         "test-pod-1",
+		Namespace:       "default",
+		StartTime:       start,
+		EndTime:         end,
+		CPUUsageHistory: usageHistory(samples, 5*time.Minute, steady(0.5), "cores"),
+		MemUsageHistory: usageHistory(samples, 5*time.Minute, func(i int) float64 {
+			if start.Add(time.Duration(i) * 5 * time.Minute).Before(release) {
+				return 256 * 1024 * 1024
+			}
+			return 512 * 1024 * 1024
+		}, "bytes"),
+	}
+	workloadMetrics := &metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{podMetrics}}
+
+	recommendations, err := engine.GenerateRecommendationsWithOptions(ctx, workloadMetrics, RecommendationOptions{
+		Rollouts: []Rollout{{Time: release, Revision: "7", Images: []string{"app:2.0.0"}}},
+	})
+	require.NoError(t, err)
+	require.Len(t, recommendations, 1)
+	recommendation := recommendations[0]
+
+	// Only usage since the release counts
+	memory := recommendation.RecommendedResources.Requests[corev1.ResourceMemory]
+	assert.Greater(t, memory.Value(), int64(512*1024*1024))
+	assert.Contains(t, recommendation.Reason, "Reset history at change point: memory usage shifted +100% "+
+		"at 2025-01-07T18:00:00Z with the rollout of revision 7 (app:2.0.0); dropped")
+
+	// Six hours of the 24 needed for full confidence
+	assert.Equal(t, 62, confidenceScore(recommendation, rightsizingv1alpha1.ConfidenceFactorChangePoint))
+	assert.Contains(t, recommendation.Reason, "changepoint to 62% (memory usage shifted +100% "+
+		"at 2025-01-07T18:00:00Z with the rollout of revision 7 (app:2.0.0), 5h55m0s of data since)")
+
+	// Without change point detection the old usage dilutes the recommendation
+	engine.MinChangePointShift = 0
+	recommendations, err = engine.GenerateRecommendationsWithOptions(ctx, workloadMetrics, RecommendationOptions{})
+	require.NoError(t, err)
+	require.Len(t, recommendations, 1)
+	diluted := recommendations[0].RecommendedResources.Requests[corev1.ResourceMemory]
+	assert.Less(t, diluted.Value(), memory.Value())
+	assert.Equal(t, 100, confidenceScore(recommendations[0], rightsizingv1alpha1.ConfidenceFactorChangePoint))
+	assert.NotContains(t, recommendations[0].Reason, "change point")
+}
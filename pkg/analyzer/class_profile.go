@@ -0,0 +1,132 @@
+// pkg/analyzer/class_profile.go
+package analyzer
+
+import (
+	"fmt"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+// WorkloadClasses lists every class a workload can be assigned
+var WorkloadClasses = []WorkloadClass{
+	WorkloadClassStable,
+	WorkloadClassBursty,
+	WorkloadClassPeriodic,
+	WorkloadClassGrowing,
+	WorkloadClassShrinking,
+	WorkloadClassUnpredictable,
+}
+
+// builtinClassProfile is the threshold profile of a class before overrides from the spec
+type builtinClassProfile struct {
+	rightsizingv1alpha1.ClassProfile
+	// Recommender replaces the percentile recommender when the spec does not select one
+	Recommender string
+}
+
+// builtinClassProfiles follow the advice of generateClassificationRecommendations. Limits of spiky
+// workloads are sized from the 99th percentile; growing workloads are sized for their forecast.
+var builtinClassProfiles = map[WorkloadClass]builtinClassProfile{
+	WorkloadClassStable: {ClassProfile: rightsizingv1alpha1.ClassProfile{
+		CPUUtilizationPercentile: 95, MemoryUtilizationPercentile: 95, SafetyMargin: 10,
+	}},
+	WorkloadClassBursty: {ClassProfile: rightsizingv1alpha1.ClassProfile{
+		CPUUtilizationPercentile: 95, MemoryUtilizationPercentile: 99, SafetyMargin: 30,
+		LimitPolicy: &rightsizingv1alpha1.LimitPolicy{Mode: rightsizingv1alpha1.LimitModePercentile, LimitPercentile: 99},
+	}},
+	WorkloadClassPeriodic: {ClassProfile: rightsizingv1alpha1.ClassProfile{
+		CPUUtilizationPercentile: 99, MemoryUtilizationPercentile: 99, SafetyMargin: 15,
+	}},
+	WorkloadClassGrowing: {ClassProfile: rightsizingv1alpha1.ClassProfile{
+		CPUUtilizationPercentile: 95, MemoryUtilizationPercentile: 95, SafetyMargin: 20,
+	}, Recommender: ForecastRecommender},
+	WorkloadClassShrinking: {ClassProfile: rightsizingv1alpha1.ClassProfile{
+		CPUUtilizationPercentile: 95, MemoryUtilizationPercentile: 95, SafetyMargin: 15,
+	}},
+	WorkloadClassUnpredictable: {ClassProfile: rightsizingv1alpha1.ClassProfile{
+		CPUUtilizationPercentile: 99, MemoryUtilizationPercentile: 99, SafetyMargin: 30,
+		LimitPolicy: &rightsizingv1alpha1.LimitPolicy{Mode: rightsizingv1alpha1.LimitModePercentile, LimitPercentile: 99},
+	}},
+}
+
+// WithClassProfile returns the options for a workload of class. Each percentile and the safety margin
+// come from classification.profiles when set there, else from the options' thresholds when set, else
+// from the built-in profile. A limit policy from the spec's profile always applies, a built-in one only
+// when spec.limitPolicy is left in Default mode; likewise the built-in recommender only applies when the
+// spec does not select one. An empty or unknown class leaves the options unchanged.
+func (o RecommendationOptions) WithClassProfile(
+	class WorkloadClass,
+	classification rightsizingv1alpha1.ClassificationSpec,
+) RecommendationOptions {
+	builtin, known := builtinClassProfiles[class]
+	if !known {
+		return o
+	}
+	override := classification.Profiles[string(class)]
+
+	o.Thresholds.CPUUtilizationPercentile = firstPositive(override.CPUUtilizationPercentile,
+		o.Thresholds.CPUUtilizationPercentile, builtin.CPUUtilizationPercentile)
+	o.Thresholds.MemoryUtilizationPercentile = firstPositive(override.MemoryUtilizationPercentile,
+		o.Thresholds.MemoryUtilizationPercentile, builtin.MemoryUtilizationPercentile)
+	o.Thresholds.SafetyMargin = firstPositive(override.SafetyMargin, o.Thresholds.SafetyMargin, builtin.SafetyMargin)
+
+	switch {
+	case override.LimitPolicy != nil:
+		o.LimitPolicy = *override.LimitPolicy
+	case builtin.LimitPolicy != nil &&
+		(o.LimitPolicy.Mode == "" || o.LimitPolicy.Mode == rightsizingv1alpha1.LimitModeDefault):
+		policy := *builtin.LimitPolicy
+		policy.RemoveCPULimits = o.LimitPolicy.RemoveCPULimits
+		policy.MemoryLimitEqualsRequest = o.LimitPolicy.MemoryLimitEqualsRequest
+		o.LimitPolicy = policy
+	}
+
+	if builtin.Recommender != "" && o.Recommender.Name == "" {
+		o.Recommender = rightsizingv1alpha1.RecommenderSpec{Name: builtin.Recommender}
+	}
+
+	return o
+}
+
+// Classify adapts the options to the class of the workload's usage when classification is enabled. The
+// returned classification is nil when it is disabled or failed; the error tells why the workload could
+// not be classified, in which case the options are returned unchanged.
+func (o RecommendationOptions) Classify(
+	workloadMetrics *metrics.WorkloadMetrics,
+	classification rightsizingv1alpha1.ClassificationSpec,
+) (RecommendationOptions, *WorkloadClassification, error) {
+	if !classification.Enabled {
+		return o, nil, nil
+	}
+	result, err := NewWorkloadClassifier().ClassifyWorkload(workloadMetrics)
+	if err != nil {
+		return o, nil, err
+	}
+	return o.WithClassProfile(result.Class, classification), result, nil
+}
+
+// DescribeClassProfile summarizes the profile the options of a workload of class were adapted to,
+// for the recommendation reason
+func DescribeClassProfile(classification *WorkloadClassification, opts RecommendationOptions) string {
+	description := fmt.Sprintf("Classified as %s (%.0f%% confidence): %dth CPU and %dth memory percentile",
+		classification.Class, classification.Confidence*100,
+		opts.Thresholds.CPUUtilizationPercentile, opts.Thresholds.MemoryUtilizationPercentile)
+	if opts.LimitPolicy.Mode == rightsizingv1alpha1.LimitModePercentile {
+		description += fmt.Sprintf(", limits from the %dth percentile", opts.LimitPolicy.LimitPercentile)
+	}
+	if opts.Recommender.Name != "" {
+		description += fmt.Sprintf(", %s recommender", opts.Recommender.Name)
+	}
+	return description
+}
+
+// firstPositive returns the first positive value, or zero when there is none
+func firstPositive(values ...int) int {
+	for _, value := range values {
+		if value > 0 {
+			return value
+		}
+	}
+	return 0
+}
@@ -0,0 +1,148 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	structuraldefaulting "k8s.io/apiextensions-apiserver/pkg/apiserver/schema/defaulting"
+	"sigs.k8s.io/yaml"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+)
+
+// defaultedSpec applies the defaults of the PodRightSizing CRD to spec, the way the API server does
+// before the controller reads the object
+func defaultedSpec(t *testing.T, spec map[string]any) rightsizingv1alpha1.PodRightSizingSpec {
+	t.Helper()
+	data, err := os.ReadFile("../../config/crd/bases/rightsizing.k8s-rightsizer.io_podrightsizings.yaml")
+	require.NoError(t, err)
+	var crd apiextensionsv1.CustomResourceDefinition
+	require.NoError(t, yaml.Unmarshal(data, &crd))
+
+	var schema apiextensions.JSONSchemaProps
+	require.NoError(t, apiextensionsv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(
+		crd.Spec.Versions[0].Schema.OpenAPIV3Schema, &schema, nil))
+	structural, err := structuralschema.NewStructural(&schema)
+	require.NoError(t, err)
+
+	object := map[string]any{"spec": spec}
+	structuraldefaulting.Default(object, structural)
+	defaulted, err := json.Marshal(object["spec"])
+	require.NoError(t, err)
+
+	var result rightsizingv1alpha1.PodRightSizingSpec
+	require.NoError(t, json.Unmarshal(defaulted, &result))
+	return result
+}
+
+func TestWithClassProfile(t *testing.T) {
+	spec := RecommendationOptions{
+		Thresholds: rightsizingv1alpha1.ResourceThresholds{MinChangeThreshold: 5},
+	}
+	classification := rightsizingv1alpha1.ClassificationSpec{Enabled: true}
+
+	// Every class has a built-in profile
+	for _, class := range WorkloadClasses {
+		opts := spec.WithClassProfile(class, classification)
+		assert.Positive(t, opts.Thresholds.SafetyMargin, class)
+		assert.Equal(t, 5, opts.Thresholds.MinChangeThreshold, class)
+	}
+
+	// Bursty workloads get a larger margin and limits from the 99th percentile
+	opts := spec.WithClassProfile(WorkloadClassBursty, classification)
+	assert.Equal(t, 99, opts.Thresholds.MemoryUtilizationPercentile)
+	assert.Equal(t, 30, opts.Thresholds.SafetyMargin)
+	assert.Equal(t, rightsizingv1alpha1.LimitPolicy{Mode: rightsizingv1alpha1.LimitModePercentile, LimitPercentile: 99},
+		opts.LimitPolicy)
+
+	// A limit policy from the spec is kept over the built-in one
+	requestsOnly := spec
+	requestsOnly.LimitPolicy = rightsizingv1alpha1.LimitPolicy{Mode: rightsizingv1alpha1.LimitModeRequestsOnly}
+	opts = requestsOnly.WithClassProfile(WorkloadClassBursty, classification)
+	assert.Equal(t, rightsizingv1alpha1.LimitModeRequestsOnly, opts.LimitPolicy.Mode)
+
+	// Growing workloads are forecast unless the spec selects a recommender
+	opts = spec.WithClassProfile(WorkloadClassGrowing, classification)
+	assert.Equal(t, ForecastRecommender, opts.Recommender.Name)
+	peak := spec
+	peak.Recommender = rightsizingv1alpha1.RecommenderSpec{Name: PeakRecommender}
+	opts = peak.WithClassProfile(WorkloadClassGrowing, classification)
+	assert.Equal(t, PeakRecommender, opts.Recommender.Name)
+
+	// Profiles from the spec replace the fields they set
+	classification.Profiles = map[string]rightsizingv1alpha1.ClassProfile{
+		"Bursty": {SafetyMargin: 50, LimitPolicy: &rightsizingv1alpha1.LimitPolicy{RemoveCPULimits: true}},
+	}
+	opts = requestsOnly.WithClassProfile(WorkloadClassBursty, classification)
+	assert.Equal(t, 99, opts.Thresholds.MemoryUtilizationPercentile)
+	assert.Equal(t, 50, opts.Thresholds.SafetyMargin)
+	assert.Equal(t, rightsizingv1alpha1.LimitPolicy{RemoveCPULimits: true}, opts.LimitPolicy)
+
+	// Without a class the spec applies unchanged
+	assert.Equal(t, spec, spec.WithClassProfile("", classification))
+}
+
+func TestWithClassProfile_Precedence(t *testing.T) {
+	spec := RecommendationOptions{
+		Thresholds: rightsizingv1alpha1.ResourceThresholds{CPUUtilizationPercentile: 90, SafetyMargin: 5},
+	}
+	classification := rightsizingv1alpha1.ClassificationSpec{Enabled: true}
+
+	// Thresholds set in the spec win over the built-in profile, unset ones come from it
+	opts := spec.WithClassProfile(WorkloadClassUnpredictable, classification)
+	assert.Equal(t, 90, opts.Thresholds.CPUUtilizationPercentile)
+	assert.Equal(t, 99, opts.Thresholds.MemoryUtilizationPercentile)
+	assert.Equal(t, 5, opts.Thresholds.SafetyMargin)
+
+	// Profiles from the spec win over both
+	classification.Profiles = map[string]rightsizingv1alpha1.ClassProfile{
+		"Unpredictable": {CPUUtilizationPercentile: 98},
+	}
+	opts = spec.WithClassProfile(WorkloadClassUnpredictable, classification)
+	assert.Equal(t, 98, opts.Thresholds.CPUUtilizationPercentile)
+	assert.Equal(t, 5, opts.Thresholds.SafetyMargin)
+
+	// The CRD no longer defaults the thresholds, so an untouched spec gets the built-in profile
+	defaulted := defaultedSpec(t, map[string]any{"classification": map[string]any{"enabled": true}})
+	opts = RecommendationOptions{Thresholds: defaulted.Thresholds}.
+		WithClassProfile(WorkloadClassBursty, defaulted.Classification)
+	assert.Equal(t, 99, opts.Thresholds.MemoryUtilizationPercentile)
+	assert.Equal(t, 30, opts.Thresholds.SafetyMargin)
+}
+
+func TestWithClassProfile_DefaultedSpec(t *testing.T) {
+	// A spec without a recommender, or with an empty one, gets the class recommender after defaulting
+	for _, spec := range []map[string]any{
+		{"classification": map[string]any{"enabled": true}},
+		{"classification": map[string]any{"enabled": true}, "recommender": map[string]any{}},
+	} {
+		defaulted := defaultedSpec(t, spec)
+		opts := RecommendationOptions{Recommender: defaulted.Recommender}.
+			WithClassProfile(WorkloadClassGrowing, defaulted.Classification)
+		assert.Equal(t, ForecastRecommender, opts.Recommender.Name)
+	}
+
+	// A recommender the user selected is kept
+	defaulted := defaultedSpec(t, map[string]any{
+		"classification": map[string]any{"enabled": true},
+		"recommender":    map[string]any{"name": PercentileRecommender},
+	})
+	opts := RecommendationOptions{Recommender: defaulted.Recommender}.
+		WithClassProfile(WorkloadClassGrowing, defaulted.Classification)
+	assert.Equal(t, PercentileRecommender, opts.Recommender.Name)
+}
+
+func TestDescribeClassProfile(t *testing.T) {
+	spec := RecommendationOptions{}
+	opts := spec.WithClassProfile(WorkloadClassUnpredictable, rightsizingv1alpha1.ClassificationSpec{Enabled: true})
+
+	description := DescribeClassProfile(&WorkloadClassification{Class: WorkloadClassUnpredictable, Confidence: 0.8}, opts)
+	assert.Equal(t, "Classified as Unpredictable (80% confidence): 99th CPU and 99th memory percentile, "+
+		"limits from the 99th percentile", description)
+}
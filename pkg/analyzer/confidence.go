@@ -0,0 +1,259 @@
+// pkg/analyzer/confidence.go
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+// Confidence model constants
+const (
+	// dayConfidenceWeight is the share of confidence lost when none of the expected days is observed.
+	// Coverage already penalizes a short history, so days only weigh in on top of it.
+	dayConfidenceWeight = 0.25
+	// replicaConfidenceWeight is the share of confidence lost as the replica count drops towards zero
+	replicaConfidenceWeight = 0.1
+	// oomRestartDelay bounds how long after an OOM kill the container restarts. The kubelet backs off
+	// restarts of crash-looping containers for up to five minutes.
+	oomRestartDelay = 6 * time.Minute
+)
+
+// confidenceFactors scores the evidence behind a pod recommendation. cpuConfidence and memoryConfidence
+// are the confidences of the recommender's estimates, podMetrics holds the normalized metrics before any
+// samples were excluded, replicas is the number of replicas of the workload that were analyzed and change
+// is the change point the history was reset at, nil when there was none.
+func (r *RecommendationEngine) confidenceFactors(
+	podMetrics metrics.PodMetrics,
+	cpuConfidence, memoryConfidence, replicas int,
+	change *changePoint,
+) []rightsizingv1alpha1.ConfidenceFactor {
+	return []rightsizingv1alpha1.ConfidenceFactor{
+		variabilityFactor(cpuConfidence, memoryConfidence),
+		r.coverageFactor(podMetrics.Coverage),
+		r.daysFactor(podMetrics),
+		r.replicasFactor(replicas),
+		r.restartsFactor(podMetrics),
+		r.changePointFactor(podMetrics, change),
+	}
+}
+
+// containerConfidenceFactors adapts the confidence factors of a pod to one of its containers. Variability
+// comes from the container's own estimates and restarts from its own starts and OOM kills, while coverage,
+// days, replicas and change points are those of the pod. podMetrics holds the pod's normalized metrics
+// before any samples were excluded.
+func (r *RecommendationEngine) containerConfidenceFactors(
+	podFactors []rightsizingv1alpha1.ConfidenceFactor,
+	podMetrics metrics.PodMetrics,
+	name string,
+	cpuConfidence, memoryConfidence int,
+) []rightsizingv1alpha1.ConfidenceFactor {
+	container := metrics.PodMetrics{CPUUsageHistory: podMetrics.CPUUsageHistory}
+	for _, start := range podMetrics.ContainerStarts {
+		if start.Container == name {
+			container.ContainerStarts = append(container.ContainerStarts, start)
+		}
+	}
+	for _, kill := range podMetrics.OOMKills {
+		if kill.Container == name {
+			container.OOMKills = append(container.OOMKills, kill)
+		}
+	}
+
+	factors := make([]rightsizingv1alpha1.ConfidenceFactor, 0, len(podFactors))
+	for _, factor := range podFactors {
+		switch factor.Name {
+		case rightsizingv1alpha1.ConfidenceFactorVariability:
+			factor = variabilityFactor(cpuConfidence, memoryConfidence)
+		case rightsizingv1alpha1.ConfidenceFactorRestarts:
+			factor = r.restartsFactor(container)
+		}
+		factors = append(factors, factor)
+	}
+	return factors
+}
+
+// variabilityFactor scores the lower of the confidences of the recommender's CPU and memory estimates
+func variabilityFactor(cpuConfidence, memoryConfidence int) rightsizingv1alpha1.ConfidenceFactor {
+	return rightsizingv1alpha1.ConfidenceFactor{
+		Name:   rightsizingv1alpha1.ConfidenceFactorVariability,
+		Score:  min(cpuConfidence, memoryConfidence),
+		Detail: fmt.Sprintf("%d%% for CPU and %d%% for memory usage", cpuConfidence, memoryConfidence),
+	}
+}
+
+// coverageFactor scores the share of the window the CPU and memory metrics cover. Coverage of at least
+// FullConfidenceCoverage keeps full confidence, lower coverage reduces it proportionally.
+func (r *RecommendationEngine) coverageFactor(coverage *metrics.Coverage) rightsizingv1alpha1.ConfidenceFactor {
+	factor := rightsizingv1alpha1.ConfidenceFactor{Name: rightsizingv1alpha1.ConfidenceFactorCoverage, Score: 100}
+	if coverage == nil {
+		factor.Detail = "coverage unknown"
+		return factor
+	}
+
+	factor.Detail = fmt.Sprintf("metrics cover %.0f%% of the analysis window with %d gaps too long to interpolate",
+		coverage.Min()*100, coverage.Gaps)
+	if r.FullConfidenceCoverage > 0 {
+		factor.Score = scoreFraction(math.Min(coverage.Min()/r.FullConfidenceCoverage, 1))
+	}
+	return factor
+}
+
+// daysFactor scores the number of distinct UTC days with CPU or memory samples against the days the
+// analysis window spans, up to FullConfidenceDays, so a week of data outweighs an hour of it
+func (r *RecommendationEngine) daysFactor(podMetrics metrics.PodMetrics) rightsizingv1alpha1.ConfidenceFactor {
+	days := make(map[string]bool)
+	var first, last time.Time
+	for _, history := range [][]metrics.ResourceUsage{podMetrics.CPUUsageHistory, podMetrics.MemUsageHistory} {
+		for _, usage := range history {
+			days[usage.Timestamp.UTC().Format(time.DateOnly)] = true
+			if first.IsZero() || usage.Timestamp.Before(first) {
+				first = usage.Timestamp
+			}
+			if usage.Timestamp.After(last) {
+				last = usage.Timestamp
+			}
+		}
+	}
+
+	// Without a window the samples span the window
+	window := last.Sub(first)
+	if !podMetrics.StartTime.IsZero() && podMetrics.EndTime.After(podMetrics.StartTime) {
+		window = podMetrics.EndTime.Sub(podMetrics.StartTime)
+	}
+	expected := max(int(math.Ceil(window.Hours()/24)), 1)
+	if r.FullConfidenceDays > 0 {
+		expected = min(expected, r.FullConfidenceDays)
+	}
+
+	observed := math.Min(float64(len(days))/float64(expected), 1)
+	return rightsizingv1alpha1.ConfidenceFactor{
+		Name:   rightsizingv1alpha1.ConfidenceFactorDays,
+		Score:  scoreFraction(1 - dayConfidenceWeight*(1-observed)),
+		Detail: fmt.Sprintf("%d distinct days observed of %d expected", len(days), expected),
+	}
+}
+
+// replicasFactor scores the number of replicas analyzed. FullConfidenceReplicas or more keep full
+// confidence; a usage pattern seen in a single replica may be peculiar to it.
+func (r *RecommendationEngine) replicasFactor(replicas int) rightsizingv1alpha1.ConfidenceFactor {
+	factor := rightsizingv1alpha1.ConfidenceFactor{
+		Name:   rightsizingv1alpha1.ConfidenceFactorReplicas,
+		Score:  100,
+		Detail: fmt.Sprintf("%d replicas analyzed", replicas),
+	}
+	if r.FullConfidenceReplicas > 0 {
+		observed := math.Min(float64(replicas)/float64(r.FullConfidenceReplicas), 1)
+		factor.Score = scoreFraction(1 - replicaConfidenceWeight*(1-observed))
+	}
+	return factor
+}
+
+// restartsFactor deducts RestartConfidencePenalty for every container restart and OOMConfidencePenalty
+// for every OOM kill in the window. Restarts are container starts after the first sample, since usage
+// around them is not representative and OOM-killed usage is capped at the limit. The restart an OOM kill
+// causes is left to the OOM penalty, so each kill is deducted once.
+func (r *RecommendationEngine) restartsFactor(podMetrics metrics.PodMetrics) rightsizingv1alpha1.ConfidenceFactor {
+	var first time.Time
+	for _, usage := range podMetrics.CPUUsageHistory {
+		if first.IsZero() || usage.Timestamp.Before(first) {
+			first = usage.Timestamp
+		}
+	}
+	afterKill := oomRestarts(podMetrics)
+	restarts := 0
+	for i, start := range podMetrics.ContainerStarts {
+		if start.Time.After(first) && !afterKill[i] {
+			restarts++
+		}
+	}
+
+	penalty := restarts*r.RestartConfidencePenalty + len(podMetrics.OOMKills)*r.OOMConfidencePenalty
+	return rightsizingv1alpha1.ConfidenceFactor{
+		Name:   rightsizingv1alpha1.ConfidenceFactorRestarts,
+		Score:  max(100-penalty, 0),
+		Detail: fmt.Sprintf("%d OOM kills and %d other restarts in the window", len(podMetrics.OOMKills), restarts),
+	}
+}
+
+// oomRestarts returns the indexes of the container starts that are restarts after an OOM kill: the first
+// start of the killed container within oomRestartDelay of each kill. Starts and kills of unknown
+// containers match any container.
+func oomRestarts(podMetrics metrics.PodMetrics) map[int]bool {
+	matched := make(map[int]bool)
+	for _, kill := range podMetrics.OOMKills {
+		for i, start := range podMetrics.ContainerStarts {
+			if matched[i] || start.Time.Before(kill.Time) || start.Time.Sub(kill.Time) > oomRestartDelay {
+				continue
+			}
+			if kill.Container == "" || start.Container == "" || kill.Container == start.Container {
+				matched[i] = true
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// changePointFactor scores the history left after a change point against FullConfidencePostChange.
+// Right after a change the recommendation rests on little data, so confidence recovers as it grows.
+func (r *RecommendationEngine) changePointFactor(
+	podMetrics metrics.PodMetrics,
+	change *changePoint,
+) rightsizingv1alpha1.ConfidenceFactor {
+	factor := rightsizingv1alpha1.ConfidenceFactor{Name: rightsizingv1alpha1.ConfidenceFactorChangePoint, Score: 100}
+	if change == nil {
+		factor.Detail = "no significant usage shift in the window"
+		return factor
+	}
+
+	var last time.Time
+	for _, history := range [][]metrics.ResourceUsage{podMetrics.CPUUsageHistory, podMetrics.MemUsageHistory} {
+		for _, usage := range history {
+			if usage.Timestamp.After(last) {
+				last = usage.Timestamp
+			}
+		}
+	}
+	since := max(last.Sub(change.Time), 0)
+	factor.Detail = fmt.Sprintf("%s, %s of data since", change, since.Round(time.Minute))
+	if r.FullConfidencePostChange > 0 {
+		observed := math.Min(float64(since)/float64(r.FullConfidencePostChange), 1)
+		factor.Score = scoreFraction(1 - changeConfidenceWeight*(1-observed))
+	}
+	return factor
+}
+
+// combineConfidence returns the confidence the factors amount to: the product of their scores
+func combineConfidence(factors []rightsizingv1alpha1.ConfidenceFactor) int {
+	confidence := 1.0
+	for _, factor := range factors {
+		confidence *= float64(factor.Score) / 100
+	}
+	return scoreFraction(confidence)
+}
+
+// describeConfidence notes the factors besides variability that reduced the confidence, for the
+// recommendation reason. It returns an empty string when none did.
+func describeConfidence(confidence int, factors []rightsizingv1alpha1.ConfidenceFactor) string {
+	var reduced []string
+	for _, factor := range factors {
+		if factor.Name != rightsizingv1alpha1.ConfidenceFactorVariability && factor.Score < 100 {
+			reduced = append(reduced, fmt.Sprintf("%s to %d%% (%s)",
+				strings.ToLower(string(factor.Name)), factor.Score, factor.Detail))
+		}
+	}
+	if len(reduced) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" Confidence %d%% reduced by %s.", confidence, strings.Join(reduced, ", "))
+}
+
+// scoreFraction converts a fraction from 0 to 1 into a score from 0 to 100
+func scoreFraction(fraction float64) int {
+	return int(math.Round(fraction * 100))
+}
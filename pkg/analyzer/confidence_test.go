@@ -0,0 +1,217 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+// confidenceScore returns the score of the named confidence factor of a recommendation, or -1 without it
+func confidenceScore(recommendation rightsizingv1alpha1.PodRecommendation, name rightsizingv1alpha1.ConfidenceFactorName) int {
+	for _, factor := range recommendation.ConfidenceFactors {
+		if factor.Name == name {
+			return factor.Score
+		}
+	}
+	return -1
+}
+
+func TestGenerateRecommendations_Coverage(t *testing.T) {
+	engine := NewRecommendationEngine()
+	engine.FullConfidenceReplicas = 1
+	ctx := context.Background()
+	end := usageStart.Add(59 * time.Minute)
+
+	// Usage every minute for the last half of a two-hour window
+	podMetrics := metrics.PodMetrics{
+		PodName:         "test-pod-1",
+		Namespace:       "default",
+		CPUUsageHistory: usageHistory(60, time.Minute, steady(0.5), "cores"),
+		MemUsageHistory: usageHistory(60, time.Minute, steady(400*1024*1024), "bytes"),
+		StartTime:       end.Add(-2 * time.Hour),
+		EndTime:         end,
+	}
+	workloadMetrics := &metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{podMetrics}}
+
+	// Half the window against 70% for full confidence
+	engine.DefaultConfidenceThreshold = 50
+	recommendations, err := engine.GenerateRecommendations(ctx, workloadMetrics, rightsizingv1alpha1.ResourceThresholds{})
+	require.NoError(t, err)
+	require.Len(t, recommendations, 1)
+	assert.InDelta(t, 100*0.5/0.7, recommendations[0].Confidence, 2)
+	assert.Contains(t, recommendations[0].Reason, "reduced by coverage to 71% "+
+		"(metrics cover 50% of the analysis window with 2 gaps too long to interpolate).")
+
+	// Metrics covering the whole window keep full confidence
+	workloadMetrics.Pods[0].StartTime = end.Add(-59 * time.Minute)
+	recommendations, err = engine.GenerateRecommendations(ctx, workloadMetrics, rightsizingv1alpha1.ResourceThresholds{})
+	require.NoError(t, err)
+	require.Len(t, recommendations, 1)
+	assert.Equal(t, 100, recommendations[0].Confidence)
+	assert.NotContains(t, recommendations[0].Reason, "Confidence")
+}
+
+func TestGenerateRecommendations_ConfidenceFactors(t *testing.T) {
+	engine := NewRecommendationEngine()
+	ctx := context.Background()
+
+	// Ten points from the last hour of a week-long window
+	recent := metrics.PodMetrics{
+		PodName:         "recent",
+		Namespace:       "default",
+		CPUUsageHistory: usageHistory(10, 6*time.Minute, steady(0.5), "cores"),
+		MemUsageHistory: usageHistory(10, 6*time.Minute, steady(400*1024*1024), "bytes"),
+		StartTime:       usageStart.Add(54*time.Minute - 7*24*time.Hour),
+		EndTime:         usageStart.Add(54 * time.Minute),
+	}
+	// A full week sampled every hour
+	end := usageStart.Add(167 * time.Hour)
+	week := metrics.PodMetrics{
+		PodName:         "week",
+		Namespace:       "default",
+		CPUUsageHistory: usageHistory(7*24, time.Hour, steady(0.5), "cores"),
+		MemUsageHistory: usageHistory(7*24, time.Hour, steady(400*1024*1024), "bytes"),
+		StartTime:       end.Add(-7 * 24 * time.Hour),
+		EndTime:         end,
+	}
+
+	for _, pod := range []metrics.PodMetrics{recent, week} {
+		factors := engine.confidenceFactors(metrics.NormalizePodMetrics(pod, engine.MaxInterpolatedGap), 100, 100, 3, nil)
+		require.Len(t, factors, 6)
+		assert.Equal(t, rightsizingv1alpha1.ConfidenceFactorVariability, factors[0].Name)
+		assert.Equal(t, 100, factors[0].Score)
+	}
+	score := func(pod metrics.PodMetrics) int {
+		return combineConfidence(engine.confidenceFactors(
+			metrics.NormalizePodMetrics(pod, engine.MaxInterpolatedGap), 100, 100, 3, nil))
+	}
+	assert.Less(t, score(recent), 10, "an hour of a week scores far below")
+	assert.Equal(t, 100, score(week))
+
+	recentFactors := engine.confidenceFactors(metrics.NormalizePodMetrics(recent, engine.MaxInterpolatedGap), 100, 100, 3, nil)
+	assert.Equal(t, "1 distinct days observed of 7 expected", recentFactors[2].Detail)
+	assert.Equal(t, 79, recentFactors[2].Score)
+
+	// Replicas and restarts
+	assert.Equal(t, 95, engine.replicasFactor(1).Score)
+	assert.Equal(t, 100, engine.replicasFactor(2).Score)
+	assert.Equal(t, 100, engine.replicasFactor(5).Score)
+
+	week.ContainerStarts = []metrics.ContainerStart{
+		{Time: week.StartTime.Add(-time.Hour), Container: "app"},
+		{Time: end.Add(-36 * time.Hour), Container: "app"},
+		{Time: end.Add(-24 * time.Hour), Container: "app"},
+		{Time: end.Add(-12*time.Hour + 10*time.Second), Container: "app"},
+	}
+	week.OOMKills = []metrics.OOMKill{{Time: end.Add(-12 * time.Hour), Container: "app"}}
+	restarts := engine.restartsFactor(week)
+	assert.Equal(t, 100-2*engine.RestartConfidencePenalty-engine.OOMConfidencePenalty, restarts.Score)
+	assert.Equal(t, "1 OOM kills and 2 other restarts in the window", restarts.Detail)
+
+	// Every factor is exposed in the recommendation and their product is the confidence
+	workloadMetrics := &metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{week}}
+	engine.DefaultConfidenceThreshold = 0
+	recommendations, err := engine.GenerateRecommendations(ctx, workloadMetrics, rightsizingv1alpha1.ResourceThresholds{})
+	require.NoError(t, err)
+	require.Len(t, recommendations, 1)
+	recommendation := recommendations[0]
+	require.Len(t, recommendation.ConfidenceFactors, 6)
+	assert.Equal(t, 95, confidenceScore(recommendation, rightsizingv1alpha1.ConfidenceFactorReplicas))
+	assert.Equal(t, 70, confidenceScore(recommendation, rightsizingv1alpha1.ConfidenceFactorRestarts))
+	assert.Equal(t, combineConfidence(recommendation.ConfidenceFactors), recommendation.Confidence)
+	assert.Contains(t, recommendation.Reason, "replicas to 95% (1 replicas analyzed), "+
+		"restarts to 70% (1 OOM kills and 2 other restarts in the window).")
+}
+
+func TestGenerateRecommendations_HealthySmallWorkloads(t *testing.T) {
+	ctx := context.Background()
+	end := usageStart.Add(7 * 24 * time.Hour)
+
+	tests := []struct {
+		name     string
+		replicas int
+	}{
+		{name: "one replica with a week of data", replicas: 1},
+		{name: "two replicas with a week of data", replicas: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewRecommendationEngine()
+			random := rand.New(rand.NewSource(1))
+
+			// A daily cycle with a restart two days ago in a week-long window
+			workloadMetrics := &metrics.WorkloadMetrics{}
+			for i := 0; i < tt.replicas; i++ {
+				cpu := usageHistory(7*24*12, 5*time.Minute, dailyWave(random, 5*time.Minute), "cores")
+				wave := dailyWave(random, 5*time.Minute)
+				memory := usageHistory(7*24*12, 5*time.Minute, func(i int) float64 {
+					return wave(i) * 256 * 1024 * 1024
+				}, "bytes")
+				workloadMetrics.Pods = append(workloadMetrics.Pods, metrics.PodMetrics{
+					PodName:         fmt.Sprintf("web-%d", i),
+					Namespace:       "default",
+					CPUUsageHistory: cpu,
+					MemUsageHistory: memory,
+					ContainerStarts: []metrics.ContainerStart{{Time: end.Add(-48 * time.Hour), Container: "app"}},
+					StartTime:       end.Add(-7 * 24 * time.Hour),
+					EndTime:         end,
+				})
+			}
+
+			recommendations, err := engine.GenerateRecommendations(ctx, workloadMetrics, rightsizingv1alpha1.ResourceThresholds{})
+			require.NoError(t, err)
+			require.Len(t, recommendations, tt.replicas)
+			for _, recommendation := range recommendations {
+				assert.GreaterOrEqual(t, recommendation.Confidence, engine.DefaultConfidenceThreshold, recommendation.Reason)
+			}
+		})
+	}
+}
+
+func TestGenerateRecommendations_ContainerConfidence(t *testing.T) {
+	engine := NewRecommendationEngine()
+	ctx := context.Background()
+	end := usageStart.Add(7*24*time.Hour - 10*time.Minute)
+
+	// A week of steady usage in which only the proxy restarted
+	pod := metrics.PodMetrics{
+		PodName:         "web-0",
+		Namespace:       "default",
+		CPUUsageHistory: usageHistory(7*24*6, 10*time.Minute, steady(0.5), "cores"),
+		MemUsageHistory: usageHistory(7*24*6, 10*time.Minute, steady(400*1024*1024), "bytes"),
+		StartTime:       end.Add(-7 * 24 * time.Hour),
+		EndTime:         end,
+	}
+	pod.Containers = map[string]metrics.ContainerMetrics{
+		"app":   {ContainerName: "app", CPUUsageHistory: pod.CPUUsageHistory, MemUsageHistory: pod.MemUsageHistory},
+		"proxy": {ContainerName: "proxy", CPUUsageHistory: pod.CPUUsageHistory, MemUsageHistory: pod.MemUsageHistory},
+	}
+	for _, hours := range []int{72, 48, 24} {
+		pod.ContainerStarts = append(pod.ContainerStarts,
+			metrics.ContainerStart{Time: end.Add(time.Duration(-hours) * time.Hour), Container: "proxy"})
+	}
+
+	recommendations, err := engine.GenerateRecommendations(ctx,
+		&metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{pod}}, rightsizingv1alpha1.ResourceThresholds{})
+	require.NoError(t, err)
+	require.Len(t, recommendations, 1)
+	recommendation := recommendations[0]
+	require.Len(t, recommendation.Containers, 2)
+	app, proxy := recommendation.Containers[0], recommendation.Containers[1]
+
+	// Containers share the pod's evidence and keep their own restarts
+	assert.Equal(t, 85, confidenceScore(recommendation, rightsizingv1alpha1.ConfidenceFactorRestarts))
+	assert.Equal(t, recommendation.Confidence, proxy.Confidence)
+	assert.Equal(t, 95, app.Confidence, app.Reason)
+	assert.Contains(t, app.Reason, "Confidence 95% reduced by replicas to 95% (1 replicas analyzed).")
+	assert.Contains(t, proxy.Reason, "restarts to 85% (0 OOM kills and 3 other restarts in the window)")
+}
@@ -13,6 +13,8 @@ type CostCalculator struct {
 	CPUCostPerCoreMonth float64
 	// Cost per GB memory per month (USD)
 	MemoryCostPerGBMonth float64
+	// Cost per GB ephemeral storage per month (USD), based on node disk pricing
+	StorageCostPerGBMonth float64
 	// Cloud provider (for different pricing models)
 	CloudProvider string
 }
@@ -21,27 +23,30 @@ type CostCalculator struct {
 func NewCostCalculator() *CostCalculator {
 	return &CostCalculator{
 		// AKS Standard_D2s_v3 approximate costs (adjust for your region)
-		CPUCostPerCoreMonth:  20.0, // ~$20 per core per month
-		MemoryCostPerGBMonth: 2.5,  // ~$2.50 per GB per month
-		CloudProvider:        "azure",
+		CPUCostPerCoreMonth:   20.0,  // ~$20 per core per month
+		MemoryCostPerGBMonth:  2.5,   // ~$2.50 per GB per month
+		StorageCostPerGBMonth: 0.075, // ~$0.075 per GB per month (Standard SSD)
+		CloudProvider:         "azure",
 	}
 }
 
 // NewAWSCostCalculator creates a cost calculator with EKS pricing
 func NewAWSCostCalculator() *CostCalculator {
 	return &CostCalculator{
-		CPUCostPerCoreMonth:  25.0, // EC2 pricing varies significantly
-		MemoryCostPerGBMonth: 3.0,
-		CloudProvider:        "aws",
+		CPUCostPerCoreMonth:   25.0, // EC2 pricing varies significantly
+		MemoryCostPerGBMonth:  3.0,
+		StorageCostPerGBMonth: 0.08, // gp3
+		CloudProvider:         "aws",
 	}
 }
 
 // NewGCPCostCalculator creates a cost calculator with GKE pricing
 func NewGCPCostCalculator() *CostCalculator {
 	return &CostCalculator{
-		CPUCostPerCoreMonth:  22.0,
-		MemoryCostPerGBMonth: 2.8,
-		CloudProvider:        "gcp",
+		CPUCostPerCoreMonth:   22.0,
+		MemoryCostPerGBMonth:  2.8,
+		StorageCostPerGBMonth: 0.10, // pd-balanced
+		CloudProvider:         "gcp",
 	}
 }
 
@@ -71,6 +76,16 @@ func (c *CostCalculator) CalculateSavings(current, recommended corev1.ResourceRe
 		}
 	}
 
+	// Calculate ephemeral-storage savings
+	if currentStorage, exists := current.Requests[corev1.ResourceEphemeralStorage]; exists {
+		if recommendedStorage, recExists := recommended.Requests[corev1.ResourceEphemeralStorage]; recExists {
+			storageDiff := currentStorage.AsApproximateFloat64() - recommendedStorage.AsApproximateFloat64()
+			if storageDiff > 0 {
+				savings.EphemeralStorageSavings = resource.NewQuantity(int64(storageDiff), resource.BinarySI)
+			}
+		}
+	}
+
 	// Calculate cost savings
 	monthlyCostSavings := c.calculateMonthlySavings(savings)
 	if monthlyCostSavings > 0 {
@@ -96,6 +111,12 @@ func (c *CostCalculator) calculateMonthlySavings(savings rightsizingv1alpha1.Res
 		totalSavings += memoryGB * c.MemoryCostPerGBMonth
 	}
 
+	// Ephemeral-storage savings
+	if savings.EphemeralStorageSavings != nil {
+		storageGB := savings.EphemeralStorageSavings.AsApproximateFloat64() / (1024 * 1024 * 1024)
+		totalSavings += storageGB * c.StorageCostPerGBMonth
+	}
+
 	return totalSavings
 }
 
@@ -108,6 +129,7 @@ func (c *CostCalculator) EstimateClusterSavings(recommendations []rightsizingv1a
 
 	totalCPUSavings := 0.0
 	totalMemorySavings := 0.0
+	totalStorageSavings := 0.0
 
 	for _, rec := range recommendations {
 		if rec.PotentialSavings.CPUSavings != nil {
@@ -116,12 +138,17 @@ func (c *CostCalculator) EstimateClusterSavings(recommendations []rightsizingv1a
 		if rec.PotentialSavings.MemorySavings != nil {
 			totalMemorySavings += rec.PotentialSavings.MemorySavings.AsApproximateFloat64() / (1024 * 1024 * 1024)
 		}
+		if rec.PotentialSavings.EphemeralStorageSavings != nil {
+			totalStorageSavings += rec.PotentialSavings.EphemeralStorageSavings.AsApproximateFloat64() / (1024 * 1024 * 1024)
+		}
 	}
 
-	totalMonthlySavings := totalCPUSavings*c.CPUCostPerCoreMonth + totalMemorySavings*c.MemoryCostPerGBMonth
+	totalMonthlySavings := totalCPUSavings*c.CPUCostPerCoreMonth + totalMemorySavings*c.MemoryCostPerGBMonth +
+		totalStorageSavings*c.StorageCostPerGBMonth
 
 	report.TotalCPUSavings = fmt.Sprintf("%.3f cores", totalCPUSavings)
 	report.TotalMemorySavings = fmt.Sprintf("%.2f GB", totalMemorySavings)
+	report.TotalEphemeralStorageSavings = fmt.Sprintf("%.2f GB", totalStorageSavings)
 	report.EstimatedMonthlySavings = fmt.Sprintf("$%.2f", totalMonthlySavings)
 	report.EstimatedAnnualSavings = fmt.Sprintf("$%.2f", totalMonthlySavings*12)
 
@@ -137,11 +164,12 @@ func (c *CostCalculator) EstimateClusterSavings(recommendations []rightsizingv1a
 
 // ClusterSavingsReport provides a comprehensive savings analysis
 type ClusterSavingsReport struct {
-	TotalRecommendations    int
-	TotalCPUSavings         string
-	TotalMemorySavings      string
-	EstimatedMonthlySavings string
-	EstimatedAnnualSavings  string
-	ROIAnalysis             string
-	CloudProvider           string
+	TotalRecommendations         int
+	TotalCPUSavings              string
+	TotalMemorySavings           string
+	TotalEphemeralStorageSavings string
+	EstimatedMonthlySavings      string
+	EstimatedAnnualSavings       string
+	ROIAnalysis                  string
+	CloudProvider                string
 }
@@ -0,0 +1,187 @@
+// pkg/analyzer/forecast.go
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/stats"
+)
+
+// ForecastRecommender is the name of the recommender that projects growing usage over the horizon
+const ForecastRecommender = "Forecast"
+
+// Forecast constants
+const (
+	// defaultForecastHorizon applies when neither the engine nor the parameters set a horizon
+	defaultForecastHorizon = 24 * time.Hour
+	// defaultTrendSignificance is the share of the variance the trend and daily cycle must explain
+	defaultTrendSignificance = 0.5
+)
+
+// forecastRecommender fits a linear trend plus a daily cycle to the hourly usage and sizes for the
+// projected percentile at the end of the horizon. Usage without a significant upward trend is sized
+// like the percentile recommender, so flat or shrinking workloads are not sized for growth.
+type forecastRecommender struct {
+	engine *RecommendationEngine
+	// horizon overrides the horizon handed in by the engine
+	horizon      time.Duration
+	significance float64
+}
+
+func newForecastRecommender(engine *RecommendationEngine, parameters map[string]string) (Recommender, error) {
+	if err := checkParameters(parameters, "horizon", "significance"); err != nil {
+		return nil, err
+	}
+
+	recommender := &forecastRecommender{engine: engine, significance: defaultTrendSignificance}
+
+	if value, ok := parameters["horizon"]; ok {
+		horizon, err := rightsizingv1alpha1.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("horizon: %w", err)
+		}
+		if horizon <= 0 {
+			return nil, fmt.Errorf("horizon must be positive")
+		}
+		recommender.horizon = horizon
+	}
+	if value, ok := parameters["significance"]; ok {
+		significance, err := strconv.ParseFloat(value, 64)
+		if err != nil || significance <= 0 || significance > 1 {
+			return nil, fmt.Errorf("significance must be a number greater than 0 and at most 1")
+		}
+		recommender.significance = significance
+	}
+
+	return recommender, nil
+}
+
+// Estimate returns the projected utilization percentile at the end of the horizon when usage grows
+// significantly, and the utilization percentile of the history otherwise. The projection never drops
+// below the observed percentile.
+func (f *forecastRecommender) Estimate(input RecommenderInput) (UsageEstimate, error) {
+	sketch, moments := usageStats(input.History)
+	observed := sketch.Quantile(float64(input.Percentile) / 100)
+	fallback := UsageEstimate{
+		Value:      observed,
+		Confidence: f.engine.momentsConfidence(moments),
+		Reason: fmt.Sprintf("Based on %dth percentile of %d data points, no significant growth",
+			input.Percentile, sketch.Count()),
+	}
+
+	horizon := f.horizon
+	if horizon == 0 {
+		horizon = input.Horizon
+	}
+	if horizon == 0 {
+		horizon = defaultForecastHorizon
+	}
+
+	forecast := fitForecast(input.History)
+	if forecast == nil || forecast.slope <= 0 || forecast.explained < f.significance {
+		return fallback, nil
+	}
+
+	// Residuals around the model give the spread to add to the projected usage
+	residuals := stats.NewSketch()
+	for _, residual := range forecast.residuals {
+		residuals.Add(residual)
+	}
+	spread := residuals.Quantile(float64(input.Percentile) / 100)
+
+	// The trend keeps growing, so the highest point of the cycle in the last day of the horizon counts
+	steps := int(horizon / seasonalityStep)
+	projected := 0.0
+	for step := max(steps-24, 0) + 1; step <= steps; step++ {
+		projected = math.Max(projected, forecast.at(len(forecast.fitted)-1+step))
+	}
+	projected = math.Max(projected+spread, observed)
+
+	// Confidence reflects how well the model fits, like the spread of a stable series
+	var modelMoments stats.Moments
+	for _, residual := range forecast.residuals {
+		modelMoments.Add(forecast.fitted[len(forecast.fitted)-1] + residual)
+	}
+
+	return UsageEstimate{
+		Value:      projected,
+		Confidence: f.engine.momentsConfidence(modelMoments),
+		Reason: fmt.Sprintf("Based on %dth percentile projected %s ahead from a trend of %s per day over %d data points",
+			input.Percentile, horizon, formatUsage(input.Resource, forecast.slope*24), sketch.Count()),
+	}, nil
+}
+
+// forecastModel is a linear trend with an optional daily cycle fitted to an hourly series
+type forecastModel struct {
+	slope, intercept float64
+	// seasonal holds the daily cycle by hour offset from the first interval, nil without a cycle
+	seasonal []float64
+	// fitted and residuals hold the model and the remaining deviation for every interval
+	fitted    []float64
+	residuals []float64
+	// explained is the share of the variance the model explains, from 0 to 1
+	explained float64
+}
+
+// at returns the model value for interval i, counted from the first interval of the series
+func (m *forecastModel) at(i int) float64 {
+	value := m.intercept + m.slope*float64(i)
+	if m.seasonal != nil {
+		value += m.seasonal[i%len(m.seasonal)]
+	}
+	return value
+}
+
+// fitForecast fits a linear trend to the hourly usage and, when the detrended series has a daily
+// cycle, the average deviation of every hour of the day. It returns nil when the history does not
+// cover at least a day with samples in minSeasonalityCoverage of its hours.
+func fitForecast(history []metrics.ResourceUsage) *forecastModel {
+	series := metrics.Resample(history, time.Time{}, time.Time{}, seasonalityStep, math.MaxInt)
+	if len(series.Values) < 24 || series.Coverage < minSeasonalityCoverage {
+		return nil
+	}
+	values := series.Values
+
+	detrended := detrend(values)
+	model := &forecastModel{}
+	model.intercept = values[0] - detrended[0]
+	model.slope = (values[len(values)-1] - detrended[len(values)-1] - model.intercept) / float64(len(values)-1)
+
+	if periodStrength(detrended, DailyPeriod) >= defaultSeasonalityThreshold {
+		model.seasonal = make([]float64, 24)
+		counts := make([]int, 24)
+		for i, residual := range detrended {
+			model.seasonal[i%24] += residual
+			counts[i%24]++
+		}
+		for hour := range model.seasonal {
+			model.seasonal[hour] /= float64(counts[hour])
+		}
+	}
+
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	var total, remaining float64
+	model.fitted = make([]float64, len(values))
+	model.residuals = make([]float64, len(values))
+	for i, v := range values {
+		model.fitted[i] = model.at(i)
+		model.residuals[i] = v - model.fitted[i]
+		total += (v - mean) * (v - mean)
+		remaining += model.residuals[i] * model.residuals[i]
+	}
+	if total > 0 {
+		model.explained = 1 - remaining/total
+	}
+
+	return model
+}
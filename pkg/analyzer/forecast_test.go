@@ -0,0 +1,176 @@
+package analyzer
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+// growth grows from 0.5 cores by 0.24 cores a day with some noise, for samples every five minutes
+func growth(random *rand.Rand) func(i int) float64 {
+	return func(i int) float64 {
+		return 0.5 + 0.01*float64(i)/12 + 0.01*random.NormFloat64()
+	}
+}
+
+func TestForecastRecommender(t *testing.T) {
+	engine := NewRecommendationEngine()
+	random := rand.New(rand.NewSource(1))
+	history := usageHistory(72*12, 5*time.Minute, growth(random), "cores")
+
+	recommender, err := engine.NewRecommender(rightsizingv1alpha1.RecommenderSpec{Name: ForecastRecommender})
+	require.NoError(t, err)
+
+	// Two days ahead the trend adds about 0.48 cores to the latest usage of about 1.22 cores
+	estimate, err := recommender.Estimate(RecommenderInput{
+		Resource: corev1.ResourceCPU, History: history, Percentile: 95, Horizon: 48 * time.Hour})
+	require.NoError(t, err)
+	assert.InDelta(t, 1.7, estimate.Value, 0.05)
+	assert.GreaterOrEqual(t, estimate.Confidence, 90)
+	assert.Regexp(t, `projected 48h0m0s ahead from a trend of 24\dm per day`, estimate.Reason)
+
+	// Without a horizon from the engine the default applies, and the parameter overrides both
+	estimate, err = recommender.Estimate(RecommenderInput{Resource: corev1.ResourceCPU, History: history, Percentile: 95})
+	require.NoError(t, err)
+	assert.InDelta(t, 1.46, estimate.Value, 0.05)
+
+	recommender, err = engine.NewRecommender(rightsizingv1alpha1.RecommenderSpec{
+		Name:       ForecastRecommender,
+		Parameters: map[string]string{"horizon": "4d"},
+	})
+	require.NoError(t, err)
+	estimate, err = recommender.Estimate(RecommenderInput{
+		Resource: corev1.ResourceCPU, History: history, Percentile: 95, Horizon: 48 * time.Hour})
+	require.NoError(t, err)
+	assert.InDelta(t, 2.18, estimate.Value, 0.05)
+}
+
+func TestForecastRecommender_DailyCycle(t *testing.T) {
+	engine := NewRecommendationEngine()
+	random := rand.New(rand.NewSource(1))
+
+	// Growth on top of a daily wave, which a plain linear fit could not explain
+	wave := dailyWave(random, 15*time.Minute)
+	history := usageHistory(7*24*4, 15*time.Minute, func(i int) float64 {
+		return wave(i) + 0.1*float64(i)/(24*4)
+	}, "cores")
+
+	forecast := fitForecast(history)
+	require.NotNil(t, forecast)
+	assert.NotNil(t, forecast.seasonal)
+	assert.Greater(t, forecast.explained, 0.9)
+	assert.InDelta(t, 0.1/24, forecast.slope, 0.001)
+
+	recommender, err := engine.NewRecommender(rightsizingv1alpha1.RecommenderSpec{Name: ForecastRecommender})
+	require.NoError(t, err)
+	estimate, err := recommender.Estimate(RecommenderInput{
+		Resource: corev1.ResourceCPU, History: history, Percentile: 95, Horizon: 24 * time.Hour})
+	require.NoError(t, err)
+
+	// The next daily peak is about 1.5 cores plus 0.8 cores of growth
+	assert.InDelta(t, 2.3, estimate.Value, 0.1)
+}
+
+func TestForecastRecommender_NoGrowth(t *testing.T) {
+	engine := NewRecommendationEngine()
+	random := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		name    string
+		history []metrics.ResourceUsage
+	}{
+		{name: "flat", history: usageHistory(72*12, 5*time.Minute, func(int) float64 {
+			return 1 + 0.1*random.NormFloat64()
+		}, "cores")},
+		{name: "shrinking", history: usageHistory(72*12, 5*time.Minute, func(i int) float64 {
+			return 0.3 + 0.01*(72-float64(i)/12)
+		}, "cores")},
+		{name: "shorter than a day", history: usageHistory(200, 5*time.Minute, growth(random), "cores")},
+	}
+
+	recommender, err := engine.NewRecommender(rightsizingv1alpha1.RecommenderSpec{Name: ForecastRecommender})
+	require.NoError(t, err)
+	percentile, err := engine.NewRecommender(rightsizingv1alpha1.RecommenderSpec{})
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := RecommenderInput{Resource: corev1.ResourceCPU, History: tt.history, Percentile: 95, Horizon: 48 * time.Hour}
+			estimate, err := recommender.Estimate(input)
+			require.NoError(t, err)
+			expected, err := percentile.Estimate(input)
+			require.NoError(t, err)
+
+			assert.Equal(t, expected.Value, estimate.Value)
+			assert.Contains(t, estimate.Reason, "no significant growth")
+		})
+	}
+}
+
+func TestForecastRecommender_Parameters(t *testing.T) {
+	engine := NewRecommendationEngine()
+
+	tests := []struct {
+		name       string
+		parameters map[string]string
+		wantError  string
+	}{
+		{name: "defaults"},
+		{name: "all parameters", parameters: map[string]string{"horizon": "1w", "significance": "0.8"}},
+		{name: "invalid horizon", parameters: map[string]string{"horizon": "soon"}, wantError: "horizon"},
+		{name: "zero horizon", parameters: map[string]string{"horizon": "0"}, wantError: "horizon must be positive"},
+		{name: "invalid significance", parameters: map[string]string{"significance": "1.5"},
+			wantError: "significance must be a number greater than 0 and at most 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := engine.NewRecommender(rightsizingv1alpha1.RecommenderSpec{
+				Name:       ForecastRecommender,
+				Parameters: tt.parameters,
+			})
+			if tt.wantError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestGenerateRecommendations_Forecast(t *testing.T) {
+	engine := NewRecommendationEngine()
+	random := rand.New(rand.NewSource(1))
+
+	workloadMetrics := &metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{{
+		PodName:         "test-pod-1",
+		Namespace:       "default",
+		CPUUsageHistory: usageHistory(72*12, 5*time.Minute, growth(random), "cores"),
+		MemUsageHistory: usageHistory(72*12, 5*time.Minute, steady(256*1024*1024), "bytes"),
+	}}}
+
+	cpuLimit := func(opts RecommendationOptions) float64 {
+		recommendations, err := engine.GenerateRecommendationsWithOptions(context.Background(), workloadMetrics, opts)
+		require.NoError(t, err)
+		require.Len(t, recommendations, 1)
+		limit := recommendations[0].RecommendedResources.Limits[corev1.ResourceCPU]
+		return limit.AsApproximateFloat64()
+	}
+
+	// The percentile sizes for the last day, the forecast for two days ahead
+	percentile := cpuLimit(RecommendationOptions{})
+	forecast := cpuLimit(RecommendationOptions{
+		Recommender: rightsizingv1alpha1.RecommenderSpec{Name: ForecastRecommender},
+		Horizon:     48 * time.Hour,
+	})
+	assert.InDelta(t, 1.7*1.2, forecast, 0.1)
+	assert.Greater(t, forecast, percentile+0.5)
+}
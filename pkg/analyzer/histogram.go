@@ -0,0 +1,248 @@
+// pkg/analyzer/histogram.go
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+// DecayingHistogramRecommender is the name of the VPA-style decaying histogram recommender
+const DecayingHistogramRecommender = "DecayingHistogram"
+
+// Histogram bucketing follows the Vertical Pod Autoscaler: every bucket is 5% wider than the one before
+const (
+	histogramBucketGrowth       = 1.05
+	cpuFirstBucketSize          = 0.01 // cores
+	memoryFirstBucketSize       = 1e7  // bytes
+	defaultHistogramHalfLife    = 24 * time.Hour
+	defaultPeakInterval         = time.Hour
+	defaultLowerBoundPercentile = 50
+	defaultUpperBoundPercentile = 99
+)
+
+// decayingHistogram is a histogram with exponentially growing buckets whose sample weights halve every
+// half-life, so recent behavior outweighs old behavior
+type decayingHistogram struct {
+	firstBucketSize float64
+	halfLife        time.Duration
+	// reference is the time at which a sample has weight 1; older samples weigh less
+	reference   time.Time
+	weights     []float64
+	totalWeight float64
+}
+
+func newDecayingHistogram(firstBucketSize float64, halfLife time.Duration, reference time.Time) *decayingHistogram {
+	return &decayingHistogram{firstBucketSize: firstBucketSize, halfLife: halfLife, reference: reference}
+}
+
+// bucket returns the index of the bucket holding value
+func (h *decayingHistogram) bucket(value float64) int {
+	if value < h.firstBucketSize {
+		return 0
+	}
+	return int(math.Log(value*(histogramBucketGrowth-1)/h.firstBucketSize+1) / math.Log(histogramBucketGrowth))
+}
+
+// bucketStart returns the smallest value held by a bucket
+func (h *decayingHistogram) bucketStart(bucket int) float64 {
+	return h.firstBucketSize * (math.Pow(histogramBucketGrowth, float64(bucket)) - 1) / (histogramBucketGrowth - 1)
+}
+
+// AddSample adds a sample taken at timestamp, weighted by its age relative to the reference time
+func (h *decayingHistogram) AddSample(value float64, timestamp time.Time) {
+	if value < 0 {
+		return
+	}
+	weight := math.Exp2(timestamp.Sub(h.reference).Hours() / h.halfLife.Hours())
+
+	bucket := h.bucket(value)
+	for len(h.weights) <= bucket {
+		h.weights = append(h.weights, 0)
+	}
+	h.weights[bucket] += weight
+	h.totalWeight += weight
+}
+
+// Percentile returns the end of the bucket in which the cumulative weight reaches percentile,
+// rounding up like the Vertical Pod Autoscaler does
+func (h *decayingHistogram) Percentile(percentile float64) float64 {
+	if h.totalWeight == 0 {
+		return 0
+	}
+
+	threshold := h.totalWeight * math.Min(math.Max(percentile, 0), 100) / 100
+	cumulative := 0.0
+	for bucket, weight := range h.weights {
+		cumulative += weight
+		if weight > 0 && cumulative >= threshold {
+			return h.bucketStart(bucket + 1)
+		}
+	}
+	return h.bucketStart(len(h.weights))
+}
+
+// histogramRecommender sizes for a percentile of a decaying histogram of the usage history. Memory is
+// sized from the peak of every aggregation interval, since a single short peak leads to an OOM kill.
+type histogramRecommender struct {
+	halfLife             time.Duration
+	peakInterval         time.Duration
+	lowerBoundPercentile int
+	upperBoundPercentile int
+}
+
+func newHistogramRecommender(_ *RecommendationEngine, parameters map[string]string) (Recommender, error) {
+	if err := checkParameters(parameters,
+		"halfLife", "memoryPeakInterval", "lowerBoundPercentile", "upperBoundPercentile"); err != nil {
+		return nil, err
+	}
+
+	recommender := &histogramRecommender{
+		halfLife:             defaultHistogramHalfLife,
+		peakInterval:         defaultPeakInterval,
+		lowerBoundPercentile: defaultLowerBoundPercentile,
+		upperBoundPercentile: defaultUpperBoundPercentile,
+	}
+
+	if value, ok := parameters["halfLife"]; ok {
+		halfLife, err := rightsizingv1alpha1.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("halfLife: %w", err)
+		}
+		if halfLife <= 0 {
+			return nil, fmt.Errorf("halfLife must be positive")
+		}
+		recommender.halfLife = halfLife
+	}
+	if value, ok := parameters["memoryPeakInterval"]; ok {
+		interval, err := rightsizingv1alpha1.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("memoryPeakInterval: %w", err)
+		}
+		if interval < 0 {
+			return nil, fmt.Errorf("memoryPeakInterval must not be negative")
+		}
+		recommender.peakInterval = interval
+	}
+	for key, target := range map[string]*int{
+		"lowerBoundPercentile": &recommender.lowerBoundPercentile,
+		"upperBoundPercentile": &recommender.upperBoundPercentile,
+	} {
+		value, ok := parameters[key]
+		if !ok {
+			continue
+		}
+		percentile, err := strconv.Atoi(value)
+		if err != nil || percentile < 1 || percentile > 100 {
+			return nil, fmt.Errorf("%s must be an integer between 1 and 100", key)
+		}
+		*target = percentile
+	}
+	if recommender.lowerBoundPercentile > recommender.upperBoundPercentile {
+		return nil, fmt.Errorf("lowerBoundPercentile must not exceed upperBoundPercentile")
+	}
+
+	return recommender, nil
+}
+
+// Estimate returns the utilization percentile of the decaying histogram as the target, with the
+// bound percentiles around it. The bounds widen for short histories, and confidence drops as they
+// spread apart relative to the target, so a week of steady usage scores close to 90.
+func (h *histogramRecommender) Estimate(input RecommenderInput) (UsageEstimate, error) {
+	if len(input.History) == 0 {
+		return UsageEstimate{}, fmt.Errorf("no %s data points", input.Resource)
+	}
+
+	samples := input.History
+	firstBucketSize := memoryFirstBucketSize
+	switch input.Resource {
+	case corev1.ResourceCPU:
+		firstBucketSize = cpuFirstBucketSize
+	case corev1.ResourceMemory:
+		samples = intervalPeaks(samples, h.peakInterval)
+	}
+
+	oldest, newest := input.History[0].Timestamp, input.History[0].Timestamp
+	for _, usage := range input.History {
+		if usage.Timestamp.Before(oldest) {
+			oldest = usage.Timestamp
+		}
+		if usage.Timestamp.After(newest) {
+			newest = usage.Timestamp
+		}
+	}
+	histogram := newDecayingHistogram(firstBucketSize, h.halfLife, newest)
+	for _, sample := range samples {
+		histogram.AddSample(sample.Value, sample.Timestamp)
+	}
+
+	lowerPercentile := min(h.lowerBoundPercentile, input.Percentile)
+	upperPercentile := max(h.upperBoundPercentile, input.Percentile)
+	target := histogram.Percentile(float64(input.Percentile))
+	lower := histogram.Percentile(float64(lowerPercentile))
+	upper := histogram.Percentile(float64(upperPercentile))
+
+	// Like the Vertical Pod Autoscaler, widen the bounds when the history covers only a few days
+	days := math.Max(newest.Sub(oldest).Hours()/24, 1.0/(24*60))
+	upper *= 1 + 1/days
+	lower *= math.Pow(1+0.001/days, -2)
+
+	confidence := 100
+	if target > 0 {
+		confidence = int(100 / (1 + (upper-lower)/target))
+	}
+
+	return UsageEstimate{
+		Value:      target,
+		LowerBound: lower,
+		UpperBound: upper,
+		Confidence: confidence,
+		Reason: fmt.Sprintf("Based on %dth percentile of a decaying histogram of %d samples (half-life %s), bounds %s-%s",
+			input.Percentile, len(samples), h.halfLife,
+			formatUsage(input.Resource, lower), formatUsage(input.Resource, upper)),
+	}, nil
+}
+
+// intervalPeaks returns the highest sample of every interval, keeping the time of the peak. A zero
+// interval keeps all samples.
+func intervalPeaks(history []metrics.ResourceUsage, interval time.Duration) []metrics.ResourceUsage {
+	if interval <= 0 {
+		return history
+	}
+
+	var peaks []metrics.ResourceUsage
+	index := make(map[time.Time]int)
+	for _, usage := range history {
+		start := usage.Timestamp.Truncate(interval)
+		i, exists := index[start]
+		if !exists {
+			index[start] = len(peaks)
+			peaks = append(peaks, usage)
+			continue
+		}
+		if usage.Value > peaks[i].Value {
+			peaks[i] = usage
+		}
+	}
+	return peaks
+}
+
+// formatUsage formats a usage value as a quantity of the resource
+func formatUsage(name corev1.ResourceName, value float64) string {
+	return usageQuantity(name, value).String()
+}
+
+// usageQuantity converts a usage value in cores or bytes to a quantity of the resource, rounded up
+func usageQuantity(name corev1.ResourceName, value float64) *resource.Quantity {
+	if name == corev1.ResourceCPU {
+		return resource.NewMilliQuantity(int64(math.Ceil(value*1000)), resource.DecimalSI)
+	}
+	return resource.NewQuantity(int64(math.Ceil(value)), resource.BinarySI)
+}
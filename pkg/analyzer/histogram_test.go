@@ -0,0 +1,169 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+func TestDecayingHistogram(t *testing.T) {
+	now := time.Now()
+	histogram := newDecayingHistogram(cpuFirstBucketSize, time.Hour, now)
+
+	// Empty histograms have no percentiles
+	assert.Zero(t, histogram.Percentile(95))
+
+	for i := 1; i <= 100; i++ {
+		histogram.AddSample(float64(i)/100, now)
+	}
+
+	// Percentiles round up to the end of the bucket, within 5% plus the first bucket size of the value
+	for _, tc := range []struct{ percentile, value float64 }{{50, 0.5}, {90, 0.9}, {100, 1}} {
+		got := histogram.Percentile(tc.percentile)
+		assert.GreaterOrEqual(t, got, tc.value, "p%v", tc.percentile)
+		assert.LessOrEqual(t, got, tc.value*histogramBucketGrowth+cpuFirstBucketSize, "p%v", tc.percentile)
+	}
+
+	// A sample two half-lives old weighs a quarter of a current one
+	histogram = newDecayingHistogram(cpuFirstBucketSize, time.Hour, now)
+	histogram.AddSample(0.1, now)
+	histogram.AddSample(2, now.Add(-2*time.Hour))
+	assert.InDelta(t, 1.25, histogram.totalWeight, 1e-9)
+	assert.Less(t, histogram.Percentile(80), 0.2)
+	assert.Greater(t, histogram.Percentile(81), 1.9)
+}
+
+func TestHistogramRecommender(t *testing.T) {
+	engine := NewRecommendationEngine()
+	now := time.Now()
+
+	// Six days at 1 core followed by a day at 0.2 cores, sampled hourly
+	var history []metrics.ResourceUsage
+	for hour := 7 * 24; hour > 0; hour-- {
+		value := 1.0
+		if hour <= 24 {
+			value = 0.2
+		}
+		history = append(history, metrics.ResourceUsage{
+			Timestamp: now.Add(time.Duration(-hour) * time.Hour),
+			Value:     value,
+			Unit:      "cores",
+		})
+	}
+	input := RecommenderInput{Resource: corev1.ResourceCPU, History: history, Percentile: 95}
+
+	// The plain percentile still sizes for last week's usage
+	percentile, err := engine.NewRecommender(rightsizingv1alpha1.RecommenderSpec{})
+	require.NoError(t, err)
+	estimate, err := percentile.Estimate(input)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, estimate.Value)
+
+	// With a 4h half-life the old usage carries less than 5% of the weight
+	recommender, err := engine.NewRecommender(rightsizingv1alpha1.RecommenderSpec{
+		Name:       DecayingHistogramRecommender,
+		Parameters: map[string]string{"halfLife": "4h"},
+	})
+	require.NoError(t, err)
+	estimate, err = recommender.Estimate(input)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, estimate.Value, 0.2)
+	assert.Less(t, estimate.Value, 0.25)
+	assert.LessOrEqual(t, estimate.LowerBound, estimate.Value)
+	assert.GreaterOrEqual(t, estimate.UpperBound, estimate.Value)
+	assert.Contains(t, estimate.Reason, "decaying histogram of 168 samples (half-life 4h0m0s)")
+
+	// A week of steady usage keeps the bounds close to the target
+	for i := range history {
+		history[i].Value = 0.5
+	}
+	estimate, err = recommender.Estimate(input)
+	require.NoError(t, err)
+	assert.InDelta(t, 87, estimate.Confidence, 2)
+
+	// One day of history widens the upper bound to twice the target
+	estimate, err = recommender.Estimate(RecommenderInput{
+		Resource: corev1.ResourceCPU, History: history[len(history)-25:], Percentile: 95})
+	require.NoError(t, err)
+	assert.InDelta(t, 2*estimate.Value, estimate.UpperBound, 1e-9)
+	assert.InDelta(t, 50, estimate.Confidence, 1)
+}
+
+func TestHistogramRecommender_MemoryPeaks(t *testing.T) {
+	engine := NewRecommendationEngine()
+	now := time.Now().Truncate(time.Hour)
+
+	// Memory sits at 100Mi with a one-minute spike to 400Mi every hour
+	var history []metrics.ResourceUsage
+	for minute := 0; minute < 24*60; minute++ {
+		value := 100.0 * 1024 * 1024
+		if minute%60 == 30 {
+			value = 400.0 * 1024 * 1024
+		}
+		history = append(history, metrics.ResourceUsage{
+			Timestamp: now.Add(time.Duration(minute-24*60) * time.Minute),
+			Value:     value,
+			Unit:      "bytes",
+		})
+	}
+
+	assert.Len(t, intervalPeaks(history, time.Hour), 24)
+	assert.Len(t, intervalPeaks(history, 0), len(history))
+
+	recommender, err := engine.NewRecommender(rightsizingv1alpha1.RecommenderSpec{Name: DecayingHistogramRecommender})
+	require.NoError(t, err)
+	estimate, err := recommender.Estimate(RecommenderInput{Resource: corev1.ResourceMemory, History: history, Percentile: 95})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, estimate.Value, 400.0*1024*1024)
+
+	// Without aggregation the spikes are below the 95th percentile
+	recommender, err = engine.NewRecommender(rightsizingv1alpha1.RecommenderSpec{
+		Name:       DecayingHistogramRecommender,
+		Parameters: map[string]string{"memoryPeakInterval": "0"},
+	})
+	require.NoError(t, err)
+	estimate, err = recommender.Estimate(RecommenderInput{Resource: corev1.ResourceMemory, History: history, Percentile: 95})
+	require.NoError(t, err)
+	assert.Less(t, estimate.Value, 200.0*1024*1024)
+}
+
+func TestHistogramRecommender_Parameters(t *testing.T) {
+	engine := NewRecommendationEngine()
+
+	tests := []struct {
+		name       string
+		parameters map[string]string
+		wantError  string
+	}{
+		{name: "defaults"},
+		{name: "all parameters", parameters: map[string]string{
+			"halfLife": "2d", "memoryPeakInterval": "24h", "lowerBoundPercentile": "40", "upperBoundPercentile": "98",
+		}},
+		{name: "invalid half-life", parameters: map[string]string{"halfLife": "0"}, wantError: "halfLife must be positive"},
+		{name: "invalid percentile", parameters: map[string]string{"upperBoundPercentile": "101"},
+			wantError: "upperBoundPercentile must be an integer between 1 and 100"},
+		{name: "inverted bounds", parameters: map[string]string{"lowerBoundPercentile": "99", "upperBoundPercentile": "90"},
+			wantError: "lowerBoundPercentile must not exceed upperBoundPercentile"},
+		{name: "unknown parameter", parameters: map[string]string{"decay": "fast"}, wantError: "unknown parameters: decay"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := engine.NewRecommender(rightsizingv1alpha1.RecommenderSpec{
+				Name:       DecayingHistogramRecommender,
+				Parameters: tt.parameters,
+			})
+			if tt.wantError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantError)
+			}
+		})
+	}
+}
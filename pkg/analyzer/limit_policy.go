@@ -0,0 +1,114 @@
+// pkg/analyzer/limit_policy.go
+package analyzer
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+)
+
+// ApplyLimitPolicy combines recommended resources with a container's current resources according to
+// the limit policy. It is used both for the recommendations reported in status and when updating
+// workloads, so that both show the same limits. Applying it twice gives the same result. Limits
+// below floors are raised to them whatever the mode, so that e.g. an increase after OOM kills holds.
+func ApplyLimitPolicy(
+	policy rightsizingv1alpha1.LimitPolicy,
+	current, recommended corev1.ResourceRequirements,
+	floors corev1.ResourceList,
+) corev1.ResourceRequirements {
+	result := corev1.ResourceRequirements{
+		Requests: recommended.Requests.DeepCopy(),
+		Limits:   recommended.Limits.DeepCopy(),
+	}
+	if result.Requests == nil {
+		result.Requests = corev1.ResourceList{}
+	}
+	if result.Limits == nil {
+		result.Limits = corev1.ResourceList{}
+	}
+
+	switch policy.Mode {
+	case rightsizingv1alpha1.LimitModeRequestsOnly:
+		keepCurrentResources(result, current)
+		result.Limits = current.Limits.DeepCopy()
+		if result.Limits == nil {
+			result.Limits = corev1.ResourceList{}
+		}
+		raiseToFloors(result.Limits, floors)
+
+		// The API server rejects requests above the limit, so cap them at the limits left in place
+		for name, limit := range result.Limits {
+			if request, ok := result.Requests[name]; ok && request.Cmp(limit) > 0 {
+				result.Requests[name] = limit.DeepCopy()
+			}
+		}
+
+	case rightsizingv1alpha1.LimitModePreserveRatio:
+		keepCurrentResources(result, current)
+		result.Limits = corev1.ResourceList{}
+		for name, currentLimit := range current.Limits {
+			request, ok := recommended.Requests[name]
+			if !ok {
+				result.Limits[name] = currentLimit.DeepCopy()
+				continue
+			}
+
+			// A missing request defaults to the limit, which is a ratio of one
+			ratio := 1.0
+			if currentRequest, ok := current.Requests[name]; ok && !currentRequest.IsZero() {
+				ratio = currentLimit.AsApproximateFloat64() / currentRequest.AsApproximateFloat64()
+			}
+			result.Limits[name] = scaleQuantity(name, request, ratio)
+		}
+	}
+
+	if policy.MemoryLimitEqualsRequest {
+		if request, ok := result.Requests[corev1.ResourceMemory]; ok {
+			result.Limits[corev1.ResourceMemory] = request.DeepCopy()
+		}
+	}
+	if policy.RemoveCPULimits {
+		delete(result.Limits, corev1.ResourceCPU)
+	}
+
+	if raiseToFloors(result.Limits, floors) && policy.MemoryLimitEqualsRequest {
+		if limit, ok := result.Limits[corev1.ResourceMemory]; ok {
+			result.Requests[corev1.ResourceMemory] = limit.DeepCopy()
+		}
+	}
+
+	return result
+}
+
+// raiseToFloors raises limits below their floor to it and returns whether any was raised. Only
+// limits that are set are raised, since a missing limit is no limit at all.
+func raiseToFloors(limits, floors corev1.ResourceList) bool {
+	raised := false
+	for name, floor := range floors {
+		if limit, ok := limits[name]; ok && limit.Cmp(floor) < 0 {
+			limits[name] = floor.DeepCopy()
+			raised = true
+		}
+	}
+	return raised
+}
+
+// keepCurrentResources copies the current requests of resources without a recommendation, so that
+// limits left in place are not paired with a defaulted request
+func keepCurrentResources(result, current corev1.ResourceRequirements) {
+	for name, request := range current.Requests {
+		if _, ok := result.Requests[name]; !ok {
+			result.Requests[name] = request.DeepCopy()
+		}
+	}
+}
+
+// scaleQuantity multiplies a quantity by factor, keeping millicores for CPU and whole units otherwise
+func scaleQuantity(name corev1.ResourceName, quantity resource.Quantity, factor float64) resource.Quantity {
+	value := quantity.AsApproximateFloat64() * factor
+	if name == corev1.ResourceCPU {
+		return *resource.NewMilliQuantity(int64(value*1000), resource.DecimalSI)
+	}
+	return *resource.NewQuantity(int64(value), quantity.Format)
+}
@@ -0,0 +1,143 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+)
+
+func TestApplyLimitPolicy(t *testing.T) {
+	current := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:              resource.MustParse("500m"),
+			corev1.ResourceMemory:           resource.MustParse("256Mi"),
+			corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:              resource.MustParse("1"),
+			corev1.ResourceMemory:           resource.MustParse("256Mi"),
+			corev1.ResourceEphemeralStorage: resource.MustParse("2Gi"),
+		},
+	}
+	recommended := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("200m"),
+			corev1.ResourceMemory: resource.MustParse("512Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("250m"),
+			corev1.ResourceMemory: resource.MustParse("600Mi"),
+		},
+	}
+
+	tests := []struct {
+		name         string
+		policy       rightsizingv1alpha1.LimitPolicy
+		floors       corev1.ResourceList
+		wantRequests corev1.ResourceList
+		wantLimits   corev1.ResourceList
+	}{
+		{
+			name:         "default",
+			policy:       rightsizingv1alpha1.LimitPolicy{},
+			wantRequests: recommended.Requests,
+			wantLimits:   recommended.Limits,
+		},
+		{
+			name:   "requests only caps requests at existing limits",
+			policy: rightsizingv1alpha1.LimitPolicy{Mode: rightsizingv1alpha1.LimitModeRequestsOnly},
+			wantRequests: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("200m"),
+				corev1.ResourceMemory:           resource.MustParse("256Mi"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+			},
+			wantLimits: current.Limits,
+		},
+		{
+			name:   "preserve ratio",
+			policy: rightsizingv1alpha1.LimitPolicy{Mode: rightsizingv1alpha1.LimitModePreserveRatio},
+			wantRequests: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("200m"),
+				corev1.ResourceMemory:           resource.MustParse("512Mi"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+			},
+			wantLimits: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("400m"),
+				corev1.ResourceMemory:           resource.MustParse("512Mi"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("2Gi"),
+			},
+		},
+		{
+			name: "no CPU limits and memory limit equal to request",
+			policy: rightsizingv1alpha1.LimitPolicy{
+				RemoveCPULimits:          true,
+				MemoryLimitEqualsRequest: true,
+			},
+			wantRequests: recommended.Requests,
+			wantLimits: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("512Mi"),
+			},
+		},
+		{
+			name:   "requests only keeps the memory limit raised after OOM kills",
+			policy: rightsizingv1alpha1.LimitPolicy{Mode: rightsizingv1alpha1.LimitModeRequestsOnly},
+			floors: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("640Mi")},
+			wantRequests: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("200m"),
+				corev1.ResourceMemory:           resource.MustParse("512Mi"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+			},
+			wantLimits: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("1"),
+				corev1.ResourceMemory:           resource.MustParse("640Mi"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("2Gi"),
+			},
+		},
+		{
+			name: "floors hold when the memory limit equals the request",
+			policy: rightsizingv1alpha1.LimitPolicy{
+				Mode:                     rightsizingv1alpha1.LimitModePreserveRatio,
+				MemoryLimitEqualsRequest: true,
+			},
+			floors: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("640Mi")},
+			wantRequests: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("200m"),
+				corev1.ResourceMemory:           resource.MustParse("640Mi"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("1Gi"),
+			},
+			wantLimits: corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("400m"),
+				corev1.ResourceMemory:           resource.MustParse("640Mi"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("2Gi"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ApplyLimitPolicy(tt.policy, current, recommended, tt.floors)
+			assertResourceList(t, tt.wantRequests, result.Requests)
+			assertResourceList(t, tt.wantLimits, result.Limits)
+
+			// Applying the policy again against the updated container changes nothing
+			again := ApplyLimitPolicy(tt.policy, result, result, tt.floors)
+			assertResourceList(t, result.Requests, again.Requests)
+			assertResourceList(t, result.Limits, again.Limits)
+		})
+	}
+}
+
+func assertResourceList(t *testing.T, want, got corev1.ResourceList) {
+	t.Helper()
+	assert.Len(t, got, len(want))
+	for name, quantity := range want {
+		actual, ok := got[name]
+		if assert.True(t, ok, "missing %s", name) {
+			assert.Zero(t, quantity.Cmp(actual), "%s: want %s, got %s", name, quantity.String(), actual.String())
+		}
+	}
+}
@@ -0,0 +1,275 @@
+// pkg/analyzer/outliers.go
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/stats"
+)
+
+// Outlier filtering constants
+const (
+	// defaultMADThreshold is the number of scaled median absolute deviations beyond which samples are outliers
+	defaultMADThreshold = 3.5
+	// defaultIQRThreshold is the number of interquartile ranges outside the quartiles beyond which samples
+	// are outliers, Tukey's fences
+	defaultIQRThreshold = 1.5
+	// madScale makes the median absolute deviation comparable to the standard deviation of normal data
+	madScale = 1.4826
+	// meanDeviationScale does the same for the mean absolute deviation, which stands in for the median
+	// absolute deviation when more than half of the values equal the median
+	meanDeviationScale = 1.2533
+	// minReplicasForRejection is the number of replicas needed to tell an outlying replica from the rest
+	minReplicasForRejection = 3
+)
+
+// outlierThreshold returns the threshold of the outlier filter, or the default of its method when it is
+// not set. It returns zero when the filter is disabled.
+func outlierThreshold(filter rightsizingv1alpha1.OutlierFilter) (float64, error) {
+	var threshold float64
+	switch filter.Method {
+	case "", rightsizingv1alpha1.OutlierMethodNone:
+		return 0, nil
+	case rightsizingv1alpha1.OutlierMethodMAD:
+		threshold = defaultMADThreshold
+	case rightsizingv1alpha1.OutlierMethodIQR:
+		threshold = defaultIQRThreshold
+	default:
+		return 0, fmt.Errorf("unknown outlier method %q", filter.Method)
+	}
+
+	if filter.Threshold != "" {
+		parsed, err := strconv.ParseFloat(filter.Threshold, 64)
+		if err != nil || parsed <= 0 {
+			return 0, fmt.Errorf("invalid outlierFilter threshold %q: must be a positive number", filter.Threshold)
+		}
+		threshold = parsed
+	}
+	return threshold, nil
+}
+
+// outlierBounds returns the range outside of which the n values returned by value are outliers under
+// method. Quantiles come from sketches, so long histories are neither copied nor sorted. It returns false
+// when the values do not spread enough to tell outliers apart, e.g. when most of them are equal.
+func outlierBounds(
+	n int,
+	value func(i int) float64,
+	method rightsizingv1alpha1.OutlierMethod,
+	threshold float64,
+) (float64, float64, bool) {
+	if n == 0 {
+		return 0, 0, false
+	}
+
+	sketch := stats.NewSketch()
+	for i := 0; i < n; i++ {
+		sketch.Add(value(i))
+	}
+	if method == rightsizingv1alpha1.OutlierMethodIQR {
+		q1, q3 := sketch.Quantile(0.25), sketch.Quantile(0.75)
+		iqr := q3 - q1
+		return q1 - threshold*iqr, q3 + threshold*iqr, iqr > 0
+	}
+
+	median := sketch.Quantile(0.5)
+	deviations := stats.NewSketch()
+	var meanDeviation float64
+	for i := 0; i < n; i++ {
+		deviation := math.Abs(value(i) - median)
+		deviations.Add(deviation)
+		meanDeviation += deviation
+	}
+	meanDeviation /= float64(n)
+
+	scale := madScale * deviations.Quantile(0.5)
+	if scale == 0 {
+		scale = meanDeviationScale * meanDeviation
+	}
+	return median - threshold*scale, median + threshold*scale, scale > 0
+}
+
+// filterOutliers removes the samples of a history outside the outlier bounds of its values and returns
+// the remaining samples and how many were removed
+func (r *RecommendationEngine) filterOutliers(
+	history []metrics.ResourceUsage,
+	method rightsizingv1alpha1.OutlierMethod,
+	threshold float64,
+) ([]metrics.ResourceUsage, int) {
+	low, high, ok := outlierBounds(len(history), func(i int) float64 { return history[i].Value }, method, threshold)
+	if !ok {
+		return history, 0
+	}
+
+	kept := make([]metrics.ResourceUsage, 0, len(history))
+	for _, usage := range history {
+		if usage.Value >= low && usage.Value <= high {
+			kept = append(kept, usage)
+		}
+	}
+	return kept, len(history) - len(kept)
+}
+
+// filterPodOutliers removes outlying samples from each usage history of a pod, including its init and
+// sidecar containers. It returns the filtered metrics and how many samples were removed.
+func (r *RecommendationEngine) filterPodOutliers(
+	podMetrics metrics.PodMetrics,
+	filter rightsizingv1alpha1.OutlierFilter,
+) (metrics.PodMetrics, int) {
+	threshold, err := outlierThreshold(filter)
+	if err != nil || threshold == 0 {
+		return podMetrics, 0
+	}
+
+	filterHistory := func(history []metrics.ResourceUsage) []metrics.ResourceUsage {
+		kept, _ := r.filterOutliers(history, filter.Method, threshold)
+		return kept
+	}
+	total := countUsageSamples(podMetrics)
+	podMetrics = mapUsageHistories(podMetrics, filterHistory)
+	return podMetrics, total - countUsageSamples(podMetrics)
+}
+
+// excludeTimeRanges drops the samples within the analysis exclusions from every usage and throttling
+// history of a pod. It returns the remaining metrics and how many usage samples were dropped.
+func excludeTimeRanges(
+	podMetrics metrics.PodMetrics,
+	exclusions []rightsizingv1alpha1.AnalysisExclusion,
+) (metrics.PodMetrics, int) {
+	if len(exclusions) == 0 {
+		return podMetrics, 0
+	}
+
+	excluded := func(timestamp time.Time) bool {
+		for _, exclusion := range exclusions {
+			if !timestamp.Before(exclusion.Start.Time) && timestamp.Before(exclusion.End.Time) {
+				return true
+			}
+		}
+		return false
+	}
+	keep := func(history []metrics.ResourceUsage) []metrics.ResourceUsage {
+		kept := make([]metrics.ResourceUsage, 0, len(history))
+		for _, usage := range history {
+			if !excluded(usage.Timestamp) {
+				kept = append(kept, usage)
+			}
+		}
+		return kept
+	}
+
+	total := countUsageSamples(podMetrics)
+	podMetrics = mapUsageHistories(podMetrics, keep)
+	podMetrics.CPUThrottlingHistory = keep(podMetrics.CPUThrottlingHistory)
+	// mapUsageHistories copied the container map, so it can be updated in place
+	for name, container := range podMetrics.Containers {
+		container.CPUThrottlingHistory = keep(container.CPUThrottlingHistory)
+		podMetrics.Containers[name] = container
+	}
+	return podMetrics, total - countUsageSamples(podMetrics)
+}
+
+// rejectOutlierReplicas returns the names of the pods whose median CPU or memory usage is an outlier
+// among the replicas, by the MAD rule with the filter's threshold when it uses MAD and the default
+// threshold otherwise. Workloads with fewer than minReplicasForRejection replicas keep all of them.
+func (r *RecommendationEngine) rejectOutlierReplicas(
+	pods []metrics.PodMetrics,
+	filter rightsizingv1alpha1.OutlierFilter,
+) []string {
+	if !filter.RejectReplicas || len(pods) < minReplicasForRejection {
+		return nil
+	}
+
+	threshold := defaultMADThreshold
+	if filter.Method == rightsizingv1alpha1.OutlierMethodMAD {
+		if configured, err := outlierThreshold(filter); err == nil {
+			threshold = configured
+		}
+	}
+
+	outlying := make(map[string]bool)
+	for _, history := range []func(metrics.PodMetrics) []metrics.ResourceUsage{
+		func(pod metrics.PodMetrics) []metrics.ResourceUsage { return pod.CPUUsageHistory },
+		func(pod metrics.PodMetrics) []metrics.ResourceUsage { return pod.MemUsageHistory },
+	} {
+		medians := make(map[string]float64, len(pods))
+		var values []float64
+		for _, pod := range pods {
+			if samples := history(pod); len(samples) > 0 {
+				sketch, _ := usageStats(samples)
+				medians[pod.PodName] = sketch.Quantile(0.5)
+				values = append(values, medians[pod.PodName])
+			}
+		}
+		if len(values) < minReplicasForRejection {
+			continue
+		}
+
+		low, high, ok := outlierBounds(len(values), func(i int) float64 { return values[i] },
+			rightsizingv1alpha1.OutlierMethodMAD, threshold)
+		if !ok {
+			continue
+		}
+		for name, median := range medians {
+			if median < low || median > high {
+				outlying[name] = true
+			}
+		}
+	}
+
+	var rejected []string
+	for _, pod := range pods {
+		if outlying[pod.PodName] {
+			rejected = append(rejected, pod.PodName)
+		}
+	}
+	return rejected
+}
+
+// describeRejectedReplicas notes the rejected replicas in the reason of the remaining recommendations
+func describeRejectedReplicas(rejected []string) string {
+	return fmt.Sprintf(" Rejected %d outlier replicas: %s.", len(rejected), strings.Join(rejected, ", "))
+}
+
+// mapUsageHistories replaces the CPU, memory and ephemeral-storage histories of a pod and of each of its
+// containers with the result of fn, leaving the original metrics untouched
+func mapUsageHistories(
+	podMetrics metrics.PodMetrics,
+	fn func([]metrics.ResourceUsage) []metrics.ResourceUsage,
+) metrics.PodMetrics {
+	podMetrics.CPUUsageHistory = fn(podMetrics.CPUUsageHistory)
+	podMetrics.MemUsageHistory = fn(podMetrics.MemUsageHistory)
+	podMetrics.StorageUsageHistory = fn(podMetrics.StorageUsageHistory)
+
+	mapContainers := func(containers map[string]metrics.ContainerMetrics) map[string]metrics.ContainerMetrics {
+		if containers == nil {
+			return nil
+		}
+		mapped := make(map[string]metrics.ContainerMetrics, len(containers))
+		for name, container := range containers {
+			container.CPUUsageHistory = fn(container.CPUUsageHistory)
+			container.MemUsageHistory = fn(container.MemUsageHistory)
+			container.StorageUsageHistory = fn(container.StorageUsageHistory)
+			mapped[name] = container
+		}
+		return mapped
+	}
+	podMetrics.Containers = mapContainers(podMetrics.Containers)
+	podMetrics.InitContainers = mapContainers(podMetrics.InitContainers)
+	return podMetrics
+}
+
+// countUsageSamples counts the usage samples of a pod and its init and sidecar containers. The samples
+// of the regular containers are already counted in the pod's histories.
+func countUsageSamples(podMetrics metrics.PodMetrics) int {
+	count := len(podMetrics.CPUUsageHistory) + len(podMetrics.MemUsageHistory) + len(podMetrics.StorageUsageHistory)
+	for _, container := range podMetrics.InitContainers {
+		count += len(container.CPUUsageHistory) + len(container.MemUsageHistory) + len(container.StorageUsageHistory)
+	}
+	return count
+}
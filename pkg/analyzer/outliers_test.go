@@ -0,0 +1,195 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+// incidentStart is when the usage of incident jumps to the incident level for ten minutes
+var incidentStart = usageStart.Add(30 * time.Minute)
+
+// incident stays around level with 2% noise, and at spike during the ten minutes from incidentStart, for
+// samples every minute
+func incident(random *rand.Rand, level, spike float64) func(i int) float64 {
+	return func(i int) float64 {
+		value := level * (1 + 0.02*random.NormFloat64())
+		if i >= 30 && i < 40 {
+			value = spike
+		}
+		return value
+	}
+}
+
+func TestFilterOutliers(t *testing.T) {
+	engine := NewRecommendationEngine()
+	random := rand.New(rand.NewSource(1))
+	history := usageHistory(120, time.Minute, incident(random, 0.5, 4), "cores")
+
+	for _, method := range []rightsizingv1alpha1.OutlierMethod{
+		rightsizingv1alpha1.OutlierMethodMAD, rightsizingv1alpha1.OutlierMethodIQR,
+	} {
+		threshold, err := outlierThreshold(rightsizingv1alpha1.OutlierFilter{Method: method})
+		require.NoError(t, err)
+
+		kept, removed := engine.filterOutliers(history, method, threshold)
+		assert.Equal(t, 10, removed, method)
+		assert.Len(t, kept, 110, method)
+		for _, usage := range kept {
+			assert.Less(t, usage.Value, 1.0, method)
+		}
+	}
+
+	// A week of samples is too long for exact quantiles, the binned sketch still finds the incident
+	week := usageHistory(7*24*60, time.Minute, func(i int) float64 {
+		if i >= 24*60 && i < 24*60+10 {
+			return 4
+		}
+		return 0.5 * (1 + 0.02*random.NormFloat64())
+	}, "cores")
+	for _, method := range []rightsizingv1alpha1.OutlierMethod{
+		rightsizingv1alpha1.OutlierMethodMAD, rightsizingv1alpha1.OutlierMethodIQR,
+	} {
+		threshold, err := outlierThreshold(rightsizingv1alpha1.OutlierFilter{Method: method})
+		require.NoError(t, err)
+
+		// Normal noise beyond the bounds is rare but, over a week, not absent
+		kept, removed := engine.filterOutliers(week, method, threshold)
+		assert.GreaterOrEqual(t, removed, 10, method)
+		assert.LessOrEqual(t, removed, 20, method)
+		for _, usage := range kept {
+			assert.Less(t, usage.Value, 1.0, method)
+		}
+	}
+
+	// Mostly constant usage has no median absolute deviation, the mean deviation stands in for it
+	flat := usageHistory(100, time.Minute, func(i int) float64 {
+		if i == 42 {
+			return 20
+		}
+		return 1
+	}, "cores")
+	kept, removed := engine.filterOutliers(flat, rightsizingv1alpha1.OutlierMethodMAD, defaultMADThreshold)
+	assert.Equal(t, 1, removed)
+	assert.Len(t, kept, 99)
+
+	// Constant usage has nothing to remove
+	_, removed = engine.filterOutliers(flat[:42], rightsizingv1alpha1.OutlierMethodIQR, defaultIQRThreshold)
+	assert.Zero(t, removed)
+}
+
+func TestOutlierThreshold(t *testing.T) {
+	threshold, err := outlierThreshold(rightsizingv1alpha1.OutlierFilter{})
+	require.NoError(t, err)
+	assert.Zero(t, threshold)
+
+	threshold, err = outlierThreshold(rightsizingv1alpha1.OutlierFilter{Method: rightsizingv1alpha1.OutlierMethodIQR})
+	require.NoError(t, err)
+	assert.Equal(t, defaultIQRThreshold, threshold)
+
+	threshold, err = outlierThreshold(rightsizingv1alpha1.OutlierFilter{
+		Method: rightsizingv1alpha1.OutlierMethodMAD, Threshold: "5",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5.0, threshold)
+
+	_, err = outlierThreshold(rightsizingv1alpha1.OutlierFilter{Method: rightsizingv1alpha1.OutlierMethodMAD, Threshold: "-1"})
+	assert.Error(t, err)
+	_, err = outlierThreshold(rightsizingv1alpha1.OutlierFilter{Method: "ZScore"})
+	assert.Error(t, err)
+}
+
+func TestGenerateRecommendations_OutlierFilter(t *testing.T) {
+	engine := NewRecommendationEngine()
+	ctx := context.Background()
+	random := rand.New(rand.NewSource(1))
+
+	workloadMetrics := &metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{{
+		PodName:         "test-pod-1",
+		Namespace:       "default",
+		CPUUsageHistory: usageHistory(120, time.Minute, incident(random, 0.5, 4), "cores"),
+		MemUsageHistory: usageHistory(120, time.Minute, incident(random, 512*1024*1024, 2*1024*1024*1024), "bytes"),
+	}}}
+	thresholds := rightsizingv1alpha1.ResourceThresholds{CPUUtilizationPercentile: 95}
+
+	// Unfiltered, the incident makes usage too erratic for a confident recommendation
+	recommendations, err := engine.GenerateRecommendations(ctx, workloadMetrics, thresholds)
+	require.NoError(t, err)
+	assert.Empty(t, recommendations)
+
+	recommendations, err = engine.GenerateRecommendationsWithOptions(ctx, workloadMetrics, RecommendationOptions{
+		Thresholds:    thresholds,
+		OutlierFilter: rightsizingv1alpha1.OutlierFilter{Method: rightsizingv1alpha1.OutlierMethodMAD},
+	})
+	require.NoError(t, err)
+	require.Len(t, recommendations, 1)
+	cpuLimit := recommendations[0].RecommendedResources.Limits[corev1.ResourceCPU]
+	assert.InDelta(t, 630, cpuLimit.MilliValue(), 30)
+	assert.Contains(t, recommendations[0].Reason, "Removed 20 MAD outlier samples.")
+
+	// Excluding the incident has the same effect
+	recommendations, err = engine.GenerateRecommendationsWithOptions(ctx, workloadMetrics, RecommendationOptions{
+		Thresholds: thresholds,
+		Exclusions: []rightsizingv1alpha1.AnalysisExclusion{{
+			Start:  metav1.NewTime(incidentStart),
+			End:    metav1.NewTime(incidentStart.Add(10 * time.Minute)),
+			Reason: "load test",
+		}},
+	})
+	require.NoError(t, err)
+	require.Len(t, recommendations, 1)
+	cpuLimit = recommendations[0].RecommendedResources.Limits[corev1.ResourceCPU]
+	assert.InDelta(t, 630, cpuLimit.MilliValue(), 30)
+	assert.Contains(t, recommendations[0].Reason, "Excluded 20 samples within 1 analysis exclusions.")
+	assert.NotContains(t, recommendations[0].Reason, "outlier")
+
+	_, err = engine.GenerateRecommendationsWithOptions(ctx, workloadMetrics, RecommendationOptions{
+		Thresholds:    thresholds,
+		OutlierFilter: rightsizingv1alpha1.OutlierFilter{Method: rightsizingv1alpha1.OutlierMethodIQR, Threshold: "x"},
+	})
+	assert.Error(t, err)
+}
+
+func TestGenerateRecommendations_RejectReplicas(t *testing.T) {
+	engine := NewRecommendationEngine()
+	ctx := context.Background()
+	random := rand.New(rand.NewSource(1))
+
+	workloadMetrics := &metrics.WorkloadMetrics{}
+	for i, level := range []float64{0.5, 0.52, 0.48, 3} {
+		workloadMetrics.Pods = append(workloadMetrics.Pods, metrics.PodMetrics{
+			PodName:         fmt.Sprintf("webapp-%d", i),
+			Namespace:       "default",
+			CPUUsageHistory: usageHistory(120, time.Minute, incident(random, level, level), "cores"),
+			MemUsageHistory: usageHistory(120, time.Minute, incident(random, 512*1024*1024, 512*1024*1024), "bytes"),
+		})
+	}
+	opts := RecommendationOptions{OutlierFilter: rightsizingv1alpha1.OutlierFilter{RejectReplicas: true}}
+
+	assert.Equal(t, []string{"webapp-3"}, engine.rejectOutlierReplicas(workloadMetrics.Pods, opts.OutlierFilter))
+	assert.Empty(t, engine.rejectOutlierReplicas(workloadMetrics.Pods[2:], opts.OutlierFilter),
+		"two replicas cannot outvote each other")
+
+	recommendations, err := engine.GenerateRecommendationsWithOptions(ctx, workloadMetrics, opts)
+	require.NoError(t, err)
+	require.Len(t, recommendations, 3)
+	for _, recommendation := range recommendations {
+		assert.NotEqual(t, "webapp-3", recommendation.PodReference.Name)
+		assert.Contains(t, recommendation.Reason, "Rejected 1 outlier replicas: webapp-3.")
+	}
+
+	// Without the option every replica gets a recommendation
+	recommendations, err = engine.GenerateRecommendationsWithOptions(ctx, workloadMetrics, RecommendationOptions{})
+	require.NoError(t, err)
+	assert.Len(t, recommendations, 4)
+}
@@ -0,0 +1,93 @@
+// pkg/analyzer/qos.go
+package analyzer
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// qosResources are the resources that determine a pod's QoS class
+var qosResources = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory}
+
+// ApplyQoSClass adjusts a container's resources so that its pod lands in the given QoS class.
+// Guaranteed raises CPU and memory requests to their limits, or sets missing limits to the requests.
+// Burstable removes the CPU limit from a container whose requests equal its limits. One such container
+// makes its pod Burstable, so callers apply it to a single container of the pod.
+// BestEffort drops CPU and memory entirely. Applying it twice gives the same result.
+func ApplyQoSClass(class corev1.PodQOSClass, resources corev1.ResourceRequirements) corev1.ResourceRequirements {
+	result := *resources.DeepCopy()
+	if result.Requests == nil {
+		result.Requests = corev1.ResourceList{}
+	}
+	if result.Limits == nil {
+		result.Limits = corev1.ResourceList{}
+	}
+
+	switch class {
+	case corev1.PodQOSGuaranteed:
+		for _, name := range qosResources {
+			if limit, ok := result.Limits[name]; ok {
+				result.Requests[name] = limit.DeepCopy()
+			} else if request, ok := result.Requests[name]; ok {
+				result.Limits[name] = request.DeepCopy()
+			}
+		}
+
+	case corev1.PodQOSBurstable:
+		if isGuaranteedContainer(result) {
+			delete(result.Limits, corev1.ResourceCPU)
+		}
+
+	case corev1.PodQOSBestEffort:
+		for _, name := range qosResources {
+			delete(result.Requests, name)
+			delete(result.Limits, name)
+		}
+	}
+
+	return result
+}
+
+// isGuaranteedContainer reports whether a container has CPU and memory limits equal to its requests.
+// A missing request defaults to the limit.
+func isGuaranteedContainer(resources corev1.ResourceRequirements) bool {
+	for _, name := range qosResources {
+		limit, ok := resources.Limits[name]
+		if !ok || limit.IsZero() {
+			return false
+		}
+		if request, ok := resources.Requests[name]; ok && request.Cmp(limit) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// PodQOSClass computes the QoS class of a pod spec the way the API server does, considering the
+// CPU and memory of regular and init containers
+func PodQOSClass(spec *corev1.PodSpec) corev1.PodQOSClass {
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+
+	guaranteed := true
+	bestEffort := true
+	for _, container := range containers {
+		for _, name := range qosResources {
+			request, hasRequest := container.Resources.Requests[name]
+			limit, hasLimit := container.Resources.Limits[name]
+			if (hasRequest && !request.IsZero()) || (hasLimit && !limit.IsZero()) {
+				bestEffort = false
+			}
+		}
+		if !isGuaranteedContainer(container.Resources) {
+			guaranteed = false
+		}
+	}
+
+	switch {
+	case bestEffort:
+		return corev1.PodQOSBestEffort
+	case guaranteed:
+		return corev1.PodQOSGuaranteed
+	default:
+		return corev1.PodQOSBurstable
+	}
+}
@@ -0,0 +1,108 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestApplyQoSClass(t *testing.T) {
+	// Default recommendation shape: requests at 80%/90% of limits
+	recommended := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("400m"),
+			corev1.ResourceMemory: resource.MustParse("450Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("500Mi"),
+		},
+	}
+
+	guaranteed := ApplyQoSClass(corev1.PodQOSGuaranteed, recommended)
+	assertResourceList(t, recommended.Limits, guaranteed.Requests)
+	assertResourceList(t, recommended.Limits, guaranteed.Limits)
+	assert.True(t, isGuaranteedContainer(guaranteed))
+
+	// Already Burstable, nothing to change
+	burstable := ApplyQoSClass(corev1.PodQOSBurstable, recommended)
+	assertResourceList(t, recommended.Requests, burstable.Requests)
+	assertResourceList(t, recommended.Limits, burstable.Limits)
+
+	// Equal requests and limits lose the CPU limit
+	burstable = ApplyQoSClass(corev1.PodQOSBurstable, guaranteed)
+	assert.NotContains(t, burstable.Limits, corev1.ResourceCPU)
+	assert.Contains(t, burstable.Limits, corev1.ResourceMemory)
+
+	withStorage := *recommended.DeepCopy()
+	withStorage.Requests[corev1.ResourceEphemeralStorage] = resource.MustParse("1Gi")
+	bestEffort := ApplyQoSClass(corev1.PodQOSBestEffort, withStorage)
+	assert.Len(t, bestEffort.Requests, 1)
+	assert.Empty(t, bestEffort.Limits)
+
+	// Applying the class again changes nothing
+	again := ApplyQoSClass(corev1.PodQOSGuaranteed, guaranteed)
+	assertResourceList(t, guaranteed.Requests, again.Requests)
+	assertResourceList(t, guaranteed.Limits, again.Limits)
+}
+
+func TestPodQOSClass(t *testing.T) {
+	resources := func(cpuRequest, cpuLimit, memoryRequest, memoryLimit string) corev1.ResourceRequirements {
+		requirements := corev1.ResourceRequirements{Requests: corev1.ResourceList{}, Limits: corev1.ResourceList{}}
+		for name, value := range map[corev1.ResourceName]string{corev1.ResourceCPU: cpuRequest, corev1.ResourceMemory: memoryRequest} {
+			if value != "" {
+				requirements.Requests[name] = resource.MustParse(value)
+			}
+		}
+		for name, value := range map[corev1.ResourceName]string{corev1.ResourceCPU: cpuLimit, corev1.ResourceMemory: memoryLimit} {
+			if value != "" {
+				requirements.Limits[name] = resource.MustParse(value)
+			}
+		}
+		return requirements
+	}
+
+	tests := []struct {
+		name string
+		spec corev1.PodSpec
+		want corev1.PodQOSClass
+	}{
+		{
+			name: "no resources",
+			spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			want: corev1.PodQOSBestEffort,
+		},
+		{
+			name: "limits only default requests",
+			spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Resources: resources("", "1", "", "1Gi")},
+			}},
+			want: corev1.PodQOSGuaranteed,
+		},
+		{
+			name: "requests below limits",
+			spec: corev1.PodSpec{Containers: []corev1.Container{
+				{Name: "app", Resources: resources("500m", "1", "1Gi", "1Gi")},
+			}},
+			want: corev1.PodQOSBurstable,
+		},
+		{
+			name: "init container without limits",
+			spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "init", Resources: resources("100m", "", "", "")}},
+				Containers: []corev1.Container{
+					{Name: "app", Resources: resources("1", "1", "1Gi", "1Gi")},
+				},
+			},
+			want: corev1.PodQOSBurstable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PodQOSClass(&tt.spec))
+		})
+	}
+}
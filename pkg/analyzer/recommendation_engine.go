@@ -5,49 +5,126 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"slices"
 	"sort"
 	"time"
 
+	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
 	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/stats"
 )
 
 // RecommendationEngine generates resource recommendations based on historical usage
 type RecommendationEngine struct {
 	// Configuration options
-	DefaultSafetyMargin        int     // Default safety margin percentage
-	DefaultConfidenceThreshold int     // Minimum confidence level
-	MinDataPoints              int     // Minimum data points required for recommendations
-	CPURequestMultiplier       float64 // Multiplier for CPU requests vs limits
-	MemoryRequestMultiplier    float64 // Multiplier for memory requests vs limits
+	DefaultSafetyMargin           int     // Default safety margin percentage
+	DefaultConfidenceThreshold    int     // Minimum confidence level
+	MinDataPoints                 int     // Minimum data points required for recommendations
+	CPURequestMultiplier          float64 // Multiplier for CPU requests vs limits
+	MemoryRequestMultiplier       float64 // Multiplier for memory requests vs limits
+	StorageRequestMultiplier      float64 // Multiplier for ephemeral-storage requests vs limits
+	OOMConfidencePenalty          int     // Confidence deducted for every OOM kill in the window
+	RestartConfidencePenalty      int     // Confidence deducted for every container restart in the window
+	DefaultCPUThrottlingThreshold int     // Throttled CFS period percentage above which CPU limits are raised
+	MaxInterpolatedGap            int     // Longest run of missing samples filled by interpolation, in sample intervals
+	FullConfidenceCoverage        float64 // Share of the window metrics must cover before confidence is reduced
+	FullConfidenceDays            int     // Distinct days that must be observed before confidence is reduced
+	FullConfidenceReplicas        int     // Replicas that must be analyzed before confidence is reduced
+
+	// Change point detection
+	MinChangePointShift      float64       // Relative shift of mean usage that resets the history, zero to disable
+	FullConfidencePostChange time.Duration // History after a change point needed before confidence is no longer reduced
 }
 
 // NewRecommendationEngine creates a new recommendation engine with default settings
 func NewRecommendationEngine() *RecommendationEngine {
 	return &RecommendationEngine{
-		DefaultSafetyMargin:        20,  // 20% safety margin
-		DefaultConfidenceThreshold: 70,  // 70% confidence threshold
-		MinDataPoints:              10,  // Minimum 10 data points
-		CPURequestMultiplier:       0.8, // Requests = 80% of limits
-		MemoryRequestMultiplier:    0.9, // Requests = 90% of limits
+		DefaultSafetyMargin:           20,  // 20% safety margin
+		DefaultConfidenceThreshold:    70,  // 70% confidence threshold
+		MinDataPoints:                 10,  // Minimum 10 data points
+		CPURequestMultiplier:          0.8, // Requests = 80% of limits
+		MemoryRequestMultiplier:       0.9, // Requests = 90% of limits
+		StorageRequestMultiplier:      0.9, // Requests = 90% of limits
+		OOMConfidencePenalty:          20,  // 20 points off confidence per OOM kill
+		RestartConfidencePenalty:      5,   // 5 points off confidence per container restart
+		DefaultCPUThrottlingThreshold: 10,  // Raise CPU limits when over 10% of periods are throttled
+		MaxInterpolatedGap:            metrics.DefaultMaxGapSteps,
+		FullConfidenceCoverage:        0.7, // Confidence drops proportionally below 70% coverage
+		FullConfidenceDays:            7,   // A week of data captures weekday and weekend usage
+		FullConfidenceReplicas:        2,   // A single replica costs 5% of confidence
+		MinChangePointShift:           0.2, // Only shifts of 20% or more reset the history
+		FullConfidencePostChange:      24 * time.Hour,
 	}
 }
 
-// GenerateRecommendations generates resource recommendations for a workload
+// RecommendationOptions holds the per-PodRightSizing settings used to generate recommendations
+type RecommendationOptions struct {
+	Thresholds  rightsizingv1alpha1.ResourceThresholds
+	LimitPolicy rightsizingv1alpha1.LimitPolicy
+	Recommender rightsizingv1alpha1.RecommenderSpec
+	// Horizon is how long the recommendations must hold, e.g. until the next analysis plus the minimum
+	// stability period. Forecasting recommenders project usage this far ahead.
+	Horizon time.Duration
+	// OutlierFilter removes anomalous samples and replicas before analysis
+	OutlierFilter rightsizingv1alpha1.OutlierFilter
+	// Exclusions are time ranges whose samples are removed before analysis
+	Exclusions []rightsizingv1alpha1.AnalysisExclusion
+	// Rollouts are the pod template changes of the workload within the window. Usage shifts at a rollout
+	// reset the history to the samples after it.
+	Rollouts []Rollout
+}
+
+// GenerateRecommendations generates resource recommendations for a workload with the default limit policy
 func (r *RecommendationEngine) GenerateRecommendations(
 	ctx context.Context,
 	workloadMetrics *metrics.WorkloadMetrics,
 	thresholds rightsizingv1alpha1.ResourceThresholds,
+) ([]rightsizingv1alpha1.PodRecommendation, error) {
+	return r.GenerateRecommendationsWithOptions(ctx, workloadMetrics, RecommendationOptions{Thresholds: thresholds})
+}
+
+// GenerateRecommendationsWithOptions generates resource recommendations for a workload
+func (r *RecommendationEngine) GenerateRecommendationsWithOptions(
+	ctx context.Context,
+	workloadMetrics *metrics.WorkloadMetrics,
+	opts RecommendationOptions,
 ) ([]rightsizingv1alpha1.PodRecommendation, error) {
 	logger := log.FromContext(ctx)
 
 	if len(workloadMetrics.Pods) == 0 {
 		return nil, fmt.Errorf("no pod metrics provided")
 	}
+	if _, err := startupExclusion(opts.Thresholds); err != nil {
+		return nil, err
+	}
+	if _, err := outlierThreshold(opts.OutlierFilter); err != nil {
+		return nil, err
+	}
+	recommender, err := r.NewRecommender(opts.Recommender)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resample every pod onto a fixed grid, so gaps neither skew percentiles nor go unnoticed
+	pods := make([]metrics.PodMetrics, len(workloadMetrics.Pods))
+	for i, podMetrics := range workloadMetrics.Pods {
+		pods[i] = metrics.NormalizePodMetrics(podMetrics, r.MaxInterpolatedGap)
+	}
+
+	// Replicas are compared on their usage outside the excluded time ranges
+	compared := make([]metrics.PodMetrics, len(pods))
+	for i, podMetrics := range pods {
+		compared[i], _ = excludeTimeRanges(podMetrics, opts.Exclusions)
+	}
+	rejected := r.rejectOutlierReplicas(compared, opts.OutlierFilter)
+	if len(rejected) > 0 {
+		logger.Info("Rejecting outlier replicas", "workload", workloadMetrics.WorkloadName, "pods", rejected)
+	}
 
 	var recommendations []rightsizingv1alpha1.PodRecommendation
 
@@ -56,8 +133,13 @@ func (r *RecommendationEngine) GenerateRecommendations(
 		"podCount", len(workloadMetrics.Pods))
 
 	// Generate recommendations for each pod in the workload
-	for _, podMetrics := range workloadMetrics.Pods {
-		recommendation, err := r.generatePodRecommendation(ctx, podMetrics, thresholds)
+	replicas := len(pods) - len(rejected)
+	for _, podMetrics := range pods {
+		if slices.Contains(rejected, podMetrics.PodName) {
+			continue
+		}
+
+		recommendation, err := r.generatePodRecommendation(ctx, podMetrics, opts, recommender, replicas)
 		if err != nil {
 			logger.Error(err, "Failed to generate recommendation for pod",
 				"podName", podMetrics.PodName,
@@ -66,6 +148,9 @@ func (r *RecommendationEngine) GenerateRecommendations(
 		}
 
 		if recommendation != nil {
+			if len(rejected) > 0 {
+				recommendation.Reason += describeRejectedReplicas(rejected)
+			}
 			recommendations = append(recommendations, *recommendation)
 		}
 	}
@@ -77,40 +162,61 @@ func (r *RecommendationEngine) GenerateRecommendations(
 	return recommendations, nil
 }
 
-// generatePodRecommendation generates a recommendation for a single pod
+// generatePodRecommendation generates a recommendation for a single pod of a workload with replicas
+// analyzed pods
 func (r *RecommendationEngine) generatePodRecommendation(
 	ctx context.Context,
 	podMetrics metrics.PodMetrics,
-	thresholds rightsizingv1alpha1.ResourceThresholds,
+	opts RecommendationOptions,
+	recommender Recommender,
+	replicas int,
 ) (*rightsizingv1alpha1.PodRecommendation, error) {
 	logger := log.FromContext(ctx).WithValues("pod", podMetrics.PodName)
+	thresholds := opts.Thresholds
+
+	exclusion, err := startupExclusion(thresholds)
+	if err != nil {
+		return nil, err
+	}
+	observed := podMetrics
+	podMetrics, excludedInRanges := excludeTimeRanges(podMetrics, opts.Exclusions)
+	podMetrics, startupPeak, excluded := excludeStartupSamples(podMetrics, exclusion)
+	podMetrics, change, beforeChange := r.resetAtChangePoint(podMetrics, opts.Rollouts)
+	podMetrics, outliers := r.filterPodOutliers(podMetrics, opts.OutlierFilter)
 
 	logger.V(1).Info("Analyzing pod metrics",
 		"cpuDataPoints", len(podMetrics.CPUUsageHistory),
 		"memoryDataPoints", len(podMetrics.MemUsageHistory))
 
 	// Analyze CPU usage
-	cpuRecommendation, cpuConfidence, err := r.analyzeCPUUsage(podMetrics.CPUUsageHistory, thresholds)
+	cpuRecommendation, cpuConfidence, err := r.analyzeUsage(
+		recommender, corev1.ResourceCPU, podMetrics.CPUUsageHistory, thresholds, opts.Horizon)
 	if err != nil {
 		return nil, fmt.Errorf("failed to analyze CPU usage: %w", err)
 	}
 
 	// Analyze Memory usage
-	memoryRecommendation, memoryConfidence, err := r.analyzeMemoryUsage(podMetrics.MemUsageHistory, thresholds)
+	memoryRecommendation, memoryConfidence, err := r.analyzeUsage(
+		recommender, corev1.ResourceMemory, podMetrics.MemUsageHistory, thresholds, opts.Horizon)
 	if err != nil {
 		return nil, fmt.Errorf("failed to analyze memory usage: %w", err)
 	}
 
-	// Calculate overall confidence (minimum of CPU and memory confidence)
-	overallConfidence := int(math.Min(float64(cpuConfidence), float64(memoryConfidence)))
+	// Overall confidence weighs the variability of usage against how much evidence there is of it
+	confidenceFactors := r.confidenceFactors(observed, cpuConfidence, memoryConfidence, replicas, change)
+	overallConfidence := combineConfidence(confidenceFactors)
 
 	logger.V(1).Info("Calculated confidence scores",
 		"cpuConfidence", cpuConfidence,
 		"memoryConfidence", memoryConfidence,
+		"factors", confidenceFactors,
 		"overallConfidence", overallConfidence)
 
-	// Skip recommendation if confidence is too low
-	if overallConfidence < r.DefaultConfidenceThreshold {
+	// Memory usage of OOM-killed containers was capped at the limit, so real demand is unknown
+	oomKilled := len(podMetrics.OOMKills) > 0
+
+	// Skip recommendation if confidence is too low, unless memory has to grow after OOM kills
+	if overallConfidence < r.DefaultConfidenceThreshold && !oomKilled {
 		logger.Info("Skipping recommendation due to low confidence",
 			"confidence", overallConfidence,
 			"threshold", r.DefaultConfidenceThreshold)
@@ -121,33 +227,19 @@ func (r *RecommendationEngine) generatePodRecommendation(
 		"confidence", overallConfidence,
 		"threshold", r.DefaultConfidenceThreshold)
 
-	// Build recommended resource requirements
-	recommendedResources := corev1.ResourceRequirements{
-		Requests: corev1.ResourceList{},
-		Limits:   corev1.ResourceList{},
-	}
-
-	// Set CPU recommendations
-	if cpuRecommendation.Limit != nil {
-		recommendedResources.Limits[corev1.ResourceCPU] = *cpuRecommendation.Limit
+	// Ephemeral storage is optional since not every metrics backend reports it
+	storageRecommendation := r.analyzeOptionalStorageUsage(
+		logger, recommender, podMetrics.StorageUsageHistory, thresholds, opts.Horizon)
 
-		// Calculate request as percentage of limit
-		limitValue := cpuRecommendation.Limit.AsApproximateFloat64()
-		requestValue := limitValue * r.CPURequestMultiplier
-		recommendedResources.Requests[corev1.ResourceCPU] = *resource.NewMilliQuantity(
-			int64(requestValue*1000), resource.DecimalSI)
-	}
+	r.applyLimitPercentile(opts, recommender, podMetrics.CPUUsageHistory, podMetrics.MemUsageHistory, podMetrics.StorageUsageHistory,
+		cpuRecommendation, memoryRecommendation, storageRecommendation)
 
-	// Set Memory recommendations
-	if memoryRecommendation.Limit != nil {
-		recommendedResources.Limits[corev1.ResourceMemory] = *memoryRecommendation.Limit
+	r.applyThrottlingBoost(cpuRecommendation, podMetrics.CPUThrottlingHistory, thresholds)
+	memoryFloor := r.applyOOMFloor(memoryRecommendation, podMetrics, thresholds)
 
-		// Calculate request as percentage of limit
-		limitValue := memoryRecommendation.Limit.AsApproximateFloat64()
-		requestValue := limitValue * r.MemoryRequestMultiplier
-		recommendedResources.Requests[corev1.ResourceMemory] = *resource.NewQuantity(
-			int64(requestValue), resource.BinarySI)
-	}
+	// Build recommended resource requirements
+	recommendedResources := r.buildRecommendedResources(
+		cpuRecommendation, memoryRecommendation, storageRecommendation, opts.LimitPolicy)
 
 	// Create the recommendation
 	recommendation := &rightsizingv1alpha1.PodRecommendation{
@@ -158,8 +250,35 @@ func (r *RecommendationEngine) generatePodRecommendation(
 		},
 		RecommendedResources: recommendedResources,
 		Confidence:           overallConfidence,
-		Reason:               r.buildReasonString(cpuRecommendation, memoryRecommendation, thresholds),
+		ConfidenceFactors:    confidenceFactors,
+		Reason:               r.buildReasonString(cpuRecommendation, memoryRecommendation, storageRecommendation, thresholds),
 		Applied:              false,
+		InitContainers: r.generateContainerRecommendations(
+			ctx, podMetrics.PodName, podMetrics.InitContainers, observed, confidenceFactors, opts, recommender),
+		StartupPeak: startupPeak,
+	}
+	if len(podMetrics.Containers) > 1 {
+		recommendation.Containers = r.generateContainerRecommendations(
+			ctx, podMetrics.PodName, podMetrics.Containers, observed, confidenceFactors, opts, recommender)
+	}
+	if memoryFloor != nil {
+		recommendation.LimitFloors = corev1.ResourceList{corev1.ResourceMemory: *memoryFloor}
+	}
+	recommendation.Reason += describeConfidence(overallConfidence, confidenceFactors)
+	if excludedInRanges > 0 {
+		recommendation.Reason += fmt.Sprintf(" Excluded %d samples within %d analysis exclusions.",
+			excludedInRanges, len(opts.Exclusions))
+	}
+	if excluded > 0 {
+		recommendation.Reason += fmt.Sprintf(" Excluded %d samples within %s of %d container starts.",
+			excluded, thresholds.StartupExclusion, len(podMetrics.ContainerStarts))
+	}
+	if change != nil {
+		recommendation.Reason += fmt.Sprintf(" Reset history at change point: %s; dropped %d samples from before it.",
+			change, beforeChange)
+	}
+	if outliers > 0 {
+		recommendation.Reason += fmt.Sprintf(" Removed %d %s outlier samples.", outliers, opts.OutlierFilter.Method)
 	}
 
 	// Calculate potential savings (placeholder - actual current resources would come from controller)
@@ -175,107 +294,292 @@ func (r *RecommendationEngine) generatePodRecommendation(
 	return recommendation, nil
 }
 
-// ResourceRecommendation represents a recommendation for a single resource type
-type ResourceRecommendation struct {
-	Request    *resource.Quantity
-	Limit      *resource.Quantity
-	Percentile float64
-	Confidence int
-	DataPoints int
-	Reason     string
+// generateContainerRecommendations generates recommendations for each container of a pod from its own
+// usage series, such as the init and sidecar containers or the regular containers of a pod running more
+// than one. The memory of containers among the OOM-killed ones is kept above the limit they were killed
+// at, and the CPU limit of throttled containers is raised. Confidence combines the pod's factors with the
+// container's own variability and restarts, and containers without enough data or confidence are skipped.
+// observed holds the pod's normalized metrics before any samples were excluded.
+func (r *RecommendationEngine) generateContainerRecommendations(
+	ctx context.Context,
+	podName string,
+	containers map[string]metrics.ContainerMetrics,
+	observed metrics.PodMetrics,
+	podFactors []rightsizingv1alpha1.ConfidenceFactor,
+	opts RecommendationOptions,
+	recommender Recommender,
+) []rightsizingv1alpha1.ContainerRecommendation {
+	logger := log.FromContext(ctx).WithValues("pod", podName)
+	thresholds := opts.Thresholds
+
+	names := make([]string, 0, len(containers))
+	for name := range containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var recommendations []rightsizingv1alpha1.ContainerRecommendation
+	for _, name := range names {
+		containerMetrics := containers[name]
+		var containerKills []metrics.OOMKill
+		for _, kill := range observed.OOMKills {
+			if kill.Container == name {
+				containerKills = append(containerKills, kill)
+			}
+		}
+
+		cpuRecommendation, cpuConfidence, err := r.analyzeUsage(
+			recommender, corev1.ResourceCPU, containerMetrics.CPUUsageHistory, thresholds, opts.Horizon)
+		if err != nil {
+			logger.V(1).Info("Skipping container", "container", name, "reason", err.Error())
+			continue
+		}
+		memoryRecommendation, memoryConfidence, err := r.analyzeUsage(
+			recommender, corev1.ResourceMemory, containerMetrics.MemUsageHistory, thresholds, opts.Horizon)
+		if err != nil {
+			logger.V(1).Info("Skipping container", "container", name, "reason", err.Error())
+			continue
+		}
+
+		confidenceFactors := r.containerConfidenceFactors(podFactors, observed, name, cpuConfidence, memoryConfidence)
+		confidence := combineConfidence(confidenceFactors)
+		if confidence < r.DefaultConfidenceThreshold && len(containerKills) == 0 {
+			logger.V(1).Info("Skipping container due to low confidence", "container", name, "confidence", confidence)
+			continue
+		}
+
+		storageRecommendation := r.analyzeOptionalStorageUsage(
+			logger.WithValues("container", name), recommender, containerMetrics.StorageUsageHistory, thresholds, opts.Horizon)
+
+		r.applyLimitPercentile(opts, recommender,
+			containerMetrics.CPUUsageHistory, containerMetrics.MemUsageHistory, containerMetrics.StorageUsageHistory,
+			cpuRecommendation, memoryRecommendation, storageRecommendation)
+		r.applyThrottlingBoost(cpuRecommendation, containerMetrics.CPUThrottlingHistory, thresholds)
+		memoryFloor := r.applyOOMFloor(memoryRecommendation,
+			metrics.PodMetrics{MemUsageHistory: containerMetrics.MemUsageHistory, OOMKills: containerKills}, thresholds)
+
+		recommendation := rightsizingv1alpha1.ContainerRecommendation{
+			Name: name,
+			RecommendedResources: r.buildRecommendedResources(
+				cpuRecommendation, memoryRecommendation, storageRecommendation, opts.LimitPolicy),
+			Confidence: confidence,
+			Reason: r.buildReasonString(cpuRecommendation, memoryRecommendation, storageRecommendation, thresholds) +
+				describeConfidence(confidence, confidenceFactors),
+		}
+		if memoryFloor != nil {
+			recommendation.LimitFloors = corev1.ResourceList{corev1.ResourceMemory: *memoryFloor}
+		}
+		recommendations = append(recommendations, recommendation)
+	}
+
+	return recommendations
 }
 
-// analyzeCPUUsage analyzes CPU usage history and generates recommendations
-func (r *RecommendationEngine) analyzeCPUUsage(
-	cpuHistory []metrics.ResourceUsage,
-	thresholds rightsizingv1alpha1.ResourceThresholds,
-) (*ResourceRecommendation, int, error) {
+// applyLimitPercentile sizes limits from the limit policy's percentile in Percentile mode. The size
+// from the utilization percentile becomes the request, and limits never drop below it.
+func (r *RecommendationEngine) applyLimitPercentile(
+	opts RecommendationOptions,
+	recommender Recommender,
+	cpuHistory, memoryHistory, storageHistory []metrics.ResourceUsage,
+	cpuRecommendation, memoryRecommendation, storageRecommendation *ResourceRecommendation,
+) {
+	if opts.LimitPolicy.Mode != rightsizingv1alpha1.LimitModePercentile || opts.LimitPolicy.LimitPercentile <= 0 {
+		return
+	}
 
-	if len(cpuHistory) < r.MinDataPoints {
-		return nil, 0, fmt.Errorf("insufficient CPU data points: %d < %d", len(cpuHistory), r.MinDataPoints)
+	limitThresholds := opts.Thresholds
+	limitThresholds.CPUUtilizationPercentile = opts.LimitPolicy.LimitPercentile
+	limitThresholds.MemoryUtilizationPercentile = opts.LimitPolicy.LimitPercentile
+	limitThresholds.EphemeralStorageUtilizationPercentile = opts.LimitPolicy.LimitPercentile
+
+	// The series already passed analysis at the utilization percentile, so errors cannot occur here
+	cpuLimit, _, _ := r.analyzeUsage(recommender, corev1.ResourceCPU, cpuHistory, limitThresholds, opts.Horizon)
+	memoryLimit, _, _ := r.analyzeUsage(recommender, corev1.ResourceMemory, memoryHistory, limitThresholds, opts.Horizon)
+	var storageLimit *ResourceRecommendation
+	if storageRecommendation != nil {
+		storageLimit, _, _ = r.analyzeUsage(
+			recommender, corev1.ResourceEphemeralStorage, storageHistory, limitThresholds, opts.Horizon)
 	}
 
-	// Extract values and sort them
-	values := make([]float64, len(cpuHistory))
-	for i, usage := range cpuHistory {
-		values[i] = usage.Value
+	for _, pair := range [][2]*ResourceRecommendation{
+		{cpuRecommendation, cpuLimit},
+		{memoryRecommendation, memoryLimit},
+		{storageRecommendation, storageLimit},
+	} {
+		recommendation, limit := pair[0], pair[1]
+		if recommendation == nil || recommendation.Limit == nil || limit == nil || limit.Limit == nil {
+			continue
+		}
+		recommendation.Request = recommendation.Limit
+		if limit.Limit.Cmp(*recommendation.Request) > 0 {
+			recommendation.Limit = limit.Limit
+		}
 	}
-	sort.Float64s(values)
+}
 
-	// Get the target percentile (default to 95th percentile)
-	percentile := 95
-	if thresholds.CPUUtilizationPercentile > 0 {
-		percentile = thresholds.CPUUtilizationPercentile
+// applyOOMFloor raises the memory limit above the limit the containers were OOM-killed at, by the
+// safety margin. When the limit is unknown the peak observed usage stands in for it. The limit never
+// ends up below the one the containers were killed at: when maxMemory is lower, the limit is left
+// there and the reason says so. It returns the floor the memory limit must keep, nil without OOM kills.
+func (r *RecommendationEngine) applyOOMFloor(
+	memoryRecommendation *ResourceRecommendation,
+	podMetrics metrics.PodMetrics,
+	thresholds rightsizingv1alpha1.ResourceThresholds,
+) *resource.Quantity {
+	if len(podMetrics.OOMKills) == 0 || memoryRecommendation == nil || memoryRecommendation.Limit == nil {
+		return nil
 	}
 
-	// Calculate percentile value
-	percentileValue := r.calculatePercentile(values, float64(percentile))
+	killedAt := 0.0
+	for _, kill := range podMetrics.OOMKills {
+		killedAt = math.Max(killedAt, kill.MemoryLimit)
+	}
+	if killedAt == 0 {
+		for _, usage := range podMetrics.MemUsageHistory {
+			killedAt = math.Max(killedAt, usage.Value)
+		}
+	}
 
-	// Apply safety margin
 	safetyMargin := r.DefaultSafetyMargin
 	if thresholds.SafetyMargin > 0 {
 		safetyMargin = thresholds.SafetyMargin
 	}
+	floor := killedAt * (1.0 + float64(safetyMargin)/100.0)
+	killedAtQuantity := resource.NewQuantity(int64(killedAt), resource.BinarySI)
+	reason := fmt.Sprintf(", kept above the %s limit after %d OOM kills (usage was capped, confidence reduced)",
+		killedAtQuantity.String(), len(podMetrics.OOMKills))
+	if !thresholds.MaxMemory.IsZero() && thresholds.MaxMemory.AsApproximateFloat64() < floor {
+		floor = math.Max(thresholds.MaxMemory.AsApproximateFloat64(), killedAt)
+		if thresholds.MaxMemory.AsApproximateFloat64() < killedAt {
+			// Lowering the limit would only bring the OOM kills back sooner
+			reason = fmt.Sprintf(", left at the %s limit after %d OOM kills since maxMemory %s is below it; "+
+				"raise maxMemory to size memory for the demand", killedAtQuantity.String(), len(podMetrics.OOMKills),
+				thresholds.MaxMemory.String())
+		}
+	}
 
-	recommendedLimit := percentileValue * (1.0 + float64(safetyMargin)/100.0)
+	floorQuantity := resource.NewQuantity(int64(floor), resource.BinarySI)
+	if memoryRecommendation.Limit.Cmp(*floorQuantity) < 0 {
+		memoryRecommendation.Limit = floorQuantity
+	}
+	if memoryRecommendation.Request != nil && memoryRecommendation.Request.Cmp(*memoryRecommendation.Limit) > 0 {
+		memoryRecommendation.Request = memoryRecommendation.Limit
+	}
 
-	// Apply min/max constraints
-	if !thresholds.MinCPU.IsZero() {
-		minCPU := thresholds.MinCPU.AsApproximateFloat64()
-		if recommendedLimit < minCPU {
-			recommendedLimit = minCPU
-		}
+	memoryRecommendation.Reason += reason
+	return floorQuantity
+}
+
+// buildRecommendedResources converts CPU, memory and ephemeral-storage recommendations into resource
+// requirements. Requests are derived from the recommended limits unless a recommendation sets its own,
+// and the limit policy decides which limits are part of the result.
+func (r *RecommendationEngine) buildRecommendedResources(
+	cpuRecommendation, memoryRecommendation, storageRecommendation *ResourceRecommendation,
+	policy rightsizingv1alpha1.LimitPolicy,
+) corev1.ResourceRequirements {
+	recommendedResources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{},
+		Limits:   corev1.ResourceList{},
 	}
 
-	if !thresholds.MaxCPU.IsZero() {
-		maxCPU := thresholds.MaxCPU.AsApproximateFloat64()
-		if recommendedLimit > maxCPU {
-			recommendedLimit = maxCPU
-		}
+	// Set CPU recommendations
+	if cpuRecommendation.Limit != nil {
+		recommendedResources.Limits[corev1.ResourceCPU] = *cpuRecommendation.Limit
+		recommendedResources.Requests[corev1.ResourceCPU] = requestQuantity(corev1.ResourceCPU, cpuRecommendation, r.CPURequestMultiplier)
 	}
 
-	// Calculate confidence based on data consistency
-	confidence := r.calculateConfidence(values)
+	// Set Memory recommendations
+	if memoryRecommendation.Limit != nil {
+		recommendedResources.Limits[corev1.ResourceMemory] = *memoryRecommendation.Limit
+		recommendedResources.Requests[corev1.ResourceMemory] = requestQuantity(corev1.ResourceMemory, memoryRecommendation, r.MemoryRequestMultiplier)
+	}
 
-	// Convert to Kubernetes resource format
-	limitQuantity := resource.NewMilliQuantity(int64(recommendedLimit*1000), resource.DecimalSI)
+	// Set ephemeral-storage recommendations
+	if storageRecommendation != nil && storageRecommendation.Limit != nil {
+		recommendedResources.Limits[corev1.ResourceEphemeralStorage] = *storageRecommendation.Limit
+		recommendedResources.Requests[corev1.ResourceEphemeralStorage] = requestQuantity(corev1.ResourceEphemeralStorage, storageRecommendation, r.StorageRequestMultiplier)
+	}
 
-	recommendation := &ResourceRecommendation{
-		Limit:      limitQuantity,
-		Percentile: percentileValue,
-		Confidence: confidence,
-		DataPoints: len(cpuHistory),
-		Reason:     fmt.Sprintf("Based on %dth percentile of %d data points", percentile, len(cpuHistory)),
+	switch policy.Mode {
+	case rightsizingv1alpha1.LimitModeRequestsOnly, rightsizingv1alpha1.LimitModePreserveRatio:
+		// Limits follow the containers' current resources, see ApplyLimitPolicy
+		recommendedResources.Limits = corev1.ResourceList{}
 	}
+	if policy.RemoveCPULimits {
+		delete(recommendedResources.Limits, corev1.ResourceCPU)
+	}
+
+	return recommendedResources
+}
 
-	return recommendation, confidence, nil
+// requestQuantity returns the request of a recommendation, deriving it from the limit when it is not set
+func requestQuantity(
+	name corev1.ResourceName,
+	recommendation *ResourceRecommendation,
+	multiplier float64,
+) resource.Quantity {
+	if recommendation.Request != nil {
+		return *recommendation.Request
+	}
+
+	// Calculate request as percentage of limit
+	return scaleQuantity(name, *recommendation.Limit, multiplier)
+}
+
+// ResourceRecommendation represents a recommendation for a single resource type
+type ResourceRecommendation struct {
+	Request    *resource.Quantity
+	Limit      *resource.Quantity
+	Percentile float64
+	Confidence int
+	DataPoints int
+	Reason     string
 }
 
-// analyzeMemoryUsage analyzes memory usage history and generates recommendations
-func (r *RecommendationEngine) analyzeMemoryUsage(
-	memoryHistory []metrics.ResourceUsage,
+// analyzeUsage sizes a resource from its usage history. The recommender estimates the usage to size
+// for over the horizon, then the safety margin and the min/max constraints of the resource are applied.
+func (r *RecommendationEngine) analyzeUsage(
+	recommender Recommender,
+	resourceName corev1.ResourceName,
+	history []metrics.ResourceUsage,
 	thresholds rightsizingv1alpha1.ResourceThresholds,
+	horizon time.Duration,
 ) (*ResourceRecommendation, int, error) {
-
-	if len(memoryHistory) < r.MinDataPoints {
-		return nil, 0, fmt.Errorf("insufficient memory data points: %d < %d", len(memoryHistory), r.MinDataPoints)
+	// Per-resource percentile and constraints
+	var percentile int
+	var minimum, maximum resource.Quantity
+	var label string
+	switch resourceName {
+	case corev1.ResourceCPU:
+		percentile, minimum, maximum, label = thresholds.CPUUtilizationPercentile, thresholds.MinCPU, thresholds.MaxCPU, "CPU"
+	case corev1.ResourceMemory:
+		percentile, minimum, maximum, label =
+			thresholds.MemoryUtilizationPercentile, thresholds.MinMemory, thresholds.MaxMemory, "memory"
+	case corev1.ResourceEphemeralStorage:
+		percentile, minimum, maximum, label = thresholds.EphemeralStorageUtilizationPercentile,
+			thresholds.MinEphemeralStorage, thresholds.MaxEphemeralStorage, "ephemeral-storage"
+	default:
+		return nil, 0, fmt.Errorf("unsupported resource %q", resourceName)
 	}
 
-	// Extract values and sort them
-	values := make([]float64, len(memoryHistory))
-	for i, usage := range memoryHistory {
-		values[i] = usage.Value
+	if len(history) < r.MinDataPoints {
+		return nil, 0, fmt.Errorf("insufficient %s data points: %d < %d", label, len(history), r.MinDataPoints)
 	}
-	sort.Float64s(values)
 
 	// Get the target percentile (default to 95th percentile)
-	percentile := 95
-	if thresholds.MemoryUtilizationPercentile > 0 {
-		percentile = thresholds.MemoryUtilizationPercentile
+	if percentile <= 0 {
+		percentile = 95
 	}
 
-	// Calculate percentile value
-	percentileValue := r.calculatePercentile(values, float64(percentile))
+	estimate, err := recommender.Estimate(RecommenderInput{
+		Resource:   resourceName,
+		History:    history,
+		Percentile: percentile,
+		Horizon:    horizon,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
 
 	// Apply safety margin
 	safetyMargin := r.DefaultSafetyMargin
@@ -283,76 +587,67 @@ func (r *RecommendationEngine) analyzeMemoryUsage(
 		safetyMargin = thresholds.SafetyMargin
 	}
 
-	recommendedLimit := percentileValue * (1.0 + float64(safetyMargin)/100.0)
+	recommendedLimit := estimate.Value * (1.0 + float64(safetyMargin)/100.0)
 
 	// Apply min/max constraints
-	if !thresholds.MinMemory.IsZero() {
-		minMemory := thresholds.MinMemory.AsApproximateFloat64()
-		if recommendedLimit < minMemory {
-			recommendedLimit = minMemory
-		}
+	if !minimum.IsZero() {
+		recommendedLimit = math.Max(recommendedLimit, minimum.AsApproximateFloat64())
 	}
-
-	if !thresholds.MaxMemory.IsZero() {
-		maxMemory := thresholds.MaxMemory.AsApproximateFloat64()
-		if recommendedLimit > maxMemory {
-			recommendedLimit = maxMemory
-		}
+	if !maximum.IsZero() {
+		recommendedLimit = math.Min(recommendedLimit, maximum.AsApproximateFloat64())
 	}
 
-	// Calculate confidence based on data consistency
-	confidence := r.calculateConfidence(values)
-
 	// Convert to Kubernetes resource format
 	limitQuantity := resource.NewQuantity(int64(recommendedLimit), resource.BinarySI)
+	if resourceName == corev1.ResourceCPU {
+		limitQuantity = resource.NewMilliQuantity(int64(recommendedLimit*1000), resource.DecimalSI)
+	}
 
 	recommendation := &ResourceRecommendation{
 		Limit:      limitQuantity,
-		Percentile: percentileValue,
-		Confidence: confidence,
-		DataPoints: len(memoryHistory),
-		Reason:     fmt.Sprintf("Based on %dth percentile of %d data points", percentile, len(memoryHistory)),
+		Percentile: estimate.Value,
+		Confidence: estimate.Confidence,
+		DataPoints: len(history),
+		Reason:     estimate.Reason,
 	}
 
-	return recommendation, confidence, nil
+	return recommendation, estimate.Confidence, nil
 }
 
-// calculatePercentile calculates the percentile value from sorted data
-func (r *RecommendationEngine) calculatePercentile(sortedValues []float64, percentile float64) float64 {
-	if len(sortedValues) == 0 {
-		return 0
+// analyzeOptionalStorageUsage returns an ephemeral-storage recommendation, or nil when the history is
+// missing, too short or not confident enough. Storage never blocks CPU and memory recommendations.
+func (r *RecommendationEngine) analyzeOptionalStorageUsage(
+	logger logr.Logger,
+	recommender Recommender,
+	storageHistory []metrics.ResourceUsage,
+	thresholds rightsizingv1alpha1.ResourceThresholds,
+	horizon time.Duration,
+) *ResourceRecommendation {
+	if len(storageHistory) == 0 {
+		return nil
 	}
 
-	if percentile <= 0 {
-		return sortedValues[0]
-	}
-	if percentile >= 100 {
-		return sortedValues[len(sortedValues)-1]
+	recommendation, confidence, err := r.analyzeUsage(
+		recommender, corev1.ResourceEphemeralStorage, storageHistory, thresholds, horizon)
+	if err != nil {
+		logger.V(1).Info("Skipping ephemeral-storage recommendation", "reason", err.Error())
+		return nil
 	}
-
-	// Calculate index
-	index := (percentile / 100.0) * float64(len(sortedValues)-1)
-	lower := int(math.Floor(index))
-	upper := int(math.Ceil(index))
-
-	if lower == upper {
-		return sortedValues[lower]
+	if confidence < r.DefaultConfidenceThreshold {
+		logger.V(1).Info("Skipping ephemeral-storage recommendation due to low confidence", "confidence", confidence)
+		return nil
 	}
 
-	// Linear interpolation between lower and upper values
-	weight := index - float64(lower)
-	return sortedValues[lower]*(1-weight) + sortedValues[upper]*weight
+	return recommendation
 }
 
-// calculateConfidence calculates confidence level based on data variance
-func (r *RecommendationEngine) calculateConfidence(values []float64) int {
-	if len(values) < 2 {
+// momentsConfidence calculates confidence level from the mean and variance of the data
+func (r *RecommendationEngine) momentsConfidence(moments stats.Moments) int {
+	if moments.Count() < 2 {
 		return 50 // Low confidence with insufficient data
 	}
 
-	// Calculate mean and standard deviation
-	mean := r.calculateMean(values)
-	stdDev := r.calculateStandardDeviation(values, mean)
+	mean, stdDev := moments.Mean(), moments.StdDev()
 
 	// Calculate coefficient of variation (CV)
 	cv := 0.0
@@ -377,45 +672,17 @@ func (r *RecommendationEngine) calculateConfidence(values []float64) int {
 	}
 
 	// Boost confidence if we have more data points
-	dataPointBoost := math.Min(float64(len(values))/100.0, 0.1) // Up to 10% boost for 100+ points
+	dataPointBoost := math.Min(float64(moments.Count())/100.0, 0.1) // Up to 10% boost for 100+ points
 	confidence = int(math.Min(float64(confidence)*(1+dataPointBoost), 100))
 
 	return confidence
 }
 
-// calculateMean calculates the arithmetic mean of values
-func (r *RecommendationEngine) calculateMean(values []float64) float64 {
-	if len(values) == 0 {
-		return 0
-	}
-
-	sum := 0.0
-	for _, v := range values {
-		sum += v
-	}
-	return sum / float64(len(values))
-}
-
-// calculateStandardDeviation calculates the standard deviation
-func (r *RecommendationEngine) calculateStandardDeviation(values []float64, mean float64) float64 {
-	if len(values) < 2 {
-		return 0
-	}
-
-	sumSquaredDiff := 0.0
-	for _, v := range values {
-		diff := v - mean
-		sumSquaredDiff += diff * diff
-	}
-
-	variance := sumSquaredDiff / float64(len(values)-1)
-	return math.Sqrt(variance)
-}
-
 // buildReasonString creates a human-readable reason for the recommendation
 func (r *RecommendationEngine) buildReasonString(
 	cpuRec *ResourceRecommendation,
 	memRec *ResourceRecommendation,
+	storageRec *ResourceRecommendation,
 	thresholds rightsizingv1alpha1.ResourceThresholds,
 ) string {
 	var reasons []string
@@ -428,17 +695,18 @@ func (r *RecommendationEngine) buildReasonString(
 		reasons = append(reasons, fmt.Sprintf("Memory: %s", memRec.Reason))
 	}
 
+	if storageRec != nil {
+		reasons = append(reasons, fmt.Sprintf("Ephemeral storage: %s", storageRec.Reason))
+	}
+
 	safetyMargin := r.DefaultSafetyMargin
 	if thresholds.SafetyMargin > 0 {
 		safetyMargin = thresholds.SafetyMargin
 	}
 
 	reasonStr := "Recommendations based on historical usage analysis. "
-	if len(reasons) > 0 {
-		reasonStr += fmt.Sprintf("%s. ", reasons[0])
-		if len(reasons) > 1 {
-			reasonStr += fmt.Sprintf("%s. ", reasons[1])
-		}
+	for _, reason := range reasons {
+		reasonStr += fmt.Sprintf("%s. ", reason)
 	}
 	reasonStr += fmt.Sprintf("Applied %d%% safety margin.", safetyMargin)
 
@@ -504,7 +772,9 @@ func (a *AdvancedAnalyzer) analyzeResourcePatterns(
 	resourceType, unit string,
 	getUsageHistory func(metrics.PodMetrics) []metrics.ResourceUsage,
 ) (*ResourceAnalysis, error) {
-	var allValues []float64
+	// Pod sketches merge into the workload sketch, so no pass holds the samples of all pods
+	workloadSketch := stats.NewSketch()
+	var workloadMoments stats.Moments
 	podAnalyses := make([]PodResourceAnalysis, 0, len(pods))
 
 	for _, pod := range pods {
@@ -513,46 +783,42 @@ func (a *AdvancedAnalyzer) analyzeResourcePatterns(
 			continue
 		}
 
-		podValues := make([]float64, len(usageHistory))
-		for i, usage := range usageHistory {
-			podValues[i] = usage.Value
-			allValues = append(allValues, usage.Value)
+		sketch, moments := usageStats(usageHistory)
+		if err := workloadSketch.Merge(sketch); err != nil {
+			return nil, err
 		}
-
-		sort.Float64s(podValues)
+		workloadMoments.Merge(moments)
 
 		podAnalysis := PodResourceAnalysis{
 			PodName:     pod.PodName,
-			Min:         podValues[0],
-			Max:         podValues[len(podValues)-1],
-			Mean:        a.calculateMean(podValues),
-			P50:         a.calculatePercentile(podValues, 50),
-			P95:         a.calculatePercentile(podValues, 95),
-			P99:         a.calculatePercentile(podValues, 99),
-			StandardDev: a.calculateStandardDeviation(podValues, a.calculateMean(podValues)),
-			DataPoints:  len(podValues),
+			Min:         sketch.Min(),
+			Max:         sketch.Max(),
+			Mean:        moments.Mean(),
+			P50:         sketch.Quantile(0.50),
+			P95:         sketch.Quantile(0.95),
+			P99:         sketch.Quantile(0.99),
+			StandardDev: moments.StdDev(),
+			DataPoints:  sketch.Count(),
 		}
 
 		podAnalyses = append(podAnalyses, podAnalysis)
 	}
 
-	if len(allValues) == 0 {
+	if workloadSketch.Count() == 0 {
 		return nil, fmt.Errorf("no %s data available", resourceType)
 	}
 
-	sort.Float64s(allValues)
-
 	return &ResourceAnalysis{
 		ResourceType:    resourceType,
 		Unit:            unit,
-		TotalDataPoints: len(allValues),
-		WorkloadMin:     allValues[0],
-		WorkloadMax:     allValues[len(allValues)-1],
-		WorkloadMean:    a.calculateMean(allValues),
-		WorkloadP50:     a.calculatePercentile(allValues, 50),
-		WorkloadP95:     a.calculatePercentile(allValues, 95),
-		WorkloadP99:     a.calculatePercentile(allValues, 99),
-		WorkloadStdDev:  a.calculateStandardDeviation(allValues, a.calculateMean(allValues)),
+		TotalDataPoints: workloadSketch.Count(),
+		WorkloadMin:     workloadSketch.Min(),
+		WorkloadMax:     workloadSketch.Max(),
+		WorkloadMean:    workloadMoments.Mean(),
+		WorkloadP50:     workloadSketch.Quantile(0.50),
+		WorkloadP95:     workloadSketch.Quantile(0.95),
+		WorkloadP99:     workloadSketch.Quantile(0.99),
+		WorkloadStdDev:  workloadMoments.StdDev(),
 		PodAnalyses:     podAnalyses,
 	}, nil
 }
@@ -575,9 +841,6 @@ func (a *AdvancedAnalyzer) analyzeMemoryPatterns(pods []metrics.PodMetrics) (*Re
 func (a *AdvancedAnalyzer) detectUsagePatterns(pods []metrics.PodMetrics) []UsagePattern {
 	var patterns []UsagePattern
 
-	// Detect if there are clear daily/weekly patterns
-	// This is a simplified implementation - production would use more sophisticated time series analysis
-
 	for _, pod := range pods {
 		// Analyze CPU patterns
 		if len(pod.CPUUsageHistory) > 24 { // Need at least 24 data points
@@ -601,22 +864,18 @@ func (a *AdvancedAnalyzer) detectUsagePatterns(pods []metrics.PodMetrics) []Usag
 	return patterns
 }
 
-// analyzeTimeSeries performs basic time series analysis to detect patterns
+// analyzeTimeSeries describes the variability and spikes of a usage history and detects daily and
+// weekly cycles by autocorrelation
 func (a *AdvancedAnalyzer) analyzeTimeSeries(usage []metrics.ResourceUsage, resourceType string) *UsagePattern {
 	if len(usage) < 24 {
 		return nil
 	}
 
-	// Simple pattern detection - look for cyclical behavior
-	// In production, you'd use more sophisticated algorithms like FFT, autocorrelation, etc.
-
-	values := make([]float64, len(usage))
-	for i, u := range usage {
-		values[i] = u.Value
+	var moments stats.Moments
+	for _, u := range usage {
+		moments.Add(u.Value)
 	}
-
-	mean := a.calculateMean(values)
-	stdDev := a.calculateStandardDeviation(values, mean)
+	mean, stdDev := moments.Mean(), moments.StdDev()
 
 	// Detect if usage varies significantly (indicating a pattern)
 	cv := stdDev / mean
@@ -630,25 +889,33 @@ func (a *AdvancedAnalyzer) analyzeTimeSeries(usage []metrics.ResourceUsage, reso
 
 	// Detect if there are obvious spikes
 	spikes := 0
-	for _, v := range values {
-		if v > mean+2*stdDev {
+	for _, u := range usage {
+		if u.Value > mean+2*stdDev {
 			spikes++
 		}
 	}
 
 	spikePattern := "none"
-	if spikes > len(values)/10 {
+	if spikes > len(usage)/10 {
 		spikePattern = "frequent"
 	} else if spikes > 0 {
 		spikePattern = "occasional"
 	}
 
+	cycles := detectCycles(usage, defaultSeasonalityThreshold)
+
+	description := fmt.Sprintf("%s usage shows %s pattern with %s spikes", resourceType, patternType, spikePattern)
+	for _, cycle := range cycles {
+		description += fmt.Sprintf(" and a %s", cycle)
+	}
+
 	return &UsagePattern{
 		ResourceType: resourceType,
 		PatternType:  patternType,
 		SpikePattern: spikePattern,
-		Confidence:   a.calculateConfidence(values),
-		Description:  fmt.Sprintf("%s usage shows %s pattern with %s spikes", resourceType, patternType, spikePattern),
+		Cycles:       cycles,
+		Confidence:   a.momentsConfidence(moments),
+		Description:  description,
 	}
 }
 
@@ -694,6 +961,21 @@ func (a *AdvancedAnalyzer) generateWorkloadRecommendations(analysis *WorkloadAna
 
 	// Pattern-based recommendations
 	for _, pattern := range analysis.UsagePatterns {
+		if len(pattern.Cycles) > 0 {
+			rec := WorkloadRecommendation{
+				Type: "Scheduled Scaling",
+				Description: fmt.Sprintf(
+					"Pod %s shows a %s in %s usage. "+
+						"Consider scheduled scaling around the peaks instead of sizing for them all day",
+					pattern.PodName,
+					pattern.Cycles[0],
+					pattern.ResourceType,
+				),
+				Priority: "Medium",
+				Impact:   "Medium",
+			}
+			recommendations = append(recommendations, rec)
+		}
 		if pattern.PatternType == "variable" {
 			rec := WorkloadRecommendation{
 				Type: "Scaling Strategy",
@@ -770,8 +1052,10 @@ type UsagePattern struct {
 	ResourceType string
 	PatternType  string
 	SpikePattern string
-	Confidence   int
-	Description  string
+	// Cycles holds the daily and weekly cycles detected in the usage, if any
+	Cycles      []Cycle
+	Confidence  int
+	Description string
 }
 
 type WorkloadRecommendation struct {
@@ -2,17 +2,40 @@ package analyzer
 
 import (
 	"context"
+	"math"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 
 	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
 	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/stats"
 )
 
+// usageStart is when the histories of usageHistory begin
+var usageStart = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// usageHistory samples value(i) every step from usageStart for n steps, skipping the steps for which it
+// returns NaN
+func usageHistory(n int, step time.Duration, value func(i int) float64, unit string) []metrics.ResourceUsage {
+	var usage []metrics.ResourceUsage
+	for i := 0; i < n; i++ {
+		if v := value(i); !math.IsNaN(v) {
+			usage = append(usage, metrics.ResourceUsage{Timestamp: usageStart.Add(time.Duration(i) * step), Value: v, Unit: unit})
+		}
+	}
+	return usage
+}
+
+// steady returns the same value for every sample
+func steady(value float64) func(int) float64 {
+	return func(int) float64 { return value }
+}
+
 func TestNewRecommendationEngine(t *testing.T) {
 	engine := NewRecommendationEngine()
 
@@ -102,6 +125,482 @@ func TestGenerateRecommendations_ValidInput(t *testing.T) {
 	assert.LessOrEqual(t, rec.Confidence, 100)
 }
 
+func TestGenerateRecommendations_InitContainers(t *testing.T) {
+	engine := NewRecommendationEngine()
+	ctx := context.Background()
+
+	workloadMetrics := &metrics.WorkloadMetrics{
+		Pods: []metrics.PodMetrics{
+			{
+				PodName:         "test-pod-1",
+				Namespace:       "default",
+				CPUUsageHistory: usageHistory(15, time.Minute, steady(0.5), "cores"),
+				MemUsageHistory: usageHistory(15, time.Minute, steady(512*1024*1024), "bytes"),
+				InitContainers: map[string]metrics.ContainerMetrics{
+					"istio-proxy": {
+						ContainerName:   "istio-proxy",
+						CPUUsageHistory: usageHistory(15, time.Minute, steady(0.05), "cores"),
+						MemUsageHistory: usageHistory(15, time.Minute, steady(64*1024*1024), "bytes"),
+					},
+					// A short-lived init container without enough samples is skipped
+					"migrate": {
+						ContainerName:   "migrate",
+						CPUUsageHistory: usageHistory(2, time.Minute, steady(1), "cores"),
+						MemUsageHistory: usageHistory(2, time.Minute, steady(256*1024*1024), "bytes"),
+					},
+				},
+			},
+		},
+	}
+
+	recommendations, err := engine.GenerateRecommendations(ctx, workloadMetrics, rightsizingv1alpha1.ResourceThresholds{})
+	assert.NoError(t, err)
+	assert.Len(t, recommendations, 1)
+
+	rec := recommendations[0]
+	assert.Len(t, rec.InitContainers, 1)
+	sidecar := rec.InitContainers[0]
+	assert.Equal(t, "istio-proxy", sidecar.Name)
+
+	// The sidecar is sized from its own series, not the pod's
+	sidecarCPU := sidecar.RecommendedResources.Limits[corev1.ResourceCPU]
+	podCPU := rec.RecommendedResources.Limits[corev1.ResourceCPU]
+	assert.InDelta(t, 0.06, sidecarCPU.AsApproximateFloat64(), 0.001)
+	assert.InDelta(t, 0.6, podCPU.AsApproximateFloat64(), 0.001)
+}
+
+func TestGenerateRecommendations_Containers(t *testing.T) {
+	engine := NewRecommendationEngine()
+	ctx := context.Background()
+
+	// The proxy was OOM-killed at its own 64Mi limit, not the app's
+	podMetrics := metrics.PodMetrics{
+		PodName:         "test-pod-1",
+		Namespace:       "default",
+		CPUUsageHistory: usageHistory(15, time.Minute, steady(0.55), "cores"),
+		MemUsageHistory: usageHistory(15, time.Minute, steady(460*1024*1024), "bytes"),
+		Containers: map[string]metrics.ContainerMetrics{
+			"app": {
+				ContainerName:   "app",
+				CPUUsageHistory: usageHistory(15, time.Minute, steady(0.5), "cores"),
+				MemUsageHistory: usageHistory(15, time.Minute, steady(400*1024*1024), "bytes"),
+			},
+			"proxy": {
+				ContainerName:   "proxy",
+				CPUUsageHistory: usageHistory(15, time.Minute, steady(0.05), "cores"),
+				MemUsageHistory: usageHistory(15, time.Minute, steady(60*1024*1024), "bytes"),
+			},
+		},
+		OOMKills: []metrics.OOMKill{
+			{Time: usageStart.Add(5 * time.Minute), Container: "proxy", MemoryLimit: 64 * 1024 * 1024},
+		},
+	}
+	workloadMetrics := &metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{podMetrics}}
+
+	recommendations, err := engine.GenerateRecommendations(ctx, workloadMetrics, rightsizingv1alpha1.ResourceThresholds{})
+	require.NoError(t, err)
+	require.Len(t, recommendations, 1)
+	rec := recommendations[0]
+	require.Len(t, rec.Containers, 2)
+
+	// Each container is sized from its own series instead of the pod total
+	app, proxy := rec.Containers[0], rec.Containers[1]
+	assert.Equal(t, "app", app.Name)
+	appCPU := app.RecommendedResources.Limits[corev1.ResourceCPU]
+	podCPU := rec.RecommendedResources.Limits[corev1.ResourceCPU]
+	assert.InDelta(t, 0.6, appCPU.AsApproximateFloat64(), 0.001)
+	assert.InDelta(t, 0.66, podCPU.AsApproximateFloat64(), 0.001)
+	assert.Nil(t, app.LimitFloors)
+
+	assert.Equal(t, "proxy", proxy.Name)
+	proxyCPU := proxy.RecommendedResources.Limits[corev1.ResourceCPU]
+	assert.InDelta(t, 0.06, proxyCPU.AsApproximateFloat64(), 0.001)
+	floor := proxy.LimitFloors[corev1.ResourceMemory]
+	assert.Equal(t, int64(64*1024*1024*12/10), floor.Value())
+	assert.Contains(t, proxy.Reason, "kept above the 64Mi limit after 1 OOM kills")
+
+	// Pods with a single regular container are sized from the pod total alone
+	podMetrics.Containers = map[string]metrics.ContainerMetrics{"app": podMetrics.Containers["app"]}
+	recommendations, err = engine.GenerateRecommendations(ctx,
+		&metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{podMetrics}}, rightsizingv1alpha1.ResourceThresholds{})
+	require.NoError(t, err)
+	require.Len(t, recommendations, 1)
+	assert.Empty(t, recommendations[0].Containers)
+}
+
+func TestGenerateRecommendations_ContainerThrottling(t *testing.T) {
+	engine := NewRecommendationEngine()
+	ctx := context.Background()
+
+	// The app is throttled in 40% of CFS periods, the proxy never
+	podMetrics := metrics.PodMetrics{
+		PodName:              "test-pod-1",
+		Namespace:            "default",
+		CPUUsageHistory:      usageHistory(15, time.Minute, steady(0.55), "cores"),
+		MemUsageHistory:      usageHistory(15, time.Minute, steady(460*1024*1024), "bytes"),
+		CPUThrottlingHistory: usageHistory(15, time.Minute, steady(0.4), "ratio"),
+		Containers: map[string]metrics.ContainerMetrics{
+			"app": {
+				ContainerName:        "app",
+				CPUUsageHistory:      usageHistory(15, time.Minute, steady(0.5), "cores"),
+				MemUsageHistory:      usageHistory(15, time.Minute, steady(400*1024*1024), "bytes"),
+				CPUThrottlingHistory: usageHistory(15, time.Minute, steady(0.4), "ratio"),
+			},
+			"proxy": {
+				ContainerName:        "proxy",
+				CPUUsageHistory:      usageHistory(15, time.Minute, steady(0.05), "cores"),
+				MemUsageHistory:      usageHistory(15, time.Minute, steady(60*1024*1024), "bytes"),
+				CPUThrottlingHistory: usageHistory(15, time.Minute, steady(0), "ratio"),
+			},
+		},
+	}
+
+	recommendations, err := engine.GenerateRecommendations(ctx,
+		&metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{podMetrics}}, rightsizingv1alpha1.ResourceThresholds{})
+	require.NoError(t, err)
+	require.Len(t, recommendations, 1)
+	require.Len(t, recommendations[0].Containers, 2)
+
+	// Only the throttled container's CPU limit is raised, by the throttled ratio
+	app, proxy := recommendations[0].Containers[0], recommendations[0].Containers[1]
+	appCPU := app.RecommendedResources.Limits[corev1.ResourceCPU]
+	assert.InDelta(t, 0.84, appCPU.AsApproximateFloat64(), 0.001)
+	assert.Contains(t, app.Reason, "limit raised because 40% of CFS periods were throttled")
+
+	proxyCPU := proxy.RecommendedResources.Limits[corev1.ResourceCPU]
+	assert.InDelta(t, 0.06, proxyCPU.AsApproximateFloat64(), 0.001)
+	assert.NotContains(t, proxy.Reason, "throttled")
+}
+
+func TestGenerateRecommendations_EphemeralStorage(t *testing.T) {
+	engine := NewRecommendationEngine()
+	ctx := context.Background()
+
+	podMetrics := metrics.PodMetrics{
+		PodName:             "test-pod-1",
+		Namespace:           "default",
+		CPUUsageHistory:     usageHistory(15, time.Minute, steady(0.5), "cores"),
+		MemUsageHistory:     usageHistory(15, time.Minute, steady(512*1024*1024), "bytes"),
+		StorageUsageHistory: usageHistory(15, time.Minute, steady(1024*1024*1024), "bytes"),
+	}
+	thresholds := rightsizingv1alpha1.ResourceThresholds{
+		MaxEphemeralStorage: resource.MustParse("1Gi"),
+	}
+
+	recommendations, err := engine.GenerateRecommendations(ctx,
+		&metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{podMetrics}}, thresholds)
+	assert.NoError(t, err)
+	assert.Len(t, recommendations, 1)
+
+	// 1Gi + 20% margin is capped at maxEphemeralStorage
+	storageLimit := recommendations[0].RecommendedResources.Limits[corev1.ResourceEphemeralStorage]
+	assert.Equal(t, int64(1024*1024*1024), storageLimit.Value())
+	assert.Contains(t, recommendations[0].Reason, "Ephemeral storage")
+
+	// Without storage samples only CPU and memory are recommended
+	podMetrics.StorageUsageHistory = nil
+	recommendations, err = engine.GenerateRecommendations(ctx,
+		&metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{podMetrics}}, thresholds)
+	assert.NoError(t, err)
+	assert.Len(t, recommendations, 1)
+	assert.NotContains(t, recommendations[0].RecommendedResources.Limits, corev1.ResourceEphemeralStorage)
+}
+
+func TestGenerateRecommendations_LimitPolicy(t *testing.T) {
+	engine := NewRecommendationEngine()
+	ctx := context.Background()
+
+	// 11 evenly spread samples put the 50th percentile at 1.5x and the maximum at 2x the base value
+	spread := func(base float64) func(int) float64 {
+		return func(i int) float64 { return base * (1 + float64(i)/10) }
+	}
+
+	workloadMetrics := &metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{{
+		PodName:         "test-pod-1",
+		Namespace:       "default",
+		CPUUsageHistory: usageHistory(11, time.Minute, spread(1), "cores"),
+		MemUsageHistory: usageHistory(11, time.Minute, spread(100*1024*1024), "bytes"),
+	}}}
+	thresholds := rightsizingv1alpha1.ResourceThresholds{
+		CPUUtilizationPercentile:    50,
+		MemoryUtilizationPercentile: 50,
+	}
+
+	generate := func(policy rightsizingv1alpha1.LimitPolicy) corev1.ResourceRequirements {
+		recommendations, err := engine.GenerateRecommendationsWithOptions(ctx, workloadMetrics, RecommendationOptions{
+			Thresholds:  thresholds,
+			LimitPolicy: policy,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, recommendations, 1)
+		return recommendations[0].RecommendedResources
+	}
+
+	// Requests from the 50th percentile, limits from the maximum, both with the 20% safety margin
+	resources := generate(rightsizingv1alpha1.LimitPolicy{
+		Mode:            rightsizingv1alpha1.LimitModePercentile,
+		LimitPercentile: 100,
+	})
+	cpuRequest := resources.Requests[corev1.ResourceCPU]
+	cpuLimit := resources.Limits[corev1.ResourceCPU]
+	memoryRequest := resources.Requests[corev1.ResourceMemory]
+	memoryLimit := resources.Limits[corev1.ResourceMemory]
+	assert.InDelta(t, 1800, cpuRequest.MilliValue(), 1)
+	assert.InDelta(t, 2400, cpuLimit.MilliValue(), 1)
+	assert.InDelta(t, 180*1024*1024, memoryRequest.Value(), 1)
+	assert.InDelta(t, 240*1024*1024, memoryLimit.Value(), 1)
+
+	// No CPU limit, and the memory limit lowered to the request when the policy is applied
+	policy := rightsizingv1alpha1.LimitPolicy{
+		RemoveCPULimits:          true,
+		MemoryLimitEqualsRequest: true,
+	}
+	resources = generate(policy)
+	assert.NotContains(t, resources.Limits, corev1.ResourceCPU)
+	memoryRequest = resources.Requests[corev1.ResourceMemory]
+	applied := ApplyLimitPolicy(policy, corev1.ResourceRequirements{}, resources, nil)
+	appliedRequest := applied.Requests[corev1.ResourceMemory]
+	appliedLimit := applied.Limits[corev1.ResourceMemory]
+	assert.True(t, appliedRequest.Equal(memoryRequest), "the request is not inflated")
+	assert.True(t, appliedLimit.Equal(memoryRequest))
+
+	// Limits are left to the containers' current resources
+	resources = generate(rightsizingv1alpha1.LimitPolicy{Mode: rightsizingv1alpha1.LimitModeRequestsOnly})
+	assert.Empty(t, resources.Limits)
+	assert.Contains(t, resources.Requests, corev1.ResourceCPU)
+}
+
+func TestGenerateRecommendations_StartupExclusion(t *testing.T) {
+	engine := NewRecommendationEngine()
+	ctx := context.Background()
+
+	// One sample per minute for an hour, with a restart 30 minutes in and warm-up spikes for 5 minutes after each start
+	restart := usageStart.Add(30 * time.Minute)
+	warmUp := func(value, spike float64) func(int) float64 {
+		return func(i int) float64 {
+			if i < 5 || (i >= 30 && i < 35) {
+				return spike
+			}
+			return value
+		}
+	}
+
+	podMetrics := metrics.PodMetrics{
+		PodName:         "test-pod-1",
+		Namespace:       "default",
+		CPUUsageHistory: usageHistory(60, time.Minute, warmUp(0.5, 2), "cores"),
+		MemUsageHistory: usageHistory(60, time.Minute, warmUp(512*1024*1024, 1024*1024*1024), "bytes"),
+		ContainerStarts: []metrics.ContainerStart{{Time: usageStart, Container: "app"}, {Time: restart, Container: "app"}},
+	}
+	thresholds := rightsizingv1alpha1.ResourceThresholds{StartupExclusion: "5m"}
+
+	recommendations, err := engine.GenerateRecommendations(ctx,
+		&metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{podMetrics}}, thresholds)
+	assert.NoError(t, err)
+	assert.Len(t, recommendations, 1)
+	rec := recommendations[0]
+
+	// Steady state only: 500m + 20% margin
+	cpuLimit := rec.RecommendedResources.Limits[corev1.ResourceCPU]
+	assert.Equal(t, int64(600), cpuLimit.MilliValue())
+
+	cpuPeak := rec.StartupPeak[corev1.ResourceCPU]
+	memoryPeak := rec.StartupPeak[corev1.ResourceMemory]
+	assert.Equal(t, int64(2000), cpuPeak.MilliValue())
+	assert.Equal(t, int64(1024*1024*1024), memoryPeak.Value())
+	assert.Contains(t, rec.Reason, "Excluded 20 samples within 5m of 2 container starts")
+
+	// An invalid window is reported instead of silently ignored
+	thresholds.StartupExclusion = "soon"
+	_, err = engine.GenerateRecommendations(ctx,
+		&metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{podMetrics}}, thresholds)
+	assert.Error(t, err)
+}
+
+func TestGenerateRecommendations_OOMKills(t *testing.T) {
+	engine := NewRecommendationEngine()
+	ctx := context.Background()
+
+	// Working set sits just below the 512Mi limit the container was killed at
+	podMetrics := metrics.PodMetrics{
+		PodName:         "test-pod-1",
+		Namespace:       "default",
+		CPUUsageHistory: usageHistory(15, time.Minute, steady(0.5), "cores"),
+		MemUsageHistory: usageHistory(15, time.Minute, steady(400*1024*1024), "bytes"),
+		OOMKills: []metrics.OOMKill{
+			{Time: usageStart.Add(5 * time.Minute), MemoryLimit: 512 * 1024 * 1024},
+		},
+	}
+	workloadMetrics := &metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{podMetrics}}
+
+	recommendations, err := engine.GenerateRecommendations(ctx, workloadMetrics, rightsizingv1alpha1.ResourceThresholds{})
+	assert.NoError(t, err)
+	assert.Len(t, recommendations, 1)
+	rec := recommendations[0]
+
+	// 512Mi + 20% margin instead of 400Mi + 20%
+	memoryLimit := rec.RecommendedResources.Limits[corev1.ResourceMemory]
+	memoryRequest := rec.RecommendedResources.Requests[corev1.ResourceMemory]
+	assert.Equal(t, int64(512*1024*1024*12/10), memoryLimit.Value())
+	assert.Greater(t, memoryRequest.Value(), int64(512*1024*1024))
+	assert.Equal(t, 100-engine.OOMConfidencePenalty, confidenceScore(rec, rightsizingv1alpha1.ConfidenceFactorRestarts))
+	assert.Contains(t, rec.Reason, "kept above the 512Mi limit after 1 OOM kills")
+
+	// A penalty below the confidence threshold still produces the increase
+	engine.OOMConfidencePenalty = 50
+	recommendations, err = engine.GenerateRecommendations(ctx, workloadMetrics, rightsizingv1alpha1.ResourceThresholds{})
+	assert.NoError(t, err)
+	assert.Len(t, recommendations, 1)
+	assert.Less(t, recommendations[0].Confidence, engine.DefaultConfidenceThreshold)
+	assert.Equal(t, 50, confidenceScore(recommendations[0], rightsizingv1alpha1.ConfidenceFactorRestarts))
+
+	// The floor survives limit policies that keep the current limits
+	current := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+		Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+	}
+	recommendations, err = engine.GenerateRecommendationsWithOptions(ctx, workloadMetrics, RecommendationOptions{
+		LimitPolicy: rightsizingv1alpha1.LimitPolicy{Mode: rightsizingv1alpha1.LimitModeRequestsOnly},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, recommendations, 1)
+	floor := recommendations[0].LimitFloors[corev1.ResourceMemory]
+	assert.Equal(t, int64(512*1024*1024*12/10), floor.Value())
+	applied := ApplyLimitPolicy(rightsizingv1alpha1.LimitPolicy{Mode: rightsizingv1alpha1.LimitModeRequestsOnly},
+		current, recommendations[0].RecommendedResources, recommendations[0].LimitFloors)
+	appliedLimit := applied.Limits[corev1.ResourceMemory]
+	assert.Zero(t, floor.Cmp(appliedLimit), "limit %s", appliedLimit.String())
+}
+
+func TestGenerateRecommendations_OOMKillsAboveMaxMemory(t *testing.T) {
+	engine := NewRecommendationEngine()
+	ctx := context.Background()
+
+	podMetrics := metrics.PodMetrics{
+		PodName:         "test-pod-1",
+		Namespace:       "default",
+		CPUUsageHistory: usageHistory(15, time.Minute, steady(0.5), "cores"),
+		MemUsageHistory: usageHistory(15, time.Minute, steady(400*1024*1024), "bytes"),
+		OOMKills: []metrics.OOMKill{
+			{Time: usageStart.Add(5 * time.Minute), MemoryLimit: 512 * 1024 * 1024},
+		},
+	}
+	workloadMetrics := &metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{podMetrics}}
+
+	tests := []struct {
+		name      string
+		maxMemory string
+		wantLimit int64
+		wantNote  string
+	}{
+		{
+			name:      "below the limit the containers were killed at keeps that limit",
+			maxMemory: "384Mi",
+			wantLimit: 512 * 1024 * 1024,
+			wantNote:  "left at the 512Mi limit after 1 OOM kills since maxMemory 384Mi is below it",
+		},
+		{
+			name:      "between the limit and the floor caps the increase",
+			maxMemory: "576Mi",
+			wantLimit: 576 * 1024 * 1024,
+			wantNote:  "kept above the 512Mi limit after 1 OOM kills",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thresholds := rightsizingv1alpha1.ResourceThresholds{MaxMemory: resource.MustParse(tt.maxMemory)}
+			recommendations, err := engine.GenerateRecommendations(ctx, workloadMetrics, thresholds)
+			assert.NoError(t, err)
+			assert.Len(t, recommendations, 1)
+
+			limit := recommendations[0].RecommendedResources.Limits[corev1.ResourceMemory]
+			request := recommendations[0].RecommendedResources.Requests[corev1.ResourceMemory]
+			assert.Equal(t, tt.wantLimit, limit.Value())
+			assert.LessOrEqual(t, request.Value(), limit.Value())
+			assert.Contains(t, recommendations[0].Reason, tt.wantNote)
+		})
+	}
+}
+
+func TestGenerateRecommendations_CPUThrottling(t *testing.T) {
+	engine := NewRecommendationEngine()
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		throttling    float64
+		thresholds    rightsizingv1alpha1.ResourceThresholds
+		expectedLimit int64 // millicores
+		throttled     bool
+	}{
+		{
+			name:          "below default threshold",
+			throttling:    0.05,
+			expectedLimit: 600,
+		},
+		{
+			name:          "above default threshold",
+			throttling:    0.5,
+			expectedLimit: 900,
+			throttled:     true,
+		},
+		{
+			name:          "below configured threshold",
+			throttling:    0.5,
+			thresholds:    rightsizingv1alpha1.ResourceThresholds{CPUThrottlingThreshold: 60},
+			expectedLimit: 600,
+		},
+		{
+			name:          "capped by max CPU",
+			throttling:    0.5,
+			thresholds:    rightsizingv1alpha1.ResourceThresholds{MaxCPU: resource.MustParse("700m")},
+			expectedLimit: 700,
+			throttled:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workloadMetrics := &metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{{
+				PodName:              "test-pod-1",
+				Namespace:            "default",
+				CPUUsageHistory:      usageHistory(15, time.Minute, steady(0.5), "cores"),
+				MemUsageHistory:      usageHistory(15, time.Minute, steady(256*1024*1024), "bytes"),
+				CPUThrottlingHistory: usageHistory(15, time.Minute, steady(tt.throttling), "ratio"),
+			}}}
+
+			recommendations, err := engine.GenerateRecommendations(ctx, workloadMetrics, tt.thresholds)
+			assert.NoError(t, err)
+			assert.Len(t, recommendations, 1)
+
+			cpuLimit := recommendations[0].RecommendedResources.Limits[corev1.ResourceCPU]
+			assert.Equal(t, tt.expectedLimit, cpuLimit.MilliValue())
+			if tt.throttled {
+				assert.Contains(t, recommendations[0].Reason, "of CFS periods were throttled")
+			} else {
+				assert.NotContains(t, recommendations[0].Reason, "throttled")
+			}
+		})
+	}
+}
+
+func TestCalculateSavings_EphemeralStorage(t *testing.T) {
+	calculator := NewCostCalculator()
+
+	savings := calculator.CalculateSavings(
+		corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			corev1.ResourceEphemeralStorage: resource.MustParse("12Gi"),
+		}},
+		corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			corev1.ResourceEphemeralStorage: resource.MustParse("2Gi"),
+		}},
+	)
+
+	assert.NotNil(t, savings.EphemeralStorageSavings)
+	assert.Equal(t, int64(10*1024*1024*1024), savings.EphemeralStorageSavings.Value())
+	assert.Equal(t, "$0.75/month", savings.CostSavings)
+}
+
 func TestAnalyzeCPUUsage(t *testing.T) {
 	engine := NewRecommendationEngine()
 
@@ -120,7 +619,7 @@ func TestAnalyzeCPUUsage(t *testing.T) {
 		MaxCPU: resource.MustParse("1"),
 	}
 
-	recommendation, confidence, err := engine.analyzeCPUUsage(usage, thresholds)
+	recommendation, confidence, err := engine.analyzeUsage(&percentileRecommender{engine: engine}, corev1.ResourceCPU, usage, thresholds, 0)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, recommendation)
@@ -154,7 +653,7 @@ func TestAnalyzeMemoryUsage(t *testing.T) {
 		MaxMemory: resource.MustParse("2Gi"),
 	}
 
-	recommendation, confidence, err := engine.analyzeMemoryUsage(usage, thresholds)
+	recommendation, confidence, err := engine.analyzeUsage(&percentileRecommender{engine: engine}, corev1.ResourceMemory, usage, thresholds, 0)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, recommendation)
@@ -185,7 +684,7 @@ func TestAnalyzeCPUUsage_InsufficientData(t *testing.T) {
 
 	thresholds := rightsizingv1alpha1.ResourceThresholds{}
 
-	recommendation, confidence, err := engine.analyzeCPUUsage(usage, thresholds)
+	recommendation, confidence, err := engine.analyzeUsage(&percentileRecommender{engine: engine}, corev1.ResourceCPU, usage, thresholds, 0)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "insufficient CPU data points")
@@ -208,7 +707,7 @@ func TestAnalyzeMemoryUsage_InsufficientData(t *testing.T) {
 
 	thresholds := rightsizingv1alpha1.ResourceThresholds{}
 
-	recommendation, confidence, err := engine.analyzeMemoryUsage(usage, thresholds)
+	recommendation, confidence, err := engine.analyzeUsage(&percentileRecommender{engine: engine}, corev1.ResourceMemory, usage, thresholds, 0)
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "insufficient memory data points")
@@ -216,40 +715,26 @@ func TestAnalyzeMemoryUsage_InsufficientData(t *testing.T) {
 	assert.Equal(t, 0, confidence)
 }
 
-func TestCalculatePercentile(t *testing.T) {
-	engine := NewRecommendationEngine()
-
-	values := []float64{1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0, 9.0, 10.0}
-
-	// Test 50th percentile (median)
-	p50 := engine.calculatePercentile(values, 50)
-	assert.InDelta(t, 5.5, p50, 0.01)
-
-	// Test 90th percentile
-	p90 := engine.calculatePercentile(values, 90)
-	assert.InDelta(t, 9.1, p90, 0.01)
-
-	// Test 95th percentile
-	p95 := engine.calculatePercentile(values, 95)
-	assert.InDelta(t, 9.55, p95, 0.01)
-}
-
-func TestCalculateConfidence(t *testing.T) {
+func TestMomentsConfidence(t *testing.T) {
 	engine := NewRecommendationEngine()
+	confidence := func(values ...float64) int {
+		var moments stats.Moments
+		for _, value := range values {
+			moments.Add(value)
+		}
+		return engine.momentsConfidence(moments)
+	}
 
 	// Test with consistent values (low variance, high confidence)
-	consistentValues := []float64{0.5, 0.51, 0.49, 0.52, 0.48, 0.50, 0.51, 0.49, 0.50, 0.51}
-	highConfidence := engine.calculateConfidence(consistentValues)
+	highConfidence := confidence(0.5, 0.51, 0.49, 0.52, 0.48, 0.50, 0.51, 0.49, 0.50, 0.51)
 	assert.Greater(t, highConfidence, 70)
 
 	// Test with highly variable values (high variance, low confidence)
-	variableValues := []float64{0.1, 0.9, 0.2, 0.8, 0.3, 0.7, 0.4, 0.6, 0.5, 1.0}
-	lowConfidence := engine.calculateConfidence(variableValues)
+	lowConfidence := confidence(0.1, 0.9, 0.2, 0.8, 0.3, 0.7, 0.4, 0.6, 0.5, 1.0)
 	assert.Less(t, lowConfidence, 70)
 
 	// Test with minimal data points
-	minimalValues := []float64{0.5, 0.6, 0.4}
-	minimalConfidence := engine.calculateConfidence(minimalValues)
+	minimalConfidence := confidence(0.5, 0.6, 0.4)
 	assert.GreaterOrEqual(t, minimalConfidence, 0)
 	assert.LessOrEqual(t, minimalConfidence, 100)
 }
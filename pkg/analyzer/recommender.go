@@ -0,0 +1,190 @@
+// pkg/analyzer/recommender.go
+package analyzer
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/stats"
+)
+
+// Built-in recommender names
+const (
+	PercentileRecommender = "Percentile"
+	PeakRecommender       = "Peak"
+)
+
+// RecommenderInput is the usage history of one resource handed to a recommender
+type RecommenderInput struct {
+	Resource corev1.ResourceName
+	// History holds the usage samples in ascending time order, in cores for CPU and bytes otherwise
+	History []metrics.ResourceUsage
+	// Percentile is the utilization percentile configured for the resource
+	Percentile int
+	// Horizon is how far ahead the estimate must hold, zero when the caller did not set one
+	Horizon time.Duration
+}
+
+// UsageEstimate is the usage a recommender sizes a resource for. The engine adds the safety margin
+// and applies the min/max constraints.
+type UsageEstimate struct {
+	// Value is the estimated usage in the unit of the history
+	Value float64
+	// LowerBound and UpperBound bracket Value, zero when the recommender does not produce bounds
+	LowerBound float64
+	UpperBound float64
+	// Confidence is the confidence in the estimate, from 0 to 100
+	Confidence int
+	// Reason describes how the estimate was made, e.g. "Based on 95th percentile of 144 data points"
+	Reason string
+}
+
+// Recommender turns the usage history of a resource into the usage it should be sized for
+type Recommender interface {
+	Estimate(input RecommenderInput) (UsageEstimate, error)
+}
+
+// RecommenderFactory creates a recommender from the parameters in spec.recommender. The engine gives
+// access to its shared statistics helpers and settings.
+type RecommenderFactory func(engine *RecommendationEngine, parameters map[string]string) (Recommender, error)
+
+var (
+	recommendersMu sync.RWMutex
+	recommenders   = map[string]RecommenderFactory{}
+)
+
+func init() {
+	RegisterRecommender(PercentileRecommender, newPercentileRecommender)
+	RegisterRecommender(PeakRecommender, newPeakRecommender)
+	RegisterRecommender(DecayingHistogramRecommender, newHistogramRecommender)
+	RegisterRecommender(ForecastRecommender, newForecastRecommender)
+}
+
+// RegisterRecommender makes a recommender available to spec.recommender under name. It panics if
+// the name is already registered.
+func RegisterRecommender(name string, factory RecommenderFactory) {
+	recommendersMu.Lock()
+	defer recommendersMu.Unlock()
+
+	if _, exists := recommenders[name]; exists {
+		panic(fmt.Sprintf("recommender %q is already registered", name))
+	}
+	recommenders[name] = factory
+}
+
+// RecommenderNames returns the names of all registered recommenders in sorted order
+func RecommenderNames() []string {
+	recommendersMu.RLock()
+	defer recommendersMu.RUnlock()
+
+	names := make([]string, 0, len(recommenders))
+	for name := range recommenders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewRecommender creates the recommender selected by spec, the percentile recommender when no name is set
+func (r *RecommendationEngine) NewRecommender(spec rightsizingv1alpha1.RecommenderSpec) (Recommender, error) {
+	name := spec.Name
+	if name == "" {
+		name = PercentileRecommender
+	}
+
+	recommendersMu.RLock()
+	factory, exists := recommenders[name]
+	recommendersMu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("unknown recommender %q, registered recommenders: %s",
+			name, strings.Join(RecommenderNames(), ", "))
+	}
+
+	recommender, err := factory(r, spec.Parameters)
+	if err != nil {
+		return nil, fmt.Errorf("invalid parameters for recommender %q: %w", name, err)
+	}
+	return recommender, nil
+}
+
+// checkParameters returns an error for parameters a recommender does not accept
+func checkParameters(parameters map[string]string, accepted ...string) error {
+	var unknown []string
+	for key := range parameters {
+		if !slices.Contains(accepted, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown parameters: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// usageStats summarizes the sample values of a history in a quantile sketch and their moments, so
+// long histories are not sorted
+func usageStats(history []metrics.ResourceUsage) (*stats.Sketch, stats.Moments) {
+	sketch := stats.NewSketch()
+	var moments stats.Moments
+	for _, usage := range history {
+		sketch.Add(usage.Value)
+		moments.Add(usage.Value)
+	}
+	return sketch, moments
+}
+
+// percentileRecommender sizes for the configured utilization percentile of all samples
+type percentileRecommender struct {
+	engine *RecommendationEngine
+}
+
+func newPercentileRecommender(engine *RecommendationEngine, parameters map[string]string) (Recommender, error) {
+	if err := checkParameters(parameters); err != nil {
+		return nil, err
+	}
+	return &percentileRecommender{engine: engine}, nil
+}
+
+// Estimate returns the utilization percentile of the history
+func (p *percentileRecommender) Estimate(input RecommenderInput) (UsageEstimate, error) {
+	sketch, moments := usageStats(input.History)
+	return UsageEstimate{
+		Value:      sketch.Quantile(float64(input.Percentile) / 100),
+		Confidence: p.engine.momentsConfidence(moments),
+		Reason:     fmt.Sprintf("Based on %dth percentile of %d data points", input.Percentile, sketch.Count()),
+	}, nil
+}
+
+// peakRecommender sizes for the highest observed usage, for workloads that must never be constrained
+type peakRecommender struct {
+	engine *RecommendationEngine
+}
+
+func newPeakRecommender(engine *RecommendationEngine, parameters map[string]string) (Recommender, error) {
+	if err := checkParameters(parameters); err != nil {
+		return nil, err
+	}
+	return &peakRecommender{engine: engine}, nil
+}
+
+// Estimate returns the maximum of the history
+func (p *peakRecommender) Estimate(input RecommenderInput) (UsageEstimate, error) {
+	if len(input.History) == 0 {
+		return UsageEstimate{}, fmt.Errorf("no %s data points", input.Resource)
+	}
+	sketch, moments := usageStats(input.History)
+	return UsageEstimate{
+		Value:      sketch.Max(),
+		Confidence: p.engine.momentsConfidence(moments),
+		Reason:     fmt.Sprintf("Based on peak of %d data points", sketch.Count()),
+	}, nil
+}
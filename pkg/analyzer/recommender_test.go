@@ -0,0 +1,125 @@
+package analyzer
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+// fixedRecommender always estimates the same usage
+type fixedRecommender struct {
+	value float64
+}
+
+func (f *fixedRecommender) Estimate(input RecommenderInput) (UsageEstimate, error) {
+	return UsageEstimate{Value: f.value, Confidence: 90, Reason: "Fixed " + string(input.Resource)}, nil
+}
+
+func TestNewRecommender(t *testing.T) {
+	engine := NewRecommendationEngine()
+
+	recommender, err := engine.NewRecommender(rightsizingv1alpha1.RecommenderSpec{})
+	require.NoError(t, err)
+	assert.IsType(t, &percentileRecommender{}, recommender)
+
+	recommender, err = engine.NewRecommender(rightsizingv1alpha1.RecommenderSpec{Name: PeakRecommender})
+	require.NoError(t, err)
+	assert.IsType(t, &peakRecommender{}, recommender)
+
+	_, err = engine.NewRecommender(rightsizingv1alpha1.RecommenderSpec{Name: "Magic"})
+	assert.ErrorContains(t, err, `unknown recommender "Magic"`)
+
+	_, err = engine.NewRecommender(rightsizingv1alpha1.RecommenderSpec{
+		Name:       PeakRecommender,
+		Parameters: map[string]string{"window": "1d"},
+	})
+	assert.ErrorContains(t, err, "unknown parameters: window")
+
+	assert.Panics(t, func() { RegisterRecommender(PercentileRecommender, newPercentileRecommender) })
+}
+
+func TestGenerateRecommendations_Recommender(t *testing.T) {
+	engine := NewRecommendationEngine()
+	ctx := context.Background()
+
+	// Steady usage with one spike: the percentile ignores it, the peak sizes for it
+	spike := func(i int) float64 {
+		if i == 10 {
+			return 0.6
+		}
+		return 0.5
+	}
+	workloadMetrics := &metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{{
+		PodName:         "test-pod-1",
+		Namespace:       "default",
+		CPUUsageHistory: usageHistory(40, time.Minute, spike, "cores"),
+		MemUsageHistory: usageHistory(40, time.Minute, steady(256*1024*1024), "bytes"),
+	}}}
+
+	cpuLimit := func(spec rightsizingv1alpha1.RecommenderSpec) (int64, string) {
+		recommendations, err := engine.GenerateRecommendationsWithOptions(ctx, workloadMetrics, RecommendationOptions{
+			Recommender: spec,
+		})
+		require.NoError(t, err)
+		require.Len(t, recommendations, 1)
+		limit := recommendations[0].RecommendedResources.Limits[corev1.ResourceCPU]
+		return limit.MilliValue(), recommendations[0].Reason
+	}
+
+	limit, reason := cpuLimit(rightsizingv1alpha1.RecommenderSpec{})
+	assert.Equal(t, int64(600), limit)
+	assert.Contains(t, reason, "Based on 95th percentile of 40 data points")
+
+	limit, reason = cpuLimit(rightsizingv1alpha1.RecommenderSpec{Name: PeakRecommender})
+	assert.Equal(t, int64(720), limit)
+	assert.Contains(t, reason, "Based on peak of 40 data points")
+
+	// Recommenders registered outside the package are selected by name
+	if !slices.Contains(RecommenderNames(), "Fixed") {
+		RegisterRecommender("Fixed", func(*RecommendationEngine, map[string]string) (Recommender, error) {
+			return &fixedRecommender{value: 1}, nil
+		})
+	}
+	limit, reason = cpuLimit(rightsizingv1alpha1.RecommenderSpec{Name: "Fixed"})
+	assert.Equal(t, int64(1200), limit)
+	assert.Contains(t, reason, "CPU: Fixed cpu")
+
+	_, err := engine.GenerateRecommendationsWithOptions(ctx, workloadMetrics, RecommendationOptions{
+		Recommender: rightsizingv1alpha1.RecommenderSpec{Name: "Magic"},
+	})
+	assert.ErrorContains(t, err, "unknown recommender")
+}
+
+func TestPercentileRecommender_LongWindow(t *testing.T) {
+	engine := NewRecommendationEngine()
+	recommender, err := engine.NewRecommender(rightsizingv1alpha1.RecommenderSpec{})
+	require.NoError(t, err)
+
+	// A 30-day window at one-minute steps is estimated from a sketch instead of sorted samples
+	random := rand.New(rand.NewSource(1))
+	history := usageHistory(30*24*60, time.Minute, func(int) float64 {
+		return 0.5 * math.Exp(0.3*random.NormFloat64())
+	}, "cores")
+
+	estimate, err := recommender.Estimate(RecommenderInput{Resource: corev1.ResourceCPU, History: history, Percentile: 95})
+	require.NoError(t, err)
+	values := make([]float64, len(history))
+	for i, usage := range history {
+		values[i] = usage.Value
+	}
+	slices.Sort(values)
+	assert.InEpsilon(t, values[int(0.95*float64(len(values)-1))], estimate.Value, 0.015)
+	_, moments := usageStats(history)
+	assert.Equal(t, engine.momentsConfidence(moments), estimate.Confidence)
+	assert.Contains(t, estimate.Reason, "43200 data points")
+}
@@ -0,0 +1,118 @@
+// pkg/analyzer/runtime_heap.go
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultHeapOverheadPercent is the non-heap memory added on top of a configured heap
+const DefaultHeapOverheadPercent = 25
+
+// Runtimes with a configurable heap
+const (
+	RuntimeJVM  = "JVM"
+	RuntimeNode = "Node.js"
+)
+
+// heapEnvVars are the environment variables read for heap flags, in the order they are applied.
+// Flags on the command line are applied last and win.
+var heapEnvVars = []string{"JAVA_TOOL_OPTIONS", "JAVA_OPTS", "JDK_JAVA_OPTIONS", "NODE_OPTIONS"}
+
+// RuntimeHeap describes the heap configured for a managed runtime in a container
+type RuntimeHeap struct {
+	Runtime string
+	Bytes   int64
+	Flag    string // Flag that configured the heap, e.g. "-Xmx2g"
+}
+
+// DetectRuntimeHeap looks for a JVM (-Xmx, -XX:MaxHeapSize) or Node.js (--max-old-space-size) heap
+// setting in a container's command, args and environment. It returns nil when none is configured.
+// Environment values from ConfigMaps or Secrets are not resolved.
+func DetectRuntimeHeap(container corev1.Container) *RuntimeHeap {
+	var tokens []string
+	for _, name := range heapEnvVars {
+		for _, env := range container.Env {
+			if env.Name == name {
+				tokens = append(tokens, strings.Fields(env.Value)...)
+			}
+		}
+	}
+	for _, arg := range append(append([]string{}, container.Command...), container.Args...) {
+		// Shell entrypoints pass the whole command line as a single argument
+		tokens = append(tokens, strings.Fields(arg)...)
+	}
+
+	var heap *RuntimeHeap
+	for _, token := range tokens {
+		if detected := parseHeapFlag(token); detected != nil {
+			heap = detected
+		}
+	}
+	return heap
+}
+
+// parseHeapFlag parses a single heap flag, returning nil for anything else
+func parseHeapFlag(token string) *RuntimeHeap {
+	switch {
+	case strings.HasPrefix(token, "-Xmx"):
+		if bytes, ok := parseJVMSize(strings.TrimPrefix(token, "-Xmx")); ok {
+			return &RuntimeHeap{Runtime: RuntimeJVM, Bytes: bytes, Flag: token}
+		}
+	case strings.HasPrefix(token, "-XX:MaxHeapSize="):
+		if bytes, ok := parseJVMSize(strings.TrimPrefix(token, "-XX:MaxHeapSize=")); ok {
+			return &RuntimeHeap{Runtime: RuntimeJVM, Bytes: bytes, Flag: token}
+		}
+	case strings.HasPrefix(token, "--max-old-space-size="), strings.HasPrefix(token, "--max_old_space_size="):
+		value := token[len("--max-old-space-size="):]
+		if megabytes, err := strconv.ParseInt(value, 10, 64); err == nil && megabytes > 0 {
+			return &RuntimeHeap{Runtime: RuntimeNode, Bytes: megabytes * 1024 * 1024, Flag: token}
+		}
+	}
+	return nil
+}
+
+// parseJVMSize parses JVM memory sizes such as "512m", "2G" or "1073741824"
+func parseJVMSize(value string) (int64, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	multiplier := int64(1)
+	switch value[len(value)-1] {
+	case 'k', 'K':
+		multiplier = 1024
+	case 'm', 'M':
+		multiplier = 1024 * 1024
+	case 'g', 'G':
+		multiplier = 1024 * 1024 * 1024
+	case 't', 'T':
+		multiplier = 1024 * 1024 * 1024 * 1024
+	}
+	if multiplier > 1 {
+		value = value[:len(value)-1]
+	}
+
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || number <= 0 {
+		return 0, false
+	}
+	return number * multiplier, true
+}
+
+// MemoryFloor returns the heap plus the given overhead percentage
+func (h RuntimeHeap) MemoryFloor(overheadPercent int) int64 {
+	return h.Bytes * int64(100+overheadPercent) / 100
+}
+
+// FlagFor formats the heap flag of the runtime for a heap of the given size, rounded down to MiB
+func (h RuntimeHeap) FlagFor(bytes int64) string {
+	megabytes := bytes / (1024 * 1024)
+	if h.Runtime == RuntimeNode {
+		return fmt.Sprintf("--max-old-space-size=%d", megabytes)
+	}
+	return fmt.Sprintf("-Xmx%dm", megabytes)
+}
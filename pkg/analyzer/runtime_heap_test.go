@@ -0,0 +1,65 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestDetectRuntimeHeap(t *testing.T) {
+	tests := []struct {
+		name      string
+		container corev1.Container
+		want      *RuntimeHeap
+	}{
+		{
+			name:      "no runtime",
+			container: corev1.Container{Args: []string{"--port=8080"}},
+			want:      nil,
+		},
+		{
+			name:      "JVM heap in args",
+			container: corev1.Container{Command: []string{"java"}, Args: []string{"-Xms512m", "-Xmx2g", "-jar", "app.jar"}},
+			want:      &RuntimeHeap{Runtime: RuntimeJVM, Bytes: 2 * 1024 * 1024 * 1024, Flag: "-Xmx2g"},
+		},
+		{
+			name: "command line overrides JAVA_TOOL_OPTIONS",
+			container: corev1.Container{
+				Env:  []corev1.EnvVar{{Name: "JAVA_TOOL_OPTIONS", Value: "-XX:+UseG1GC -Xmx4g"}},
+				Args: []string{"-XX:MaxHeapSize=1024m"},
+			},
+			want: &RuntimeHeap{Runtime: RuntimeJVM, Bytes: 1024 * 1024 * 1024, Flag: "-XX:MaxHeapSize=1024m"},
+		},
+		{
+			name:      "JAVA_OPTS in a shell entrypoint",
+			container: corev1.Container{Command: []string{"sh", "-c", "exec java $JAVA_OPTS -Xmx768M -jar app.jar"}},
+			want:      &RuntimeHeap{Runtime: RuntimeJVM, Bytes: 768 * 1024 * 1024, Flag: "-Xmx768M"},
+		},
+		{
+			name:      "Node.js heap in NODE_OPTIONS",
+			container: corev1.Container{Env: []corev1.EnvVar{{Name: "NODE_OPTIONS", Value: "--max-old-space-size=1536"}}},
+			want:      &RuntimeHeap{Runtime: RuntimeNode, Bytes: 1536 * 1024 * 1024, Flag: "--max-old-space-size=1536"},
+		},
+		{
+			name:      "invalid size",
+			container: corev1.Container{Args: []string{"-Xmxlots"}},
+			want:      nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DetectRuntimeHeap(tt.container))
+		})
+	}
+}
+
+func TestRuntimeHeap_FloorAndFlag(t *testing.T) {
+	heap := RuntimeHeap{Runtime: RuntimeJVM, Bytes: 2 * 1024 * 1024 * 1024, Flag: "-Xmx2g"}
+	assert.Equal(t, int64(2560*1024*1024), heap.MemoryFloor(DefaultHeapOverheadPercent))
+	assert.Equal(t, "-Xmx1536m", heap.FlagFor(1536*1024*1024+12345))
+
+	node := RuntimeHeap{Runtime: RuntimeNode}
+	assert.Equal(t, "--max-old-space-size=512", node.FlagFor(512*1024*1024))
+}
@@ -0,0 +1,262 @@
+// pkg/analyzer/seasonality.go
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+// Cycle periods the seasonality detection looks for
+const (
+	DailyPeriod  = 24 * time.Hour
+	WeeklyPeriod = 7 * 24 * time.Hour
+)
+
+// Seasonality detection constants
+const (
+	// seasonalityStep is the interval series are resampled to before computing autocorrelations
+	seasonalityStep = time.Hour
+	// defaultSeasonalityThreshold is the autocorrelation at the period lag a cycle needs to be reported
+	defaultSeasonalityThreshold = 0.5
+	// minSeasonalityCoverage is the share of resampled intervals that must hold at least one sample
+	minSeasonalityCoverage = 0.5
+	// weeklyStrengthMargin is how much more a weekly cycle must explain than the daily cycle, since
+	// every daily cycle also repeats weekly
+	weeklyStrengthMargin = 0.1
+	// minCycleAmplitude is the standard deviation of the detrended series, relative to its mean, below
+	// which usage is too flat for cycles to matter
+	minCycleAmplitude = 0.05
+	// peakShare is the share of the cycle's range above its minimum that counts as peak usage
+	peakShare = 0.75
+)
+
+// Cycle is a repeating usage pattern found by autocorrelation
+type Cycle struct {
+	Period time.Duration
+	// Strength is the autocorrelation of the detrended series at the period lag, from 0 to 1
+	Strength float64
+	// PeakHours lists the hours of the day (UTC) in which usage peaks
+	PeakHours []int
+	// PeakDays lists the days of the week (UTC) in which usage peaks, for weekly cycles only
+	PeakDays []time.Weekday
+}
+
+// String describes the cycle, e.g. "daily cycle (strength 0.82, peaking 09-16h UTC)"
+func (c Cycle) String() string {
+	name := "daily"
+	if c.Period == WeeklyPeriod {
+		name = "weekly"
+	}
+
+	var peaks []string
+	if len(c.PeakDays) > 0 {
+		days := make([]string, len(c.PeakDays))
+		for i, day := range c.PeakDays {
+			days[i] = day.String()[:3]
+		}
+		peaks = append(peaks, strings.Join(days, ","))
+	}
+	if len(c.PeakHours) > 0 {
+		peaks = append(peaks, formatHours(c.PeakHours)+" UTC")
+	}
+	if len(peaks) == 0 {
+		return fmt.Sprintf("%s cycle (strength %.2f)", name, c.Strength)
+	}
+	return fmt.Sprintf("%s cycle (strength %.2f, peaking %s)", name, c.Strength, strings.Join(peaks, " "))
+}
+
+// detectCycles looks for daily and weekly cycles in a usage history. The history may hold the samples
+// of several pods in any order; they are averaged into hourly intervals and detrended before the
+// autocorrelation at each period lag is computed. A cycle needs at least two full periods of history
+// and an autocorrelation of at least threshold, and usage must vary by at least minCycleAmplitude.
+func detectCycles(history []metrics.ResourceUsage, threshold float64) []Cycle {
+	// The grid starts at the oldest sample, so the first and last intervals hold samples and every gap
+	// can be interpolated
+	series := metrics.Resample(history, time.Time{}, time.Time{}, seasonalityStep, math.MaxInt)
+	if len(series.Values) == 0 || series.Coverage < minSeasonalityCoverage {
+		return nil
+	}
+	values, start := series.Values, series.Start
+	detrended := detrend(values)
+	if !variesEnough(values, detrended) {
+		return nil
+	}
+
+	var cycles []Cycle
+	daily := periodStrength(detrended, DailyPeriod)
+	if daily >= threshold {
+		cycles = append(cycles, Cycle{
+			Period:    DailyPeriod,
+			Strength:  daily,
+			PeakHours: peakHours(values, start),
+		})
+	}
+
+	weekly := periodStrength(detrended, WeeklyPeriod)
+	if weekly >= threshold && weekly >= daily+weeklyStrengthMargin {
+		cycles = append(cycles, Cycle{
+			Period:   WeeklyPeriod,
+			Strength: weekly,
+			PeakDays: peakDays(values, start),
+		})
+	}
+
+	return cycles
+}
+
+// detrend subtracts the least-squares line from a series, so a steady trend does not read as a cycle
+func detrend(values []float64) []float64 {
+	n := float64(len(values))
+	var sumX, sumY, sumXY, sumX2 float64
+	for i, y := range values {
+		x := float64(i)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumX2 += x * x
+	}
+
+	slope := 0.0
+	if denominator := n*sumX2 - sumX*sumX; denominator != 0 {
+		slope = (n*sumXY - sumX*sumY) / denominator
+	}
+	intercept := (sumY - slope*sumX) / n
+
+	residuals := make([]float64, len(values))
+	for i, y := range values {
+		residuals[i] = y - (intercept + slope*float64(i))
+	}
+	return residuals
+}
+
+// variesEnough reports whether the detrended series moves enough relative to the mean usage
+func variesEnough(values, detrended []float64) bool {
+	var mean, variance float64
+	for i := range values {
+		mean += values[i]
+		variance += detrended[i] * detrended[i]
+	}
+	mean /= float64(len(values))
+	variance /= float64(len(values))
+	return mean > 0 && math.Sqrt(variance) >= minCycleAmplitude*mean
+}
+
+// autocorrelation returns the correlation of a zero-mean series with itself shifted by lag intervals
+func autocorrelation(values []float64, lag int) float64 {
+	if lag <= 0 || lag >= len(values) {
+		return 0
+	}
+
+	var variance float64
+	for _, v := range values {
+		variance += v * v
+	}
+	variance /= float64(len(values))
+	if variance == 0 {
+		return 0
+	}
+
+	var covariance float64
+	for i := 0; i+lag < len(values); i++ {
+		covariance += values[i] * values[i+lag]
+	}
+	covariance /= float64(len(values) - lag)
+
+	return covariance / variance
+}
+
+// periodStrength returns the autocorrelation at the period lag, or zero when the series covers less
+// than two periods or does not peak at the period: the correlation at half the period must be lower,
+// otherwise the series merely changes slowly.
+func periodStrength(values []float64, period time.Duration) float64 {
+	lag := int(period / seasonalityStep)
+	if len(values) < 2*lag {
+		return 0
+	}
+
+	strength := autocorrelation(values, lag)
+	if strength <= autocorrelation(values, lag/2) {
+		return 0
+	}
+	return math.Min(math.Max(strength, 0), 1)
+}
+
+// peakHours returns the hours of the day (UTC) whose average usage is in the top of the daily profile
+func peakHours(values []float64, start time.Time) []int {
+	profile := make([]float64, 24)
+	counts := make([]int, 24)
+	for i, v := range values {
+		hour := start.Add(time.Duration(i) * seasonalityStep).UTC().Hour()
+		profile[hour] += v
+		counts[hour]++
+	}
+	return peakIndices(profile, counts)
+}
+
+// peakDays returns the days of the week (UTC) whose average usage is in the top of the weekly profile
+func peakDays(values []float64, start time.Time) []time.Weekday {
+	profile := make([]float64, 7)
+	counts := make([]int, 7)
+	for i, v := range values {
+		day := start.Add(time.Duration(i) * seasonalityStep).UTC().Weekday()
+		profile[day] += v
+		counts[day]++
+	}
+
+	indices := peakIndices(profile, counts)
+	days := make([]time.Weekday, len(indices))
+	for i, index := range indices {
+		days[i] = time.Weekday(index)
+	}
+	return days
+}
+
+// peakIndices averages a profile and returns the indices within peakShare of its maximum
+func peakIndices(profile []float64, counts []int) []int {
+	low, high := math.Inf(1), math.Inf(-1)
+	for i := range profile {
+		if counts[i] == 0 {
+			continue
+		}
+		profile[i] /= float64(counts[i])
+		low = math.Min(low, profile[i])
+		high = math.Max(high, profile[i])
+	}
+	if high <= low {
+		return nil
+	}
+
+	var peaks []int
+	for i, v := range profile {
+		if counts[i] > 0 && v >= low+peakShare*(high-low) {
+			peaks = append(peaks, i)
+		}
+	}
+	return peaks
+}
+
+// formatHours formats sorted hours of the day as ranges, e.g. "09-16h" or "00-02h,22-23h"
+func formatHours(hours []int) string {
+	sorted := append([]int(nil), hours...)
+	sort.Ints(sorted)
+
+	var ranges []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] == sorted[j]+1 {
+			j++
+		}
+		if i == j {
+			ranges = append(ranges, fmt.Sprintf("%02dh", sorted[i]))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%02d-%02dh", sorted[i], sorted[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(ranges, ",")
+}
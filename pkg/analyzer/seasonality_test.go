@@ -0,0 +1,193 @@
+package analyzer
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+// dailyWave peaks at 14:00 UTC with some noise, for samples every step from usageStart
+func dailyWave(random *rand.Rand, step time.Duration) func(i int) float64 {
+	return func(i int) float64 {
+		timestamp := usageStart.Add(time.Duration(i) * step)
+		hours := float64(timestamp.Hour()) + float64(timestamp.Minute())/60
+		return 1 + 0.5*math.Sin(2*math.Pi*(hours-8)/24) + 0.05*random.NormFloat64()
+	}
+}
+
+func TestDetectCycles(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	// A daily wave sampled every five minutes for three days
+	cycles := detectCycles(usageHistory(72*12, 5*time.Minute, dailyWave(random, 5*time.Minute), "cores"),
+		defaultSeasonalityThreshold)
+	require.Len(t, cycles, 1)
+	assert.Equal(t, DailyPeriod, cycles[0].Period)
+	assert.Greater(t, cycles[0].Strength, 0.8)
+	assert.Contains(t, cycles[0].PeakHours, 14)
+	assert.NotContains(t, cycles[0].PeakHours, 2)
+	assert.Contains(t, cycles[0].String(), "daily cycle (strength")
+
+	// Samples every other hour leave gaps that are interpolated
+	cycles = detectCycles(usageHistory(36, 2*time.Hour, dailyWave(random, 2*time.Hour), "cores"),
+		defaultSeasonalityThreshold)
+	require.Len(t, cycles, 1)
+	assert.Equal(t, DailyPeriod, cycles[0].Period)
+
+	// Business hours on weekdays only, for three weeks
+	busy := func(i int) float64 {
+		timestamp := usageStart.Add(time.Duration(i) * 15 * time.Minute)
+		weekend := timestamp.Weekday() == time.Saturday || timestamp.Weekday() == time.Sunday
+		if !weekend && timestamp.Hour() >= 9 && timestamp.Hour() < 17 {
+			return 2 + 0.1*random.NormFloat64()
+		}
+		return 0.5 + 0.1*random.NormFloat64()
+	}
+	cycles = detectCycles(usageHistory(21*24*4, 15*time.Minute, busy, "cores"), defaultSeasonalityThreshold)
+	require.NotEmpty(t, cycles)
+	weekly := cycles[len(cycles)-1]
+	assert.Equal(t, WeeklyPeriod, weekly.Period)
+	assert.Equal(t, []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		weekly.PeakDays)
+
+	// A repeating daily pattern also repeats weekly, but is only reported as daily
+	cycles = detectCycles(usageHistory(21*24, time.Hour, dailyWave(random, time.Hour), "cores"),
+		defaultSeasonalityThreshold)
+	require.Len(t, cycles, 1)
+	assert.Equal(t, DailyPeriod, cycles[0].Period)
+}
+
+func TestDetectCycles_NoCycle(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		name    string
+		history []metrics.ResourceUsage
+	}{
+		{name: "noise", history: usageHistory(72*12, 5*time.Minute, func(int) float64 {
+			return 1 + 0.5*random.Float64()
+		}, "cores")},
+		{name: "linear growth", history: usageHistory(72*12, 5*time.Minute, func(i int) float64 {
+			return float64(i) / 100
+		}, "cores")},
+		{name: "flat", history: usageHistory(72*12, 5*time.Minute, steady(1), "cores")},
+		{name: "shorter than two periods", history: usageHistory(36*12, 5*time.Minute,
+			dailyWave(random, 5*time.Minute), "cores")},
+		{name: "too small to matter", history: usageHistory(72*12, 5*time.Minute, func(i int) float64 {
+			return 1 + 0.01*math.Sin(2*math.Pi*float64(i)/(24*12))
+		}, "cores")},
+		{name: "too sparse", history: usageHistory(24, 3*time.Hour, dailyWave(random, 3*time.Hour), "cores")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Empty(t, detectCycles(tt.history, defaultSeasonalityThreshold))
+		})
+	}
+}
+
+func TestClassifyWorkload_Periodic(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	classifier := NewWorkloadClassifier()
+
+	workloadMetrics := func(cpu func(int) float64) *metrics.WorkloadMetrics {
+		var pods []metrics.PodMetrics
+		for _, name := range []string{"pod-1", "pod-2"} {
+			pods = append(pods, metrics.PodMetrics{
+				PodName:         name,
+				CPUUsageHistory: usageHistory(72*12, 5*time.Minute, cpu, "cores"),
+				MemUsageHistory: usageHistory(72*12, 5*time.Minute, steady(256), "cores"),
+			})
+		}
+		return &metrics.WorkloadMetrics{WorkloadName: "web", Namespace: "default", Pods: pods}
+	}
+
+	classification, err := classifier.ClassifyWorkload(workloadMetrics(dailyWave(random, 5*time.Minute)))
+	require.NoError(t, err)
+	assert.Equal(t, WorkloadClassPeriodic, classification.Class)
+	require.Len(t, classification.CPUPattern.Cycles, 1)
+	assert.Empty(t, classification.MemoryPattern.Cycles)
+	assert.Contains(t, classification.GetClassificationSummary(), "Cycle: daily cycle")
+
+	// Equally variable usage without a cycle is not periodic
+	classification, err = classifier.ClassifyWorkload(workloadMetrics(func(int) float64 {
+		return 0.5 + random.Float64()
+	}))
+	require.NoError(t, err)
+	assert.NotEqual(t, WorkloadClassPeriodic, classification.Class)
+	assert.Empty(t, classification.CPUPattern.Cycles)
+}
+
+func TestClassifyWorkload_PeriodicAtEveryHour(t *testing.T) {
+	classifier := NewWorkloadClassifier()
+	flat := usageHistory(72*12, 5*time.Minute, steady(256), "cores")
+
+	// Whatever phase of the cycle the window ends in, the cycle is not taken for a trend. Three days from
+	// usageStart end at midnight, so moving the wave by whole hours moves the end through the cycle.
+	for hour := 0; hour < 24; hour++ {
+		wave := dailyWave(rand.New(rand.NewSource(1)), 5*time.Minute)
+		pod := metrics.PodMetrics{
+			PodName:         "pod-1",
+			CPUUsageHistory: usageHistory(72*12, 5*time.Minute, func(i int) float64 { return wave(i + hour*12) }, "cores"),
+			MemUsageHistory: flat,
+		}
+		classification, err := classifier.ClassifyWorkload(&metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{pod}})
+		require.NoError(t, err)
+		assert.Equal(t, WorkloadClassPeriodic, classification.Class, "window ending at %02d:00", hour)
+		assert.Equal(t, TrendDirectionStable, classification.CPUPattern.TrendDirection, "window ending at %02d:00", hour)
+	}
+
+	// A trend under the cycle is still found
+	wave := dailyWave(rand.New(rand.NewSource(1)), 5*time.Minute)
+	growing := func(i int) float64 {
+		return wave(i) * (1 - 0.2*float64(72*12-i)/(24*12))
+	}
+	pod := metrics.PodMetrics{
+		PodName:         "pod-1",
+		CPUUsageHistory: usageHistory(72*12, 5*time.Minute, growing, "cores"),
+		MemUsageHistory: flat,
+	}
+	classification, err := classifier.ClassifyWorkload(&metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{pod}})
+	require.NoError(t, err)
+	assert.Equal(t, TrendDirectionIncreasing, classification.CPUPattern.TrendDirection)
+}
+
+func TestAnalyzeTimeSeries_Cycles(t *testing.T) {
+	analyzer := NewAdvancedAnalyzer()
+	random := rand.New(rand.NewSource(1))
+
+	pattern := analyzer.analyzeTimeSeries(usageHistory(72*12, 5*time.Minute, dailyWave(random, 5*time.Minute), "cores"), "CPU")
+	require.NotNil(t, pattern)
+	require.Len(t, pattern.Cycles, 1)
+	assert.Contains(t, pattern.Description, "and a daily cycle")
+}
+
+func TestAverageSeries(t *testing.T) {
+	// One pod reports every minute, the other every five minutes and only for the second hour
+	frequent := usageHistory(120, time.Minute, steady(1), "cores")
+	sparse := usageHistory(24, 5*time.Minute, func(i int) float64 {
+		if i < 12 {
+			return math.NaN()
+		}
+		return 3
+	}, "cores")
+
+	average := averageSeries([][]metrics.ResourceUsage{frequent, sparse, nil}, 5*time.Minute)
+	require.Len(t, average.Values, 25)
+	assert.Equal(t, usageStart, average.Start)
+	assert.Equal(t, 1.0, average.Coverage)
+	// Each pod counts once per interval, however often it reports
+	assert.Equal(t, 1.0, average.Values[11])
+	assert.Equal(t, 2.0, average.Values[12])
+	assert.Equal(t, 2.0, average.Values[23])
+	// The interval centred on the end of the window only holds the frequent pod's last samples
+	assert.Equal(t, 1.0, average.Values[24])
+
+	assert.Empty(t, averageSeries(nil, 5*time.Minute).Values)
+}
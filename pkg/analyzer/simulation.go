@@ -0,0 +1,126 @@
+// pkg/analyzer/simulation.go
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+// BaselineVariant names the simulation result produced by the spec's own thresholds
+const BaselineVariant = "spec"
+
+// SimulationVariant is an alternative set of thresholds to compare against the spec
+type SimulationVariant struct {
+	Name       string                                 `json:"name"`
+	Thresholds rightsizingv1alpha1.ResourceThresholds `json:"thresholds"`
+}
+
+// SimulationResult holds the recommendations one set of thresholds produces for a workload
+type SimulationResult struct {
+	Name            string
+	Thresholds      rightsizingv1alpha1.ResourceThresholds
+	Recommendations []rightsizingv1alpha1.PodRecommendation
+	// SkippedPods counts pods left without a recommendation due to insufficient data or confidence, or
+	// with changes below the change threshold
+	SkippedPods int
+	// Savings totals the savings of all recommendations, empty without SimulationOptions.Complete
+	Savings ClusterSavingsReport
+	// Error is set when the engine rejected the thresholds
+	Error string
+}
+
+// SimulationOptions holds what the controller knows about a workload beyond its metrics
+type SimulationOptions struct {
+	// Horizon is how long the recommendations must hold, see RecommendationOptions.Horizon
+	Horizon time.Duration
+	// Rollouts are the pod template changes of the workload within the window
+	Rollouts []Rollout
+	// Complete applies the controller's resource policy to a recommendation, filling in its current
+	// resources and savings, and reports whether it meets the change threshold. Without it the
+	// recommendations are reported as the engine made them, without savings.
+	Complete func(*rightsizingv1alpha1.PodRecommendation) bool
+}
+
+// Simulate runs the engine on the same workload metrics once with the spec's thresholds and once per
+// variant, so the results can be compared side by side. Every run sees what the controller's next
+// analysis would: the horizon, rollouts, limit policy, recommender and, when classification is enabled,
+// the class profile. The spec's thresholds go through the class profile like the controller's, while
+// the percentiles and safety margin a variant sets win over it. Recommendations are then completed by
+// opts.Complete; those it drops count as skipped.
+func (r *RecommendationEngine) Simulate(
+	ctx context.Context,
+	workloadMetrics *metrics.WorkloadMetrics,
+	spec rightsizingv1alpha1.PodRightSizingSpec,
+	variants []SimulationVariant,
+	opts SimulationOptions,
+) ([]SimulationResult, error) {
+	if len(workloadMetrics.Pods) == 0 {
+		return nil, fmt.Errorf("no pod metrics provided")
+	}
+
+	runs := append([]SimulationVariant{{Name: BaselineVariant, Thresholds: spec.Thresholds}}, variants...)
+	costCalculator := NewCostCalculator()
+
+	results := make([]SimulationResult, 0, len(runs))
+	for i, run := range runs {
+		result := SimulationResult{Name: run.Name, Thresholds: run.Thresholds}
+
+		runOpts := RecommendationOptions{
+			Thresholds:    run.Thresholds,
+			LimitPolicy:   spec.LimitPolicy,
+			Recommender:   spec.Recommender,
+			Horizon:       opts.Horizon,
+			Rollouts:      opts.Rollouts,
+			OutlierFilter: spec.OutlierFilter,
+			Exclusions:    spec.AnalysisExclusions,
+		}
+		// Like the controller, fall back to the run's thresholds when the workload cannot be classified
+		runOpts, classification, _ := runOpts.Classify(workloadMetrics, spec.Classification)
+		if i > 0 {
+			// Variants exist to compare their thresholds, so classification.profiles must not hide them
+			runOpts.Thresholds.CPUUtilizationPercentile = firstPositive(
+				run.Thresholds.CPUUtilizationPercentile, runOpts.Thresholds.CPUUtilizationPercentile)
+			runOpts.Thresholds.MemoryUtilizationPercentile = firstPositive(
+				run.Thresholds.MemoryUtilizationPercentile, runOpts.Thresholds.MemoryUtilizationPercentile)
+			runOpts.Thresholds.SafetyMargin = firstPositive(run.Thresholds.SafetyMargin, runOpts.Thresholds.SafetyMargin)
+		}
+
+		recommendations, err := r.GenerateRecommendationsWithOptions(ctx, workloadMetrics, runOpts)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		var completed []rightsizingv1alpha1.PodRecommendation
+		for _, recommendation := range recommendations {
+			if classification != nil {
+				recommendation.WorkloadClass = string(classification.Class)
+				recommendation.Reason += " " + DescribeClassProfile(classification, runOpts) + "."
+			}
+			// The engine estimates savings against placeholder resources, so only completed ones are kept
+			if opts.Complete == nil {
+				recommendation.CurrentResources = corev1.ResourceRequirements{}
+				recommendation.PotentialSavings = rightsizingv1alpha1.ResourceSavings{}
+			} else if !opts.Complete(&recommendation) {
+				continue
+			}
+			completed = append(completed, recommendation)
+		}
+
+		result.Recommendations = completed
+		result.SkippedPods = len(workloadMetrics.Pods) - len(completed)
+		if opts.Complete != nil {
+			result.Savings = costCalculator.EstimateClusterSavings(completed)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
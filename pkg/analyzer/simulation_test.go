@@ -0,0 +1,152 @@
+package analyzer
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+func TestSimulate(t *testing.T) {
+	workloadMetrics := &metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{
+		{
+			PodName:         "test-pod-1",
+			Namespace:       "default",
+			CPUUsageHistory: usageHistory(15, time.Minute, steady(0.5), "cores"),
+			MemUsageHistory: usageHistory(15, time.Minute, steady(512*1024*1024), "bytes"),
+		},
+		{
+			PodName:         "test-pod-2",
+			Namespace:       "default",
+			CPUUsageHistory: usageHistory(5, time.Minute, steady(0.5), "cores"),
+			MemUsageHistory: usageHistory(5, time.Minute, steady(512*1024*1024), "bytes"),
+		},
+	}}
+
+	spec := rightsizingv1alpha1.PodRightSizingSpec{
+		Thresholds: rightsizingv1alpha1.ResourceThresholds{SafetyMargin: 20},
+	}
+	variants := []SimulationVariant{
+		{Name: "lean", Thresholds: rightsizingv1alpha1.ResourceThresholds{SafetyMargin: 10}},
+		{Name: "invalid", Thresholds: rightsizingv1alpha1.ResourceThresholds{StartupExclusion: "soon"}},
+	}
+	current := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("2"),
+			corev1.ResourceMemory: resource.MustParse("2Gi"),
+		},
+	}
+
+	// The controller fills in the current resources and savings
+	opts := SimulationOptions{Complete: func(recommendation *rightsizingv1alpha1.PodRecommendation) bool {
+		recommendation.CurrentResources = current
+		recommendation.PotentialSavings = NewCostCalculator().CalculateSavings(current, recommendation.RecommendedResources)
+		return true
+	}}
+
+	results, err := NewRecommendationEngine().Simulate(context.Background(), workloadMetrics, spec, variants, opts)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, []string{BaselineVariant, "lean", "invalid"},
+		[]string{results[0].Name, results[1].Name, results[2].Name})
+
+	baseline, lean := results[0], results[1]
+	require.Len(t, baseline.Recommendations, 1)
+	require.Len(t, lean.Recommendations, 1)
+	assert.Equal(t, 1, baseline.SkippedPods)
+
+	// Both runs use the same samples, so only the safety margin differs
+	baselineCPU := baseline.Recommendations[0].RecommendedResources.Limits[corev1.ResourceCPU]
+	leanCPU := lean.Recommendations[0].RecommendedResources.Limits[corev1.ResourceCPU]
+	assert.Equal(t, int64(600), baselineCPU.MilliValue())
+	assert.Equal(t, int64(550), leanCPU.MilliValue())
+	assert.Equal(t, baseline.Recommendations[0].Confidence, lean.Recommendations[0].Confidence)
+
+	// Savings are estimated by the completed recommendations
+	assert.Equal(t, current, baseline.Recommendations[0].CurrentResources)
+	assert.NotEmpty(t, baseline.Recommendations[0].PotentialSavings.CostSavings)
+	assert.NotEmpty(t, baseline.Savings.EstimatedMonthlySavings)
+	assert.Equal(t, "1.560 cores", lean.Savings.TotalCPUSavings)
+
+	assert.Contains(t, results[2].Error, "invalid startupExclusion")
+	assert.Empty(t, results[2].Recommendations)
+
+	// Without completion no savings are reported
+	results, err = NewRecommendationEngine().Simulate(
+		context.Background(), workloadMetrics, spec, nil, SimulationOptions{})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Empty(t, results[0].Recommendations[0].CurrentResources.Requests)
+	assert.Empty(t, results[0].Recommendations[0].PotentialSavings.CostSavings)
+	assert.Empty(t, results[0].Savings.EstimatedMonthlySavings)
+
+	// Recommendations below the change threshold are skipped
+	results, err = NewRecommendationEngine().Simulate(context.Background(), workloadMetrics, spec, nil,
+		SimulationOptions{Complete: func(*rightsizingv1alpha1.PodRecommendation) bool { return false }})
+	require.NoError(t, err)
+	assert.Empty(t, results[0].Recommendations)
+	assert.Equal(t, 2, results[0].SkippedPods)
+}
+
+func TestSimulate_HorizonAndClassification(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	workloadMetrics := &metrics.WorkloadMetrics{Pods: []metrics.PodMetrics{{
+		PodName:         "test-pod-1",
+		Namespace:       "default",
+		CPUUsageHistory: usageHistory(72*12, 5*time.Minute, growth(random), "cores"),
+		MemUsageHistory: usageHistory(72*12, 5*time.Minute, steady(256*1024*1024), "bytes"),
+	}}}
+	spec := rightsizingv1alpha1.PodRightSizingSpec{
+		Recommender: rightsizingv1alpha1.RecommenderSpec{Name: ForecastRecommender},
+	}
+
+	cpuLimit := func(result SimulationResult) float64 {
+		require.Len(t, result.Recommendations, 1)
+		limit := result.Recommendations[0].RecommendedResources.Limits[corev1.ResourceCPU]
+		return limit.AsApproximateFloat64()
+	}
+
+	// The forecast is projected as far ahead as the controller's next analysis
+	results, err := NewRecommendationEngine().Simulate(context.Background(), workloadMetrics, spec, nil,
+		SimulationOptions{Horizon: 48 * time.Hour})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.InDelta(t, 1.7*1.2, cpuLimit(results[0]), 0.1)
+
+	// Classified workloads use their class profile, like the controller
+	spec.Classification.Enabled = true
+	results, err = NewRecommendationEngine().Simulate(context.Background(), workloadMetrics, spec, nil,
+		SimulationOptions{Horizon: 48 * time.Hour})
+	require.NoError(t, err)
+	require.Len(t, results[0].Recommendations, 1)
+	recommendation := results[0].Recommendations[0]
+	assert.NotEmpty(t, recommendation.WorkloadClass)
+	assert.Contains(t, recommendation.Reason, "Classified as "+recommendation.WorkloadClass)
+
+	// Variants keep their own percentiles over the class profile, even one set in classification.profiles
+	spec.Classification.Profiles = map[string]rightsizingv1alpha1.ClassProfile{}
+	for _, class := range WorkloadClasses {
+		spec.Classification.Profiles[string(class)] = rightsizingv1alpha1.ClassProfile{CPUUtilizationPercentile: 95}
+	}
+	variants := []SimulationVariant{
+		{Name: "median", Thresholds: rightsizingv1alpha1.ResourceThresholds{CPUUtilizationPercentile: 50}},
+		{Name: "peak", Thresholds: rightsizingv1alpha1.ResourceThresholds{CPUUtilizationPercentile: 99}},
+	}
+	results, err = NewRecommendationEngine().Simulate(context.Background(), workloadMetrics, spec, variants,
+		SimulationOptions{Horizon: 48 * time.Hour})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	median, peak := results[1], results[2]
+	assert.Less(t, cpuLimit(median), cpuLimit(peak))
+	assert.Contains(t, median.Recommendations[0].Reason, "50th CPU")
+	assert.Contains(t, peak.Recommendations[0].Reason, "99th CPU")
+	assert.Contains(t, results[0].Recommendations[0].Reason, "95th CPU")
+}
@@ -0,0 +1,108 @@
+// pkg/analyzer/startup.go
+package analyzer
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+)
+
+// startupExclusion returns the configured startup exclusion window, zero when it is not set
+func startupExclusion(thresholds rightsizingv1alpha1.ResourceThresholds) (time.Duration, error) {
+	if thresholds.StartupExclusion == "" {
+		return 0, nil
+	}
+
+	exclusion, err := rightsizingv1alpha1.ParseDuration(thresholds.StartupExclusion)
+	if err != nil {
+		return 0, fmt.Errorf("invalid startupExclusion: %w", err)
+	}
+	return exclusion, nil
+}
+
+// excludeStartupSamples drops the samples of the regular containers that fall within exclusion after
+// a container start, from the pod's histories as well as each container's own. It returns the remaining
+// metrics, the peak usage of the samples dropped from the pod's histories and how many of those were
+// dropped. Init and sidecar containers are left alone.
+func excludeStartupSamples(
+	podMetrics metrics.PodMetrics,
+	exclusion time.Duration,
+) (metrics.PodMetrics, corev1.ResourceList, int) {
+	if exclusion <= 0 || len(podMetrics.ContainerStarts) == 0 {
+		return podMetrics, nil, 0
+	}
+
+	inStartup := func(timestamp time.Time) bool {
+		for _, start := range podMetrics.ContainerStarts {
+			if !timestamp.Before(start.Time) && timestamp.Before(start.Time.Add(exclusion)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	excluded := 0
+	peak := corev1.ResourceList{}
+	split := func(history []metrics.ResourceUsage, name corev1.ResourceName) []metrics.ResourceUsage {
+		steady := make([]metrics.ResourceUsage, 0, len(history))
+		peakValue, found := 0.0, false
+		for _, usage := range history {
+			if !inStartup(usage.Timestamp) {
+				steady = append(steady, usage)
+				continue
+			}
+
+			excluded++
+			if !found || usage.Value > peakValue {
+				peakValue, found = usage.Value, true
+			}
+		}
+
+		if found {
+			if name == corev1.ResourceCPU {
+				peak[name] = *resource.NewMilliQuantity(int64(peakValue*1000), resource.DecimalSI)
+			} else {
+				peak[name] = *resource.NewQuantity(int64(peakValue), resource.BinarySI)
+			}
+		}
+		return steady
+	}
+
+	podMetrics.CPUUsageHistory = split(podMetrics.CPUUsageHistory, corev1.ResourceCPU)
+	podMetrics.MemUsageHistory = split(podMetrics.MemUsageHistory, corev1.ResourceMemory)
+	podMetrics.StorageUsageHistory = split(podMetrics.StorageUsageHistory, corev1.ResourceEphemeralStorage)
+
+	withoutStartup := func(history []metrics.ResourceUsage) []metrics.ResourceUsage {
+		steady := make([]metrics.ResourceUsage, 0, len(history))
+		for _, usage := range history {
+			if !inStartup(usage.Timestamp) {
+				steady = append(steady, usage)
+			}
+		}
+		return steady
+	}
+	if podMetrics.Containers != nil {
+		containers := make(map[string]metrics.ContainerMetrics, len(podMetrics.Containers))
+		for name, container := range podMetrics.Containers {
+			container.CPUUsageHistory = withoutStartup(container.CPUUsageHistory)
+			container.MemUsageHistory = withoutStartup(container.MemUsageHistory)
+			container.StorageUsageHistory = withoutStartup(container.StorageUsageHistory)
+			container.CPUThrottlingHistory = withoutStartup(container.CPUThrottlingHistory)
+			containers[name] = container
+		}
+		podMetrics.Containers = containers
+	}
+
+	// Throttling during startup is expected and says nothing about steady-state demand
+	podMetrics.CPUThrottlingHistory = withoutStartup(podMetrics.CPUThrottlingHistory)
+
+	if len(peak) == 0 {
+		peak = nil
+	}
+	return podMetrics, peak, excluded
+}
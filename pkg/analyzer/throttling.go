@@ -0,0 +1,60 @@
+// pkg/analyzer/throttling.go
+package analyzer
+
+import (
+	"fmt"
+	"math"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	rightsizingv1alpha1 "github.com/wesleyemery/k8s-pod-rightsizer/api/v1alpha1"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/stats"
+)
+
+// applyThrottlingBoost raises the CPU limit when the containers were throttled in more CFS periods
+// than the configured threshold. CPU usage of a throttled container is capped at its limit, so the
+// limit grows by the throttled ratio, taken at the CPU utilization percentile, instead of following
+// the capped usage. The limit at most doubles per recommendation.
+func (r *RecommendationEngine) applyThrottlingBoost(
+	cpuRecommendation *ResourceRecommendation,
+	throttlingHistory []metrics.ResourceUsage,
+	thresholds rightsizingv1alpha1.ResourceThresholds,
+) {
+	if len(throttlingHistory) == 0 || cpuRecommendation == nil || cpuRecommendation.Limit == nil {
+		return
+	}
+
+	threshold := r.DefaultCPUThrottlingThreshold
+	if thresholds.CPUThrottlingThreshold > 0 {
+		threshold = thresholds.CPUThrottlingThreshold
+	}
+	percentile := 95
+	if thresholds.CPUUtilizationPercentile > 0 {
+		percentile = thresholds.CPUUtilizationPercentile
+	}
+
+	sketch := stats.NewSketch()
+	for _, usage := range throttlingHistory {
+		sketch.Add(math.Min(math.Max(usage.Value, 0), 1))
+	}
+	ratio := sketch.Quantile(float64(percentile) / 100)
+	if ratio*100 <= float64(threshold) {
+		return
+	}
+
+	limit := cpuRecommendation.Limit.AsApproximateFloat64() * (1 + ratio)
+	if !thresholds.MaxCPU.IsZero() {
+		limit = math.Min(limit, thresholds.MaxCPU.AsApproximateFloat64())
+	}
+	if limit <= cpuRecommendation.Limit.AsApproximateFloat64() {
+		return
+	}
+
+	cpuRecommendation.Limit = resource.NewMilliQuantity(int64(math.Round(limit*1000)), resource.DecimalSI)
+	if cpuRecommendation.Request != nil && cpuRecommendation.Request.Cmp(*cpuRecommendation.Limit) > 0 {
+		cpuRecommendation.Request = cpuRecommendation.Limit
+	}
+	cpuRecommendation.Reason += fmt.Sprintf(
+		", limit raised because %.0f%% of CFS periods were throttled (threshold %d%%)", ratio*100, threshold)
+}
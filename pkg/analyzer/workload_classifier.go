@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/metrics"
+	"github.com/wesleyemery/k8s-pod-rightsizer/pkg/stats"
 )
 
 // WorkloadClassifier classifies workloads based on usage patterns
@@ -15,6 +16,8 @@ type WorkloadClassifier struct {
 	HighVariabilityThreshold       float64
 	SpikeDetectionThreshold        float64
 	MinDataPointsForClassification int
+	// SeasonalityThreshold is the autocorrelation at the period lag needed to detect a daily or weekly cycle
+	SeasonalityThreshold float64
 }
 
 // NewWorkloadClassifier creates a new workload classifier.
@@ -23,6 +26,7 @@ func NewWorkloadClassifier() *WorkloadClassifier {
 		HighVariabilityThreshold:       defaultHighVariabilityThreshold,
 		SpikeDetectionThreshold:        defaultSpikeDetectionThreshold,
 		MinDataPointsForClassification: defaultMinDataPointsForClassification,
+		SeasonalityThreshold:           defaultSeasonalityThreshold,
 	}
 }
 
@@ -32,7 +36,7 @@ type WorkloadClass string
 const (
 	WorkloadClassStable        WorkloadClass = "Stable"        // Low variability, predictable
 	WorkloadClassBursty        WorkloadClass = "Bursty"        // High variability with spikes
-	WorkloadClassPeriodic      WorkloadClass = "Periodic"      // Regular patterns
+	WorkloadClassPeriodic      WorkloadClass = "Periodic"      // Daily or weekly cycles
 	WorkloadClassGrowing       WorkloadClass = "Growing"       // Increasing trend
 	WorkloadClassShrinking     WorkloadClass = "Shrinking"     // Decreasing trend
 	WorkloadClassUnpredictable WorkloadClass = "Unpredictable" // Chaotic patterns
@@ -50,6 +54,8 @@ const (
 	defaultHighVariabilityThreshold       = 0.3 // 30% coefficient of variation
 	defaultSpikeDetectionThreshold        = 2.0 // 2 standard deviations
 	defaultMinDataPointsForClassification = 20
+	minTrendPerDay                        = 0.01 // 1% change per day is too small to be a trend
+	trendFullStrengthPerDay               = 0.1  // 10% change per day is a full-strength trend
 )
 
 // WorkloadClassification contains the classification results
@@ -76,6 +82,7 @@ type ResourcePattern struct {
 	MinValue               float64
 	MaxValue               float64
 	P95Value               float64
+	Cycles                 []Cycle // Daily and weekly cycles detected by autocorrelation
 }
 
 // ClassificationRecommendation provides specific recommendations based on classification
@@ -125,7 +132,9 @@ func (w *WorkloadClassifier) ClassifyWorkload(workloadMetrics *metrics.WorkloadM
 
 // analyzeResourcePattern analyzes the pattern for a specific resource type
 func (w *WorkloadClassifier) analyzeResourcePattern(workloadMetrics *metrics.WorkloadMetrics, resourceType string) (*ResourcePattern, error) {
-	var allValues []float64
+	sketch := stats.NewSketch()
+	var moments stats.Moments
+	var histories [][]metrics.ResourceUsage
 
 	// Collect all values across all pods
 	for _, pod := range workloadMetrics.Pods {
@@ -139,37 +148,49 @@ func (w *WorkloadClassifier) analyzeResourcePattern(workloadMetrics *metrics.Wor
 			return nil, fmt.Errorf("unsupported resource type: %s", resourceType)
 		}
 
-		for _, usage := range history {
-			allValues = append(allValues, usage.Value)
+		podSketch, podMoments := usageStats(history)
+		if err := sketch.Merge(podSketch); err != nil {
+			return nil, err
 		}
+		moments.Merge(podMoments)
+		histories = append(histories, history)
 	}
 
-	if len(allValues) < w.MinDataPointsForClassification {
+	if moments.Count() < w.MinDataPointsForClassification {
 		return nil, fmt.Errorf("insufficient data points for %s analysis: %d < %d",
-			resourceType, len(allValues), w.MinDataPointsForClassification)
+			resourceType, moments.Count(), w.MinDataPointsForClassification)
 	}
 
 	pattern := &ResourcePattern{}
 
 	// Calculate basic statistics
-	pattern.Mean = w.calculateMean(allValues)
-	pattern.StandardDeviation = w.calculateStandardDeviation(allValues, pattern.Mean)
+	pattern.Mean = moments.Mean()
+	pattern.StandardDeviation = moments.StdDev()
 
 	if pattern.Mean > 0 {
 		pattern.CoefficientOfVariation = pattern.StandardDeviation / pattern.Mean
 	}
 
-	pattern.MinValue = w.calculateMin(allValues)
-	pattern.MaxValue = w.calculateMax(allValues)
-	pattern.P95Value = w.calculatePercentile(allValues, 95)
+	pattern.MinValue = sketch.Min()
+	pattern.MaxValue = sketch.Max()
+	pattern.P95Value = sketch.Quantile(0.95)
+
+	// Detect cycles in the workload's average usage over time
+	average := averageSeries(histories, metrics.InferStep(histories...))
+	pattern.Cycles = detectCycles(average.Usage(""), w.SeasonalityThreshold)
 
-	// Analyze trend
-	trendDirection, trendStrength := w.analyzeTrend(allValues)
+	// Analyze the trend of the workload's average usage over time, not over the samples of all pods in
+	// turn. Cycles are averaged out first, or the phase the window ends in would read as a trend.
+	trendSeries := average
+	for _, cycle := range pattern.Cycles {
+		trendSeries = movingAverage(trendSeries, cycle.Period)
+	}
+	trendDirection, trendStrength := w.analyzeTrend(trendSeries)
 	pattern.TrendDirection = trendDirection
 	pattern.TrendStrength = trendStrength
 
 	// Calculate spike frequency
-	pattern.SpikeFrequency = w.calculateSpikeFrequency(allValues, pattern.Mean, pattern.StandardDeviation)
+	pattern.SpikeFrequency = w.calculateSpikeFrequency(histories, pattern.Mean, pattern.StandardDeviation)
 
 	return pattern, nil
 }
@@ -186,15 +207,15 @@ func (w *WorkloadClassifier) determineWorkloadClass(cpuPattern, memPattern Resou
 		}
 	}
 
+	// Variability that follows a daily or weekly cycle is predictable
+	if len(cpuPattern.Cycles) > 0 || len(memPattern.Cycles) > 0 {
+		return WorkloadClassPeriodic
+	}
+
 	// Check for high variability (bursty workloads)
 	if cpuPattern.CoefficientOfVariation > w.HighVariabilityThreshold ||
 		memPattern.CoefficientOfVariation > w.HighVariabilityThreshold {
 
-		// If there are regular spikes, it might be periodic
-		if cpuPattern.SpikeFrequency > 0.1 && cpuPattern.SpikeFrequency < 0.3 {
-			return WorkloadClassPeriodic
-		}
-
 		// High variability with frequent spikes = bursty
 		if cpuPattern.SpikeFrequency > 0.3 || memPattern.SpikeFrequency > 0.3 {
 			return WorkloadClassBursty
@@ -325,107 +346,114 @@ func (w *WorkloadClassifier) generateClassificationRecommendations(classificatio
 
 // Helper functions for statistical calculations
 
-func (w *WorkloadClassifier) calculateMean(values []float64) float64 {
-	if len(values) == 0 {
-		return 0
-	}
-	sum := 0.0
-	for _, v := range values {
-		sum += v
-	}
-	return sum / float64(len(values))
-}
-
-func (w *WorkloadClassifier) calculateStandardDeviation(values []float64, mean float64) float64 {
-	if len(values) < 2 {
-		return 0
+// averageSeries resamples the history of every pod to a shared grid of step and averages the pods per
+// interval, so pods that report more often or only part of the time do not skew the workload's usage.
+// Intervals no pod holds a value for are NaN.
+func averageSeries(histories [][]metrics.ResourceUsage, step time.Duration) metrics.Series {
+	var first, last time.Time
+	for _, history := range histories {
+		for _, usage := range history {
+			if first.IsZero() || usage.Timestamp.Before(first) {
+				first = usage.Timestamp
+			}
+			if usage.Timestamp.After(last) {
+				last = usage.Timestamp
+			}
+		}
 	}
-	sumSquaredDiff := 0.0
-	for _, v := range values {
-		diff := v - mean
-		sumSquaredDiff += diff * diff
+	average := metrics.Series{Start: first, Step: step}
+	if first.IsZero() || step <= 0 {
+		return average
 	}
-	variance := sumSquaredDiff / float64(len(values)-1)
-	return math.Sqrt(variance)
-}
 
-func (w *WorkloadClassifier) calculateMin(values []float64) float64 {
-	if len(values) == 0 {
-		return 0
-	}
-	minVal := values[0]
-	for _, v := range values {
-		if v < minVal {
-			minVal = v
+	var sums []float64
+	var counts []int
+	for _, history := range histories {
+		if len(history) == 0 {
+			continue
+		}
+		series := metrics.Resample(history, first, last, step, metrics.DefaultMaxGapSteps)
+		for len(sums) < len(series.Values) {
+			sums = append(sums, 0)
+			counts = append(counts, 0)
+		}
+		for i, value := range series.Values {
+			if !math.IsNaN(value) {
+				sums[i] += value
+				counts[i]++
+			}
 		}
 	}
-	return minVal
-}
 
-func (w *WorkloadClassifier) calculateMax(values []float64) float64 {
-	if len(values) == 0 {
-		return 0
-	}
-	maxVal := values[0]
-	for _, v := range values {
-		if v > maxVal {
-			maxVal = v
+	average.Values = make([]float64, len(sums))
+	average.Observed = make([]bool, len(sums))
+	observed := 0
+	for i := range sums {
+		if counts[i] == 0 {
+			average.Values[i] = math.NaN()
+			continue
 		}
+		average.Values[i] = sums[i] / float64(counts[i])
+		average.Observed[i] = true
+		observed++
 	}
-	return maxVal
-}
-
-func (w *WorkloadClassifier) calculatePercentile(values []float64, percentile float64) float64 {
-	if len(values) == 0 {
-		return 0
+	if len(sums) > 0 {
+		average.Coverage = float64(observed) / float64(len(sums))
 	}
+	return average
+}
 
-	// Create a copy and sort
-	sorted := make([]float64, len(values))
-	copy(sorted, values)
-
-	// Simple bubble sort (for small datasets)
-	for i := 0; i < len(sorted); i++ {
-		for j := i + 1; j < len(sorted); j++ {
-			if sorted[i] > sorted[j] {
-				sorted[i], sorted[j] = sorted[j], sorted[i]
+// movingAverage averages a series over a sliding window of period, which cancels out a cycle of that
+// period. Each value covers the window starting at its interval; windows without values are NaN.
+// Series shorter than the window are returned unchanged.
+func movingAverage(series metrics.Series, period time.Duration) metrics.Series {
+	if series.Step <= 0 {
+		return series
+	}
+	steps := int(period / series.Step)
+	if steps < 2 || len(series.Values) < steps {
+		return series
+	}
+
+	averaged := metrics.Series{Start: series.Start, Step: series.Step, Values: make([]float64, len(series.Values)-steps+1)}
+	for i := range averaged.Values {
+		var sum float64
+		var count int
+		for _, value := range series.Values[i : i+steps] {
+			if !math.IsNaN(value) {
+				sum += value
+				count++
 			}
 		}
+		averaged.Values[i] = math.NaN()
+		if count > 0 {
+			averaged.Values[i] = sum / float64(count)
+		}
 	}
-
-	index := (percentile / 100.0) * float64(len(sorted)-1)
-	lower := int(math.Floor(index))
-	upper := int(math.Ceil(index))
-
-	if lower == upper {
-		return sorted[lower]
-	}
-
-	weight := index - float64(lower)
-	return sorted[lower]*(1-weight) + sorted[upper]*weight
+	return averaged
 }
 
-func (w *WorkloadClassifier) analyzeTrend(values []float64) (string, float64) {
-	if len(values) < 10 {
-		return TrendDirectionStable, 0.0
-	}
-
+// analyzeTrend fits a line to a resampled series over time. The strength is the relative change per day,
+// reaching 1 at trendFullStrengthPerDay; intervals in gaps too long to interpolate are left out.
+func (w *WorkloadClassifier) analyzeTrend(series metrics.Series) (string, float64) {
 	// Simple linear regression to detect trend
-	n := float64(len(values))
-	sumX := 0.0
-	sumY := 0.0
-	sumXY := 0.0
-	sumX2 := 0.0
-
-	for i, y := range values {
-		x := float64(i)
+	var n, sumX, sumY, sumXY, sumX2 float64
+	for i, y := range series.Values {
+		if math.IsNaN(y) {
+			continue
+		}
+		x := (time.Duration(i) * series.Step).Hours() / 24
+		n++
 		sumX += x
 		sumY += y
 		sumXY += x * y
 		sumX2 += x * x
 	}
+	if n < 10 || n*sumX2 == sumX*sumX {
+		return TrendDirectionStable, 0.0
+	}
 
-	// Calculate slope
+	// Calculate slope per day
 	slope := (n*sumXY - sumX*sumY) / (n*sumX2 - sumX*sumX)
 
 	// Normalize slope by mean to get relative trend strength
@@ -437,7 +465,7 @@ func (w *WorkloadClassifier) analyzeTrend(values []float64) (string, float64) {
 	relativeSlope := math.Abs(slope) / mean
 
 	// Determine direction and strength
-	if math.Abs(slope) < mean*0.001 { // Very small trend
+	if relativeSlope < minTrendPerDay { // Very small trend
 		return TrendDirectionStable, 0.0
 	}
 
@@ -449,26 +477,32 @@ func (w *WorkloadClassifier) analyzeTrend(values []float64) (string, float64) {
 	}
 
 	// Cap strength at 1.0
-	strength := math.Min(relativeSlope*100, 1.0)
+	strength := math.Min(relativeSlope/trendFullStrengthPerDay, 1.0)
 
 	return direction, strength
 }
 
-func (w *WorkloadClassifier) calculateSpikeFrequency(values []float64, mean, stdDev float64) float64 {
-	if len(values) == 0 || stdDev == 0 {
+func (w *WorkloadClassifier) calculateSpikeFrequency(histories [][]metrics.ResourceUsage, mean, stdDev float64) float64 {
+	if stdDev == 0 {
 		return 0.0
 	}
 
 	spikeThreshold := mean + w.SpikeDetectionThreshold*stdDev
-	spikes := 0
+	spikes, total := 0, 0
 
-	for _, v := range values {
-		if v > spikeThreshold {
-			spikes++
+	for _, history := range histories {
+		for _, usage := range history {
+			if usage.Value > spikeThreshold {
+				spikes++
+			}
 		}
+		total += len(history)
+	}
+	if total == 0 {
+		return 0.0
 	}
 
-	return float64(spikes) / float64(len(values))
+	return float64(spikes) / float64(total)
 }
 
 // GetClassificationSummary provides a human-readable summary of the classification
@@ -485,12 +519,18 @@ func (classification *WorkloadClassification) GetClassificationSummary() string
 	summary.WriteString(fmt.Sprintf("  - Trend: %s (%.1f%%)\n",
 		classification.CPUPattern.TrendDirection, classification.CPUPattern.TrendStrength*100))
 	summary.WriteString(fmt.Sprintf("  - Spike Frequency: %.1f%%\n", classification.CPUPattern.SpikeFrequency*100))
+	for _, cycle := range classification.CPUPattern.Cycles {
+		summary.WriteString(fmt.Sprintf("  - Cycle: %s\n", cycle))
+	}
 
 	summary.WriteString("\nMemory Pattern:\n")
 	summary.WriteString(fmt.Sprintf("  - Variability: %.2f (CV)\n", classification.MemoryPattern.CoefficientOfVariation))
 	summary.WriteString(fmt.Sprintf("  - Trend: %s (%.1f%%)\n",
 		classification.MemoryPattern.TrendDirection, classification.MemoryPattern.TrendStrength*100))
 	summary.WriteString(fmt.Sprintf("  - Spike Frequency: %.1f%%\n", classification.MemoryPattern.SpikeFrequency*100))
+	for _, cycle := range classification.MemoryPattern.Cycles {
+		summary.WriteString(fmt.Sprintf("  - Cycle: %s\n", cycle))
+	}
 
 	if len(classification.Recommendations) > 0 {
 		summary.WriteString("\nRecommendations:\n")
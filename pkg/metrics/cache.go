@@ -0,0 +1,408 @@
+package metrics
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	defaultCacheTTL        = time.Hour
+	defaultCacheMaxSamples = 2_000_000
+	// minTailFetch avoids querying the backend for tails shorter than one step
+	minTailFetch = time.Minute
+
+	cacheResultHit     = "hit"
+	cacheResultPartial = "partial"
+	cacheResultMiss    = "miss"
+)
+
+var (
+	cacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "rightsizer_metrics_cache_requests_total",
+			Help: "Workload metrics cache lookups by result (hit, partial, miss)",
+		},
+		[]string{"result"},
+	)
+	cacheSamples = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "rightsizer_metrics_cache_samples",
+			Help: "Number of usage samples currently held by the workload metrics cache",
+		},
+	)
+	cacheEvictions = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "rightsizer_metrics_cache_evictions_total",
+			Help: "Workload metrics cache entries evicted due to the memory bound",
+		},
+	)
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(cacheRequests, cacheSamples, cacheEvictions)
+}
+
+// CachingClient caches workload metrics between reconciles. Repeated lookups for
+// the same workload and window reuse the stored samples and only fetch the tail
+// since the previous query. Pod metrics are passed through uncached.
+type CachingClient struct {
+	client Client
+	// TTL bounds how long cached samples are reused before a full refetch
+	TTL time.Duration
+	// MaxSamples bounds the total number of samples held across all entries
+	MaxSamples int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	samples int
+	now     func() time.Time
+}
+
+// TailClient is implemented by backends that can fetch the samples of a workload from start until now
+// at a given step
+type TailClient interface {
+	GetWorkloadMetricsTail(
+		ctx context.Context,
+		namespace, workloadName, workloadType string,
+		start time.Time,
+		step time.Duration,
+	) (*WorkloadMetrics, error)
+}
+
+type cacheEntry struct {
+	key       string
+	mu        sync.Mutex
+	metrics   *WorkloadMetrics
+	fetchedAt time.Time
+	samples   int
+}
+
+// NewCachingClient creates a caching client. Non-positive values fall back to the defaults.
+func NewCachingClient(client Client, ttl time.Duration, maxSamples int) *CachingClient {
+	if ttl <= 0 {
+		ttl = defaultCacheTTL
+	}
+	if maxSamples <= 0 {
+		maxSamples = defaultCacheMaxSamples
+	}
+
+	return &CachingClient{
+		client:     client,
+		TTL:        ttl,
+		MaxSamples: maxSamples,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		now:        time.Now,
+	}
+}
+
+// GetPodMetrics retrieves metrics for a specific pod without caching
+func (c *CachingClient) GetPodMetrics(
+	ctx context.Context,
+	namespace, podName string,
+	window time.Duration,
+) (*PodMetrics, error) {
+	return c.client.GetPodMetrics(ctx, namespace, podName, window)
+}
+
+// GetWorkloadMetrics retrieves workload metrics, fetching only samples newer than the cached ones
+func (c *CachingClient) GetWorkloadMetrics(
+	ctx context.Context,
+	namespace, workloadName, workloadType string,
+	window time.Duration,
+) (*WorkloadMetrics, error) {
+	key := fmt.Sprintf("%s/%s/%s/%s", namespace, workloadType, workloadName, window)
+	entry := c.getEntry(key)
+
+	// Serialize refreshes of the same workload; other workloads proceed in parallel
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	now := c.now()
+	// Tails are fetched at the step the window was queried at, so the merged histories keep one density.
+	// Backends that cannot query at a given step refetch windows queried at coarser steps than a minute.
+	step := queryStep(window)
+	tailClient, fetchesTails := c.client.(TailClient)
+
+	switch {
+	case entry.metrics == nil || now.Sub(entry.fetchedAt) > c.TTL || (!fetchesTails && step > time.Minute):
+		cacheRequests.WithLabelValues(cacheResultMiss).Inc()
+		fetched, err := c.client.GetWorkloadMetrics(ctx, namespace, workloadName, workloadType, window)
+		if err != nil {
+			if entry.metrics == nil {
+				c.removeEntry(key, entry)
+			}
+			return nil, err
+		}
+		entry.metrics = copyWorkloadMetrics(fetched)
+		entry.fetchedAt = now
+
+	case now.Sub(entry.metrics.EndTime) < max(minTailFetch, step):
+		cacheRequests.WithLabelValues(cacheResultHit).Inc()
+
+	default:
+		cacheRequests.WithLabelValues(cacheResultPartial).Inc()
+		var tail *WorkloadMetrics
+		var err error
+		if fetchesTails {
+			tail, err = tailClient.GetWorkloadMetricsTail(ctx, namespace, workloadName, workloadType, entry.metrics.EndTime, step)
+		} else {
+			tail, err = c.client.GetWorkloadMetrics(ctx, namespace, workloadName, workloadType, now.Sub(entry.metrics.EndTime))
+		}
+		if err != nil {
+			return nil, err
+		}
+		mergeWorkloadTail(entry.metrics, tail)
+	}
+
+	trimWorkloadMetrics(entry.metrics, now.Add(-window))
+	c.updateSize(key, entry)
+
+	return copyWorkloadMetrics(entry.metrics), nil
+}
+
+// getEntry returns the cache entry for a key, creating it if needed, and marks it as recently used
+func (c *CachingClient) getEntry(key string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		return elem.Value.(*cacheEntry)
+	}
+
+	entry := &cacheEntry{key: key}
+	c.entries[key] = c.lru.PushFront(entry)
+	return entry
+}
+
+// removeEntry drops an entry whose first fetch failed, unless another fetch has replaced it already
+func (c *CachingClient) removeEntry(key string, entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok && elem.Value.(*cacheEntry) == entry {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+// updateSize records the new sample count of an entry and evicts least recently used entries over the bound
+func (c *CachingClient) updateSize(key string, entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The entry may have been evicted by another workload while it was refreshing
+	if elem, ok := c.entries[key]; !ok || elem.Value.(*cacheEntry) != entry {
+		return
+	}
+
+	size := countSamples(entry.metrics)
+	c.samples += size - entry.samples
+	entry.samples = size
+
+	for c.samples > c.MaxSamples && c.lru.Len() > 1 {
+		oldest := c.lru.Back()
+		victim := oldest.Value.(*cacheEntry)
+		if victim.key == key {
+			break
+		}
+		c.lru.Remove(oldest)
+		delete(c.entries, victim.key)
+		c.samples -= victim.samples
+		cacheEvictions.Inc()
+	}
+
+	cacheSamples.Set(float64(c.samples))
+}
+
+// mergeWorkloadTail appends samples from tail that are newer than those already cached
+func mergeWorkloadTail(cached, tail *WorkloadMetrics) {
+	podIndex := make(map[string]int, len(cached.Pods))
+	for i, pod := range cached.Pods {
+		podIndex[pod.PodName] = i
+	}
+
+	for _, tailPod := range tail.Pods {
+		i, ok := podIndex[tailPod.PodName]
+		if !ok {
+			cached.Pods = append(cached.Pods, copyPodMetrics(tailPod))
+			continue
+		}
+
+		pod := &cached.Pods[i]
+		pod.CPUUsageHistory = appendNewer(pod.CPUUsageHistory, tailPod.CPUUsageHistory)
+		pod.MemUsageHistory = appendNewer(pod.MemUsageHistory, tailPod.MemUsageHistory)
+		pod.StorageUsageHistory = appendNewer(pod.StorageUsageHistory, tailPod.StorageUsageHistory)
+		pod.CPUThrottlingHistory = appendNewer(pod.CPUThrottlingHistory, tailPod.CPUThrottlingHistory)
+		pod.Containers = mergeContainerTails(pod.Containers, tailPod.Containers)
+		pod.InitContainers = mergeContainerTails(pod.InitContainers, tailPod.InitContainers)
+		for _, start := range tailPod.ContainerStarts {
+			pod.ContainerStarts = AddContainerStart(pod.ContainerStarts, start)
+		}
+		for _, kill := range tailPod.OOMKills {
+			pod.OOMKills = AddOOMKill(pod.OOMKills, kill)
+		}
+		pod.EndTime = tailPod.EndTime
+	}
+
+	cached.EndTime = tail.EndTime
+}
+
+// mergeContainerTails appends the samples of each tail container that are newer than those cached
+func mergeContainerTails(containers, tail map[string]ContainerMetrics) map[string]ContainerMetrics {
+	for name, tailContainer := range tail {
+		if containers == nil {
+			containers = make(map[string]ContainerMetrics)
+		}
+		container := containers[name]
+		container.ContainerName = name
+		container.CPUUsageHistory = appendNewer(container.CPUUsageHistory, tailContainer.CPUUsageHistory)
+		container.MemUsageHistory = appendNewer(container.MemUsageHistory, tailContainer.MemUsageHistory)
+		container.StorageUsageHistory = appendNewer(container.StorageUsageHistory, tailContainer.StorageUsageHistory)
+		container.CPUThrottlingHistory = appendNewer(container.CPUThrottlingHistory, tailContainer.CPUThrottlingHistory)
+		containers[name] = container
+	}
+	return containers
+}
+
+// appendNewer appends samples from tail whose timestamp is after the last cached sample
+func appendNewer(history, tail []ResourceUsage) []ResourceUsage {
+	var last time.Time
+	if len(history) > 0 {
+		last = history[len(history)-1].Timestamp
+	}
+
+	for _, usage := range tail {
+		if usage.Timestamp.After(last) {
+			history = append(history, usage)
+		}
+	}
+	return history
+}
+
+// trimWorkloadMetrics drops samples older than cutoff and pods left without samples
+func trimWorkloadMetrics(workloadMetrics *WorkloadMetrics, cutoff time.Time) {
+	pods := workloadMetrics.Pods[:0]
+	for _, pod := range workloadMetrics.Pods {
+		pod.CPUUsageHistory = dropBefore(pod.CPUUsageHistory, cutoff)
+		pod.MemUsageHistory = dropBefore(pod.MemUsageHistory, cutoff)
+		pod.StorageUsageHistory = dropBefore(pod.StorageUsageHistory, cutoff)
+		pod.CPUThrottlingHistory = dropBefore(pod.CPUThrottlingHistory, cutoff)
+		trimContainers(pod.Containers, cutoff)
+		trimContainers(pod.InitContainers, cutoff)
+		if len(pod.CPUUsageHistory) == 0 && len(pod.MemUsageHistory) == 0 {
+			continue
+		}
+		// The start of a container still running at the cutoff is returned again with every tail
+		starts := pod.ContainerStarts[:0]
+		for _, start := range pod.ContainerStarts {
+			if !start.Time.Before(cutoff) {
+				starts = append(starts, start)
+			}
+		}
+		pod.ContainerStarts = starts
+		kills := pod.OOMKills[:0]
+		for _, kill := range pod.OOMKills {
+			if !kill.Time.Before(cutoff) {
+				kills = append(kills, kill)
+			}
+		}
+		pod.OOMKills = kills
+		if pod.StartTime.Before(cutoff) {
+			pod.StartTime = cutoff
+		}
+		pods = append(pods, pod)
+	}
+
+	workloadMetrics.Pods = pods
+	if workloadMetrics.StartTime.Before(cutoff) {
+		workloadMetrics.StartTime = cutoff
+	}
+}
+
+// trimContainers drops samples older than cutoff and containers left without samples
+func trimContainers(containers map[string]ContainerMetrics, cutoff time.Time) {
+	for name, container := range containers {
+		container.CPUUsageHistory = dropBefore(container.CPUUsageHistory, cutoff)
+		container.MemUsageHistory = dropBefore(container.MemUsageHistory, cutoff)
+		container.StorageUsageHistory = dropBefore(container.StorageUsageHistory, cutoff)
+		container.CPUThrottlingHistory = dropBefore(container.CPUThrottlingHistory, cutoff)
+		if len(container.CPUUsageHistory) == 0 && len(container.MemUsageHistory) == 0 {
+			delete(containers, name)
+			continue
+		}
+		containers[name] = container
+	}
+}
+
+// dropBefore removes samples with a timestamp before cutoff from a time-ordered history
+func dropBefore(history []ResourceUsage, cutoff time.Time) []ResourceUsage {
+	i := 0
+	for i < len(history) && history[i].Timestamp.Before(cutoff) {
+		i++
+	}
+	return history[i:]
+}
+
+// countSamples returns the total number of samples held by workload metrics
+func countSamples(workloadMetrics *WorkloadMetrics) int {
+	total := 0
+	for _, pod := range workloadMetrics.Pods {
+		total += len(pod.CPUUsageHistory) + len(pod.MemUsageHistory) + len(pod.StorageUsageHistory) +
+			len(pod.CPUThrottlingHistory)
+		for _, containers := range []map[string]ContainerMetrics{pod.Containers, pod.InitContainers} {
+			for _, container := range containers {
+				total += len(container.CPUUsageHistory) + len(container.MemUsageHistory) + len(container.StorageUsageHistory) +
+					len(container.CPUThrottlingHistory)
+			}
+		}
+	}
+	return total
+}
+
+// copyWorkloadMetrics returns a deep copy so callers cannot modify cached samples
+func copyWorkloadMetrics(workloadMetrics *WorkloadMetrics) *WorkloadMetrics {
+	out := *workloadMetrics
+	out.Pods = make([]PodMetrics, len(workloadMetrics.Pods))
+	for i, pod := range workloadMetrics.Pods {
+		out.Pods[i] = copyPodMetrics(pod)
+	}
+	return &out
+}
+
+// copyPodMetrics returns a deep copy of pod metrics
+func copyPodMetrics(pod PodMetrics) PodMetrics {
+	pod.CPUUsageHistory = append([]ResourceUsage(nil), pod.CPUUsageHistory...)
+	pod.MemUsageHistory = append([]ResourceUsage(nil), pod.MemUsageHistory...)
+	pod.StorageUsageHistory = append([]ResourceUsage(nil), pod.StorageUsageHistory...)
+	pod.CPUThrottlingHistory = append([]ResourceUsage(nil), pod.CPUThrottlingHistory...)
+	pod.ContainerStarts = append([]ContainerStart(nil), pod.ContainerStarts...)
+	pod.OOMKills = append([]OOMKill(nil), pod.OOMKills...)
+	pod.Containers = copyContainers(pod.Containers)
+	pod.InitContainers = copyContainers(pod.InitContainers)
+	return pod
+}
+
+// copyContainers returns a deep copy of container metrics keyed by name
+func copyContainers(containers map[string]ContainerMetrics) map[string]ContainerMetrics {
+	if containers == nil {
+		return nil
+	}
+	copied := make(map[string]ContainerMetrics, len(containers))
+	for name, container := range containers {
+		container.CPUUsageHistory = append([]ResourceUsage(nil), container.CPUUsageHistory...)
+		container.MemUsageHistory = append([]ResourceUsage(nil), container.MemUsageHistory...)
+		container.StorageUsageHistory = append([]ResourceUsage(nil), container.StorageUsageHistory...)
+		container.CPUThrottlingHistory = append([]ResourceUsage(nil), container.CPUThrottlingHistory...)
+		copied[name] = container
+	}
+	return copied
+}
@@ -0,0 +1,255 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSeriesClient returns one sample per query step for each pod, including both ends of the
+// window like a Prometheus range query, and records requested windows
+type fakeSeriesClient struct {
+	now            func() time.Time
+	pods           []string
+	initContainers []string
+	windows        []time.Duration
+	err            error
+}
+
+func (f *fakeSeriesClient) GetPodMetrics(_ context.Context, _, _ string, _ time.Duration) (*PodMetrics, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (f *fakeSeriesClient) GetWorkloadMetrics(
+	_ context.Context,
+	namespace, workloadName, workloadType string,
+	window time.Duration,
+) (*WorkloadMetrics, error) {
+	f.windows = append(f.windows, window)
+	if f.err != nil {
+		return nil, f.err
+	}
+	end := f.now()
+	return f.series(namespace, workloadName, workloadType, end.Add(-window), end, queryStep(window)), nil
+}
+
+// series returns the samples of every pod from start to end at step
+func (f *fakeSeriesClient) series(
+	namespace, workloadName, workloadType string,
+	start, end time.Time,
+	step time.Duration,
+) *WorkloadMetrics {
+	result := &WorkloadMetrics{
+		WorkloadName: workloadName,
+		WorkloadType: workloadType,
+		Namespace:    namespace,
+		StartTime:    start,
+		EndTime:      end,
+	}
+	for _, podName := range f.pods {
+		pod := PodMetrics{PodName: podName, Namespace: namespace, StartTime: start, EndTime: end}
+		for ts := start; !ts.After(end); ts = ts.Add(step) {
+			pod.CPUUsageHistory = append(pod.CPUUsageHistory, ResourceUsage{Timestamp: ts, Value: 0.1, Unit: "cores"})
+			pod.MemUsageHistory = append(pod.MemUsageHistory, ResourceUsage{Timestamp: ts, Value: 1024, Unit: "bytes"})
+		}
+		for _, containerName := range f.initContainers {
+			if pod.InitContainers == nil {
+				pod.InitContainers = make(map[string]ContainerMetrics)
+			}
+			container := ContainerMetrics{ContainerName: containerName}
+			for ts := start; !ts.After(end); ts = ts.Add(step) {
+				container.CPUUsageHistory = append(container.CPUUsageHistory, ResourceUsage{Timestamp: ts, Value: 0.01, Unit: "cores"})
+				container.MemUsageHistory = append(container.MemUsageHistory, ResourceUsage{Timestamp: ts, Value: 512, Unit: "bytes"})
+			}
+			pod.InitContainers[containerName] = container
+		}
+		result.Pods = append(result.Pods, pod)
+	}
+	return result
+}
+
+// fakeTailClient also fetches tails at a given step and records the requested steps
+type fakeTailClient struct {
+	*fakeSeriesClient
+	steps []time.Duration
+}
+
+func (f *fakeTailClient) GetWorkloadMetricsTail(
+	_ context.Context,
+	namespace, workloadName, workloadType string,
+	start time.Time,
+	step time.Duration,
+) (*WorkloadMetrics, error) {
+	f.steps = append(f.steps, step)
+	return f.series(namespace, workloadName, workloadType, start, f.now(), step), nil
+}
+
+func newTestCache(pods []string, ttl time.Duration, maxSamples int) (*CachingClient, *fakeSeriesClient, *time.Time) {
+	clock := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	fake := &fakeSeriesClient{now: func() time.Time { return clock }, pods: pods}
+	cache := NewCachingClient(fake, ttl, maxSamples)
+	cache.now = fake.now
+	return cache, fake, &clock
+}
+
+func TestCachingClient_FetchesOnlyTail(t *testing.T) {
+	cache, fake, clock := newTestCache([]string{"pod-a"}, time.Hour, 0)
+	ctx := context.Background()
+
+	first, err := cache.GetWorkloadMetrics(ctx, "default", "app", "Deployment", 2*time.Hour)
+	require.NoError(t, err)
+	require.Len(t, first.Pods, 1)
+	assert.Len(t, first.Pods[0].CPUUsageHistory, 121)
+
+	// Within one step nothing is fetched
+	_, err = cache.GetWorkloadMetrics(ctx, "default", "app", "Deployment", 2*time.Hour)
+	require.NoError(t, err)
+	assert.Len(t, fake.windows, 1)
+
+	*clock = clock.Add(10 * time.Minute)
+	second, err := cache.GetWorkloadMetrics(ctx, "default", "app", "Deployment", 2*time.Hour)
+	require.NoError(t, err)
+
+	require.Len(t, fake.windows, 2)
+	assert.Equal(t, 10*time.Minute, fake.windows[1])
+
+	// Old samples are trimmed to the window and new ones appended without duplicates
+	history := second.Pods[0].CPUUsageHistory
+	assert.Len(t, history, 121)
+	assert.Equal(t, clock.Add(-2*time.Hour), history[0].Timestamp)
+	assert.Equal(t, *clock, history[len(history)-1].Timestamp)
+}
+
+func TestCachingClient_RefetchesAfterTTL(t *testing.T) {
+	cache, fake, clock := newTestCache([]string{"pod-a"}, 30*time.Minute, 0)
+	ctx := context.Background()
+
+	_, err := cache.GetWorkloadMetrics(ctx, "default", "app", "Deployment", 2*time.Hour)
+	require.NoError(t, err)
+
+	*clock = clock.Add(31 * time.Minute)
+	_, err = cache.GetWorkloadMetrics(ctx, "default", "app", "Deployment", 2*time.Hour)
+	require.NoError(t, err)
+
+	require.Len(t, fake.windows, 2)
+	assert.Equal(t, 2*time.Hour, fake.windows[1])
+}
+
+func TestCachingClient_ReturnsCopies(t *testing.T) {
+	cache, _, _ := newTestCache([]string{"pod-a"}, time.Hour, 0)
+	ctx := context.Background()
+
+	first, err := cache.GetWorkloadMetrics(ctx, "default", "app", "Deployment", time.Hour)
+	require.NoError(t, err)
+	first.Pods[0].CPUUsageHistory[0].Value = 42
+
+	second, err := cache.GetWorkloadMetrics(ctx, "default", "app", "Deployment", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 0.1, second.Pods[0].CPUUsageHistory[0].Value)
+}
+
+func TestCachingClient_EvictsLeastRecentlyUsed(t *testing.T) {
+	// Each workload holds 2 x 61 samples, so only two fit
+	cache, fake, _ := newTestCache([]string{"pod-a"}, time.Hour, 250)
+	ctx := context.Background()
+
+	for _, name := range []string{"a", "b", "c"} {
+		_, err := cache.GetWorkloadMetrics(ctx, "default", name, "Deployment", time.Hour)
+		require.NoError(t, err)
+	}
+	assert.LessOrEqual(t, cache.samples, 250)
+	assert.Len(t, cache.entries, 2)
+
+	// "a" was evicted and must be fetched again
+	_, err := cache.GetWorkloadMetrics(ctx, "default", "a", "Deployment", time.Hour)
+	require.NoError(t, err)
+	assert.Len(t, fake.windows, 4)
+}
+
+func TestCachingClient_MergesInitContainerTail(t *testing.T) {
+	cache, fake, clock := newTestCache([]string{"pod-a"}, time.Hour, 0)
+	fake.initContainers = []string{"sidecar"}
+	ctx := context.Background()
+
+	_, err := cache.GetWorkloadMetrics(ctx, "default", "app", "Deployment", 2*time.Hour)
+	require.NoError(t, err)
+
+	*clock = clock.Add(10 * time.Minute)
+	result, err := cache.GetWorkloadMetrics(ctx, "default", "app", "Deployment", 2*time.Hour)
+	require.NoError(t, err)
+
+	sidecar := result.Pods[0].InitContainers["sidecar"]
+	assert.Len(t, sidecar.CPUUsageHistory, 121)
+	assert.Equal(t, *clock, sidecar.MemUsageHistory[len(sidecar.MemUsageHistory)-1].Timestamp)
+	assert.Equal(t, 4*121, cache.samples)
+}
+
+func TestCachingClient_LongWindowTailKeepsStep(t *testing.T) {
+	cache, fake, clock := newTestCache([]string{"pod-a"}, 2*time.Hour, 0)
+	tails := &fakeTailClient{fakeSeriesClient: fake}
+	cache.client = tails
+	ctx := context.Background()
+	window := 30 * 24 * time.Hour
+	step := queryStep(window)
+	require.Greater(t, step, time.Minute)
+
+	first, err := cache.GetWorkloadMetrics(ctx, "default", "app", "Deployment", window)
+	require.NoError(t, err)
+	firstLen := len(first.Pods[0].CPUUsageHistory)
+
+	// Less than a step later nothing is fetched
+	*clock = clock.Add(step - time.Second)
+	_, err = cache.GetWorkloadMetrics(ctx, "default", "app", "Deployment", window)
+	require.NoError(t, err)
+	assert.Empty(t, tails.steps)
+
+	*clock = clock.Add(time.Hour)
+	second, err := cache.GetWorkloadMetrics(ctx, "default", "app", "Deployment", window)
+	require.NoError(t, err)
+	assert.Len(t, fake.windows, 1)
+	assert.Equal(t, []time.Duration{step}, tails.steps)
+
+	// The tail is sampled at the step of the cached window instead of every minute
+	history := second.Pods[0].CPUUsageHistory
+	assert.InDelta(t, firstLen, len(history), 1)
+	for i := 1; i < len(history); i++ {
+		assert.LessOrEqual(t, history[i].Timestamp.Sub(history[i-1].Timestamp), step)
+	}
+	assert.Greater(t, history[len(history)-1].Timestamp.Sub(history[len(history)-2].Timestamp), time.Minute)
+	assert.Equal(t, countSamples(second), cache.samples)
+}
+
+func TestCachingClient_RefetchesLongWindowWithoutTailClient(t *testing.T) {
+	cache, fake, clock := newTestCache([]string{"pod-a"}, time.Hour, 0)
+	ctx := context.Background()
+	window := 30 * 24 * time.Hour
+
+	_, err := cache.GetWorkloadMetrics(ctx, "default", "app", "Deployment", window)
+	require.NoError(t, err)
+
+	// A one-minute tail would not match the coarser step of the cached window
+	*clock = clock.Add(10 * time.Minute)
+	_, err = cache.GetWorkloadMetrics(ctx, "default", "app", "Deployment", window)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{window, window}, fake.windows)
+}
+
+func TestCachingClient_DropsEntryOnFailedFetch(t *testing.T) {
+	cache, fake, _ := newTestCache([]string{"pod-a"}, time.Hour, 0)
+	fake.err = fmt.Errorf("prometheus unavailable")
+	ctx := context.Background()
+
+	_, err := cache.GetWorkloadMetrics(ctx, "default", "app", "Deployment", time.Hour)
+	require.Error(t, err)
+	assert.Empty(t, cache.entries)
+	assert.Zero(t, cache.lru.Len())
+
+	fake.err = nil
+	_, err = cache.GetWorkloadMetrics(ctx, "default", "app", "Deployment", time.Hour)
+	require.NoError(t, err)
+	assert.Len(t, cache.entries, 1)
+}
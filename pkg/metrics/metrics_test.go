@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMockMetricsClient_GetPodMetrics(t *testing.T) {
@@ -147,3 +148,81 @@ func TestNewPrometheusClient_EmptyURL(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, client)
 }
+
+func TestQueryRange(t *testing.T) {
+	end := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	// Short windows keep one-minute steps
+	assert.Equal(t, time.Minute, queryRange(end.Add(-24*time.Hour), end).Step)
+	assert.Equal(t, time.Minute, queryRange(end.Add(-6*24*time.Hour), end).Step)
+
+	// Longer windows, up to the 90 days the webhook allows, stay below 11,000 points per series
+	for _, days := range []int{7, 8, 30, 90} {
+		window := time.Duration(days) * 24 * time.Hour
+		queryRange := queryRange(end.Add(-window), end)
+		assert.Equal(t, end.Add(-window), queryRange.Start)
+		assert.Equal(t, end, queryRange.End)
+		assert.Zero(t, queryRange.Step%time.Second, "%d days", days)
+		assert.LessOrEqual(t, int(window/queryRange.Step)+1, 11000, "%d days", days)
+	}
+}
+
+func TestRateLimitedRoundTripper_LimitsEveryQuery(t *testing.T) {
+	start := time.Now().Add(-10 * time.Minute).Truncate(time.Minute)
+
+	// A generous limit lets one pod fetch send all of its range queries
+	unlimited := &containerRoundTripper{start: start}
+	client, err := NewPrometheusClient("http://prometheus:9090", NewRateLimitedRoundTripper(unlimited, 1000, 100))
+	require.NoError(t, err)
+	_, err = client.GetPodMetrics(context.Background(), "default", "web-0", time.Hour)
+	require.NoError(t, err)
+	require.Greater(t, len(unlimited.queries), 3)
+
+	// One token per hour and a burst of three: the fetch stops after three queries
+	limited := &containerRoundTripper{start: start}
+	client, err = NewPrometheusClient("http://prometheus:9090", NewRateLimitedRoundTripper(limited, 1.0/3600, 3))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.GetPodMetrics(ctx, "default", "web-0", time.Hour)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "rate limiter")
+	assert.Len(t, limited.queries, 3)
+}
+
+func TestAddContainerStart(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+
+	var starts []ContainerStart
+	starts = AddContainerStart(starts, ContainerStart{Time: base.Add(2 * time.Hour), Container: "app"})
+	starts = AddContainerStart(starts, ContainerStart{Time: base, Container: "app"})
+	starts = AddContainerStart(starts, ContainerStart{Time: base.Add(time.Hour), Container: "app"})
+	starts = AddContainerStart(starts, ContainerStart{Time: base.Add(2 * time.Hour).UTC(), Container: "app"})
+	starts = AddContainerStart(starts, ContainerStart{Time: base.Add(2 * time.Hour), Container: "proxy"})
+
+	assert.Equal(t, []ContainerStart{
+		{Time: base, Container: "app"},
+		{Time: base.Add(time.Hour), Container: "app"},
+		{Time: base.Add(2 * time.Hour), Container: "app"},
+		{Time: base.Add(2 * time.Hour), Container: "proxy"},
+	}, starts)
+}
+
+func TestAddOOMKill(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+
+	var kills []OOMKill
+	kills = AddOOMKill(kills, OOMKill{Time: base.Add(time.Hour), MemoryLimit: 512})
+	kills = AddOOMKill(kills, OOMKill{Time: base, MemoryLimit: 256})
+	kills = AddOOMKill(kills, OOMKill{Time: base.Add(time.Hour).UTC()})
+	// Containers killed at the same time are separate kills
+	kills = AddOOMKill(kills, OOMKill{Time: base, Container: "sidecar", MemoryLimit: 64})
+	kills = AddOOMKill(kills, OOMKill{Time: base, Container: "sidecar"})
+
+	assert.Equal(t, []OOMKill{
+		{Time: base, MemoryLimit: 256},
+		{Time: base, Container: "sidecar", MemoryLimit: 64},
+		{Time: base.Add(time.Hour), MemoryLimit: 512},
+	}, kills)
+}
@@ -19,10 +19,11 @@ const (
 // MockMetricsClient provides fake metrics for testing
 type MockMetricsClient struct {
 	// Configuration for generating fake data
-	BaseCPU    float64
-	BaseMemory float64
-	Variance   float64
-	PodCount   int // Number of pods to simulate in workload, defaults to 3
+	BaseCPU     float64
+	BaseMemory  float64
+	BaseStorage float64 // Ephemeral-storage usage in bytes, no storage samples when zero
+	Variance    float64
+	PodCount    int // Number of pods to simulate in workload, defaults to 3
 }
 
 // NewMockMetricsClient creates a mock metrics client for testing
@@ -52,7 +53,7 @@ func (m *MockMetricsClient) GetPodMetrics(
 		dataPoints = 1
 	}
 
-	var cpuHistory, memHistory []ResourceUsage
+	var cpuHistory, memHistory, storageHistory []ResourceUsage
 
 	for i := 0; i < dataPoints; i++ {
 		timestamp := start.Add(time.Duration(i) * interval)
@@ -94,15 +95,26 @@ func (m *MockMetricsClient) GetPodMetrics(
 			Value:     memValue,
 			Unit:      "bytes",
 		})
+
+		// Ephemeral storage changes slowly, so use a tenth of the variance
+		if m.BaseStorage > 0 {
+			storageVariance := (rand.Float64() - varianceOffset) * varianceMultiplier * m.Variance / 10
+			storageHistory = append(storageHistory, ResourceUsage{
+				Timestamp: timestamp,
+				Value:     m.BaseStorage * (1 + storageVariance),
+				Unit:      "bytes",
+			})
+		}
 	}
 
 	return &PodMetrics{
-		PodName:         podName,
-		Namespace:       namespace,
-		CPUUsageHistory: cpuHistory,
-		MemUsageHistory: memHistory,
-		StartTime:       start,
-		EndTime:         now,
+		PodName:             podName,
+		Namespace:           namespace,
+		CPUUsageHistory:     cpuHistory,
+		MemUsageHistory:     memHistory,
+		StorageUsageHistory: storageHistory,
+		StartTime:           start,
+		EndTime:             now,
 	}, nil
 }
 
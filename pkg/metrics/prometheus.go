@@ -3,7 +3,11 @@ package metrics
 import (
 	"context"
 	"fmt"
+	"math"
 	"net/http"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/api"
@@ -15,6 +19,10 @@ const (
 	httpTimeoutSeconds = 30
 	mockCPUValue       = 0.05     // 50m cores
 	mockMemoryValue    = 67108864 // 64Mi bytes
+	// maxQueryPoints keeps range queries below the limit of 11,000 points per series Prometheus enforces
+	maxQueryPoints = 10000
+	// minRateRange is the shortest range rates are taken over, several scrapes at common intervals
+	minRateRange = 5 * time.Minute
 )
 
 // PrometheusClient implements MetricsClient interface for Prometheus
@@ -39,6 +47,33 @@ func NewPrometheusClient(prometheusURL string, roundTripper http.RoundTripper) (
 	}, nil
 }
 
+// queryRange returns the range of a query from start to end at the step of queryStep
+func queryRange(start, end time.Time) v1.Range {
+	return v1.Range{Start: start, End: end, Step: queryStep(end.Sub(start))}
+}
+
+// queryStep returns one minute, or a coarser whole-second step when the window holds more than
+// maxQueryPoints minutes
+func queryStep(window time.Duration) time.Duration {
+	step := time.Minute
+	if perPoint := window / maxQueryPoints; perPoint > step {
+		step = perPoint.Truncate(time.Second) + time.Second
+	}
+	return step
+}
+
+// rateRange returns the range selector of rates queried at step. It covers the whole step, so bursts
+// between two points still count towards one of them.
+func rateRange(step time.Duration) string {
+	return model.Duration(max(step, minRateRange)).String()
+}
+
+// gaugeRange returns the range selector of gauges queried at step. Taking the maximum over it keeps
+// the peaks between two points instead of a single instant sample per step.
+func gaugeRange(step time.Duration) string {
+	return model.Duration(step).String()
+}
+
 // GetPodMetrics retrieves metrics for a specific pod
 func (p *PrometheusClient) GetPodMetrics(
 	ctx context.Context,
@@ -47,49 +82,68 @@ func (p *PrometheusClient) GetPodMetrics(
 ) (*PodMetrics, error) {
 	endTime := time.Now()
 	startTime := endTime.Add(-window)
+	timeRange := queryRange(startTime, endTime)
 
 	// Get CPU usage metrics
 	cpuQuery := fmt.Sprintf(
-		`rate(container_cpu_usage_seconds_total{namespace="%s",pod="%s",container!="POD",container!=""}[5m])`,
-		namespace, podName,
+		`sum by (pod, container) (rate(container_cpu_usage_seconds_total{namespace="%s",pod="%s",container!="POD",container!=""}[%s]))`,
+		namespace, podName, rateRange(timeRange.Step),
 	)
 
-	cpuResult, _, err := p.queryAPI.QueryRange(ctx, cpuQuery, v1.Range{
-		Start: startTime,
-		End:   endTime,
-		Step:  time.Minute,
-	})
+	cpuResult, _, err := p.queryAPI.QueryRange(ctx, cpuQuery, timeRange)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query CPU metrics: %w", err)
 	}
 
 	// Get Memory usage metrics
 	memQuery := fmt.Sprintf(
-		`container_memory_working_set_bytes{namespace="%s",pod="%s",container!="POD",container!=""}`,
-		namespace, podName,
+		`sum by (pod, container) (max_over_time(container_memory_working_set_bytes{namespace="%s",pod="%s",container!="POD",container!=""}[%s]))`,
+		namespace, podName, gaugeRange(timeRange.Step),
 	)
 
-	memResult, _, err := p.queryAPI.QueryRange(ctx, memQuery, v1.Range{
-		Start: startTime,
-		End:   endTime,
-		Step:  time.Minute,
-	})
+	memResult, _, err := p.queryAPI.QueryRange(ctx, memQuery, timeRange)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query memory metrics: %w", err)
 	}
 
-	// Convert results to our internal format
-	cpuHistory := p.convertMatrixToUsageHistory(cpuResult, "cores")
-	memHistory := p.convertMatrixToUsageHistory(memResult, "bytes")
+	// Get ephemeral-storage usage of the container filesystems in the pod
+	storageQuery := fmt.Sprintf(
+		`sum by (pod, container) (max_over_time(container_fs_usage_bytes{namespace="%s",pod="%s",container!="POD",container!=""}[%s]))`,
+		namespace, podName, gaugeRange(timeRange.Step),
+	)
 
-	return &PodMetrics{
-		PodName:         podName,
-		Namespace:       namespace,
-		CPUUsageHistory: cpuHistory,
-		MemUsageHistory: memHistory,
-		StartTime:       startTime,
-		EndTime:         endTime,
-	}, nil
+	storageResult, _, err := p.queryAPI.QueryRange(ctx, storageQuery, timeRange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ephemeral-storage metrics: %w", err)
+	}
+
+	// Get the share of CFS periods in which each of the pod's containers was throttled
+	throttlingQuery := fmt.Sprintf(
+		`sum by (pod, container) (rate(container_cpu_cfs_throttled_periods_total{namespace="%s",pod="%s",container!="POD",container!=""}[%s])) / `+
+			`sum by (pod, container) (rate(container_cpu_cfs_periods_total{namespace="%s",pod="%s",container!="POD",container!=""}[%s]))`,
+		namespace, podName, rateRange(timeRange.Step), namespace, podName, rateRange(timeRange.Step),
+	)
+
+	throttlingResult, _, err := p.queryAPI.QueryRange(ctx, throttlingQuery, timeRange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query CPU throttling metrics: %w", err)
+	}
+
+	// Convert results to our internal format, one history per container
+	podMetrics := &PodMetrics{
+		PodName:    podName,
+		Namespace:  namespace,
+		Containers: make(map[string]ContainerMetrics),
+		StartTime:  startTime,
+		EndTime:    endTime,
+	}
+	setContainerHistories(podMetrics.Containers, p.containerSeries(cpuResult, "cores")[podName], setCPUUsage)
+	setContainerHistories(podMetrics.Containers, p.containerSeries(memResult, "bytes")[podName], setMemoryUsage)
+	setContainerHistories(podMetrics.Containers, p.containerSeries(storageResult, "bytes")[podName], setStorageUsage)
+	setContainerHistories(podMetrics.Containers, p.containerSeries(throttlingResult, "ratio")[podName], setCPUThrottling)
+	sumContainerUsage(podMetrics)
+
+	return podMetrics, nil
 }
 
 // GetWorkloadMetrics retrieves aggregated metrics for a workload
@@ -99,41 +153,83 @@ func (p *PrometheusClient) GetWorkloadMetrics(
 	window time.Duration,
 ) (*WorkloadMetrics, error) {
 	endTime := time.Now()
-	startTime := endTime.Add(-window)
+	return p.workloadMetrics(ctx, namespace, workloadName, workloadType, queryRange(endTime.Add(-window), endTime))
+}
+
+// GetWorkloadMetricsTail retrieves metrics for a workload from start until now at the given step, so
+// samples added to a window queried earlier keep its density
+func (p *PrometheusClient) GetWorkloadMetricsTail(
+	ctx context.Context,
+	namespace, workloadName, workloadType string,
+	start time.Time,
+	step time.Duration,
+) (*WorkloadMetrics, error) {
+	timeRange := v1.Range{Start: start, End: time.Now(), Step: step}
+	return p.workloadMetrics(ctx, namespace, workloadName, workloadType, timeRange)
+}
+
+// workloadMetrics queries the metrics of a workload over timeRange
+func (p *PrometheusClient) workloadMetrics(
+	ctx context.Context,
+	namespace, workloadName, workloadType string,
+	timeRange v1.Range,
+) (*WorkloadMetrics, error) {
+	startTime, endTime := timeRange.Start, timeRange.End
 
 	// Build label selector based on workload type
 	labelSelector := p.buildWorkloadSelector(workloadName, workloadType)
 
-	// Get CPU usage metrics for all pods in the workload
+	// Get CPU usage metrics for all pods in the workload, excluding init and sidecar containers
 	cpuQuery := fmt.Sprintf(
-		`sum by (pod) (rate(container_cpu_usage_seconds_total{namespace="%s",%s,container!="POD",container!=""}[5m]))`,
-		namespace, labelSelector,
+		`sum by (pod, container) (rate(container_cpu_usage_seconds_total{namespace="%s",%s,container!="POD",container!=""}[%s]) `+
+			`unless on (namespace, pod, container) %s)`,
+		namespace, labelSelector, rateRange(timeRange.Step), initContainerInfo(namespace),
 	)
 
-	cpuResult, _, err := p.queryAPI.QueryRange(ctx, cpuQuery, v1.Range{
-		Start: startTime,
-		End:   endTime,
-		Step:  time.Minute,
-	})
+	cpuResult, _, err := p.queryAPI.QueryRange(ctx, cpuQuery, timeRange)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query workload CPU metrics: %w", err)
 	}
 
-	// Get Memory usage metrics for all pods in the workload
+	// Get Memory usage metrics for all pods in the workload, excluding init and sidecar containers
 	memQuery := fmt.Sprintf(
-		`sum by (pod) (container_memory_working_set_bytes{namespace="%s",%s,container!="POD",container!=""})`,
-		namespace, labelSelector,
+		`sum by (pod, container) (max_over_time(container_memory_working_set_bytes{namespace="%s",%s,container!="POD",container!=""}[%s]) `+
+			`unless on (namespace, pod, container) %s)`,
+		namespace, labelSelector, gaugeRange(timeRange.Step), initContainerInfo(namespace),
 	)
 
-	memResult, _, err := p.queryAPI.QueryRange(ctx, memQuery, v1.Range{
-		Start: startTime,
-		End:   endTime,
-		Step:  time.Minute,
-	})
+	memResult, _, err := p.queryAPI.QueryRange(ctx, memQuery, timeRange)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query workload memory metrics: %w", err)
 	}
 
+	// Get ephemeral-storage usage for all pods in the workload, excluding init and sidecar containers
+	storageQuery := fmt.Sprintf(
+		`sum by (pod, container) (max_over_time(container_fs_usage_bytes{namespace="%s",%s,container!="POD",container!=""}[%s]) `+
+			`unless on (namespace, pod, container) %s)`,
+		namespace, labelSelector, gaugeRange(timeRange.Step), initContainerInfo(namespace),
+	)
+
+	storageResult, _, err := p.queryAPI.QueryRange(ctx, storageQuery, timeRange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workload ephemeral-storage metrics: %w", err)
+	}
+
+	// Get the share of throttled CFS periods of each container in the workload, excluding init and sidecar containers
+	throttlingQuery := fmt.Sprintf(
+		`sum by (pod, container) (rate(container_cpu_cfs_throttled_periods_total{namespace="%s",%s,container!="POD",container!=""}[%s]) `+
+			`unless on (namespace, pod, container) %s) / `+
+			`sum by (pod, container) (rate(container_cpu_cfs_periods_total{namespace="%s",%s,container!="POD",container!=""}[%s]) `+
+			`unless on (namespace, pod, container) %s)`,
+		namespace, labelSelector, rateRange(timeRange.Step), initContainerInfo(namespace),
+		namespace, labelSelector, rateRange(timeRange.Step), initContainerInfo(namespace),
+	)
+
+	throttlingResult, _, err := p.queryAPI.QueryRange(ctx, throttlingQuery, timeRange)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workload CPU throttling metrics: %w", err)
+	}
+
 	// Convert to WorkloadMetrics format
 	workloadMetrics := &WorkloadMetrics{
 		WorkloadName: workloadName,
@@ -143,57 +239,294 @@ func (p *PrometheusClient) GetWorkloadMetrics(
 		EndTime:      endTime,
 	}
 
-	// Group metrics by pod
+	// Group metrics by pod and container
 	podMetricsMap := make(map[string]*PodMetrics)
+	podFor := func(podName string) *PodMetrics {
+		if _, exists := podMetricsMap[podName]; !exists {
+			podMetricsMap[podName] = &PodMetrics{
+				PodName:    podName,
+				Namespace:  namespace,
+				Containers: make(map[string]ContainerMetrics),
+				StartTime:  startTime,
+				EndTime:    endTime,
+			}
+		}
+		return podMetricsMap[podName]
+	}
+
+	// Process CPU and memory metrics
+	for podName, containers := range p.containerSeries(cpuResult, "cores") {
+		setContainerHistories(podFor(podName).Containers, containers, setCPUUsage)
+	}
+	for podName, containers := range p.containerSeries(memResult, "bytes") {
+		setContainerHistories(podFor(podName).Containers, containers, setMemoryUsage)
+	}
+
+	// Process ephemeral-storage metrics for pods that report CPU or memory usage
+	for podName, containers := range p.containerSeries(storageResult, "bytes") {
+		if podMetrics, exists := podMetricsMap[podName]; exists {
+			setContainerHistories(podMetrics.Containers, containers, setStorageUsage)
+		}
+	}
+
+	// Process CPU throttling metrics for pods that report CPU or memory usage
+	for podName, containers := range p.containerSeries(throttlingResult, "ratio") {
+		if podMetrics, exists := podMetricsMap[podName]; exists {
+			setContainerHistories(podMetrics.Containers, containers, setCPUThrottling)
+		}
+	}
+
+	// Pod-level usage is the sum of the containers, as sum by (pod) would have returned it
+	for _, podMetrics := range podMetricsMap {
+		sumContainerUsage(podMetrics)
+	}
+
+	// Init and sidecar containers are analyzed separately from the regular containers
+	if err := p.addInitContainerMetrics(ctx, namespace, labelSelector, timeRange, podMetricsMap); err != nil {
+		return nil, err
+	}
+
+	if err := p.addContainerStarts(ctx, namespace, labelSelector, timeRange, podMetricsMap); err != nil {
+		return nil, err
+	}
+
+	if err := p.addOOMKills(ctx, namespace, timeRange, podMetricsMap); err != nil {
+		return nil, err
+	}
+
+	// Convert map to slice
+	for _, podMetrics := range podMetricsMap {
+		workloadMetrics.Pods = append(workloadMetrics.Pods, *podMetrics)
+	}
+
+	return workloadMetrics, nil
+}
 
-	// Process CPU metrics
-	if matrix, ok := cpuResult.(model.Matrix); ok {
-		for _, series := range matrix {
-			podName := string(series.Metric["pod"])
-			if podName == "" {
+// addInitContainerMetrics queries per-container usage of init and sidecar containers and adds it to the pods
+func (p *PrometheusClient) addInitContainerMetrics(
+	ctx context.Context,
+	namespace, labelSelector string,
+	timeRange v1.Range,
+	podMetricsMap map[string]*PodMetrics,
+) error {
+	cpuQuery := fmt.Sprintf(
+		`sum by (pod, container) (rate(container_cpu_usage_seconds_total{namespace="%s",%s,container!="POD",container!=""}[%s]) `+
+			`and on (namespace, pod, container) %s)`,
+		namespace, labelSelector, rateRange(timeRange.Step), initContainerInfo(namespace),
+	)
+	cpuResult, _, err := p.queryAPI.QueryRange(ctx, cpuQuery, timeRange)
+	if err != nil {
+		return fmt.Errorf("failed to query init container CPU metrics: %w", err)
+	}
+
+	memQuery := fmt.Sprintf(
+		`sum by (pod, container) (max_over_time(container_memory_working_set_bytes{namespace="%s",%s,container!="POD",container!=""}[%s]) `+
+			`and on (namespace, pod, container) %s)`,
+		namespace, labelSelector, gaugeRange(timeRange.Step), initContainerInfo(namespace),
+	)
+	memResult, _, err := p.queryAPI.QueryRange(ctx, memQuery, timeRange)
+	if err != nil {
+		return fmt.Errorf("failed to query init container memory metrics: %w", err)
+	}
+
+	storageQuery := fmt.Sprintf(
+		`sum by (pod, container) (max_over_time(container_fs_usage_bytes{namespace="%s",%s,container!="POD",container!=""}[%s]) `+
+			`and on (namespace, pod, container) %s)`,
+		namespace, labelSelector, gaugeRange(timeRange.Step), initContainerInfo(namespace),
+	)
+	storageResult, _, err := p.queryAPI.QueryRange(ctx, storageQuery, timeRange)
+	if err != nil {
+		return fmt.Errorf("failed to query init container ephemeral-storage metrics: %w", err)
+	}
+
+	addSeries := func(result model.Value, unit string, set func(*ContainerMetrics, []ResourceUsage)) {
+		for podName, containers := range p.containerSeries(result, unit) {
+			// Pods still running their init containers have no regular usage to analyze yet
+			podMetrics, exists := podMetricsMap[podName]
+			if !exists {
 				continue
 			}
-
-			if _, exists := podMetricsMap[podName]; !exists {
-				podMetricsMap[podName] = &PodMetrics{
-					PodName:   podName,
-					Namespace: namespace,
-					StartTime: startTime,
-					EndTime:   endTime,
-				}
+			if podMetrics.InitContainers == nil {
+				podMetrics.InitContainers = make(map[string]ContainerMetrics)
 			}
+			setContainerHistories(podMetrics.InitContainers, containers, set)
+		}
+	}
+
+	addSeries(cpuResult, "cores", setCPUUsage)
+	addSeries(memResult, "bytes", setMemoryUsage)
+	addSeries(storageResult, "bytes", setStorageUsage)
+
+	return nil
+}
+
+// addContainerStarts adds the starts of the regular containers, taken from the distinct values of the
+// start-time metric, so restarts within the window are included
+func (p *PrometheusClient) addContainerStarts(
+	ctx context.Context,
+	namespace, labelSelector string,
+	timeRange v1.Range,
+	podMetricsMap map[string]*PodMetrics,
+) error {
+	startQuery := fmt.Sprintf(
+		`max by (pod, container) (container_start_time_seconds{namespace="%s",%s,container!="POD",container!=""} `+
+			`unless on (namespace, pod, container) %s)`,
+		namespace, labelSelector, initContainerInfo(namespace),
+	)
+	startResult, _, err := p.queryAPI.QueryRange(ctx, startQuery, timeRange)
+	if err != nil {
+		return fmt.Errorf("failed to query container start times: %w", err)
+	}
+
+	matrix, ok := startResult.(model.Matrix)
+	if !ok {
+		return nil
+	}
+	for _, series := range matrix {
+		podMetrics, exists := podMetricsMap[string(series.Metric["pod"])]
+		if !exists {
+			continue
+		}
+		for _, value := range series.Values {
+			podMetrics.ContainerStarts = AddContainerStart(podMetrics.ContainerStarts, ContainerStart{
+				Time:      time.Unix(int64(value.Value), 0),
+				Container: string(series.Metric["container"]),
+			})
+		}
+	}
+
+	return nil
+}
 
-			podMetricsMap[podName].CPUUsageHistory = p.convertSamplePairToUsageHistory(series.Values, "cores")
+// AddContainerStart inserts a container start into a list ordered by time, ignoring starts already recorded
+func AddContainerStart(starts []ContainerStart, start ContainerStart) []ContainerStart {
+	i := sort.Search(len(starts), func(i int) bool { return !starts[i].Time.Before(start.Time) })
+	for ; i < len(starts) && starts[i].Time.Equal(start.Time); i++ {
+		if starts[i].Container == start.Container {
+			return starts
 		}
 	}
+	starts = append(starts, ContainerStart{})
+	copy(starts[i+1:], starts[i:])
+	starts[i] = start
+	return starts
+}
+
+// addOOMKills adds OOM kills of the regular containers from kube-state-metrics. Kill times are the
+// distinct termination timestamps reported while the last termination reason is OOMKilled, and the
+// memory limit is the one reported at that time. kube-state-metrics does not label these series with
+// the workload, so they are selected by the names of the pods already found.
+func (p *PrometheusClient) addOOMKills(
+	ctx context.Context,
+	namespace string,
+	timeRange v1.Range,
+	podMetricsMap map[string]*PodMetrics,
+) error {
+	if len(podMetricsMap) == 0 {
+		return nil
+	}
+	pods := podSelector(podMetricsMap)
+
+	oomQuery := fmt.Sprintf(
+		`max by (pod, container) (kube_pod_container_status_last_terminated_timestamp{namespace="%s",%s} `+
+			`and on (namespace, pod, container) (kube_pod_container_status_last_terminated_reason{namespace="%s",reason="OOMKilled"} == 1) `+
+			`unless on (namespace, pod, container) %s)`,
+		namespace, pods, namespace, initContainerInfo(namespace),
+	)
+	oomResult, _, err := p.queryAPI.QueryRange(ctx, oomQuery, timeRange)
+	if err != nil {
+		return fmt.Errorf("failed to query OOM kills: %w", err)
+	}
+
+	oomMatrix, ok := oomResult.(model.Matrix)
+	if !ok || len(oomMatrix) == 0 {
+		return nil
+	}
 
-	// Process Memory metrics
-	if matrix, ok := memResult.(model.Matrix); ok {
-		for _, series := range matrix {
-			podName := string(series.Metric["pod"])
-			if podName == "" {
+	limitQuery := fmt.Sprintf(
+		`max by (pod, container) (kube_pod_container_resource_limits{namespace="%s",%s,resource="memory"})`,
+		namespace, pods,
+	)
+	limitResult, _, err := p.queryAPI.QueryRange(ctx, limitQuery, timeRange)
+	if err != nil {
+		return fmt.Errorf("failed to query memory limits: %w", err)
+	}
+
+	limits := make(map[string][]model.SamplePair)
+	if limitMatrix, ok := limitResult.(model.Matrix); ok {
+		for _, series := range limitMatrix {
+			limits[string(series.Metric["pod"])+"/"+string(series.Metric["container"])] = series.Values
+		}
+	}
+
+	for _, series := range oomMatrix {
+		podMetrics, exists := podMetricsMap[string(series.Metric["pod"])]
+		if !exists {
+			continue
+		}
+		containerLimits := limits[string(series.Metric["pod"])+"/"+string(series.Metric["container"])]
+
+		for _, value := range series.Values {
+			killTime := time.Unix(int64(value.Value), 0)
+			if killTime.Before(timeRange.Start) {
 				continue
 			}
+			podMetrics.OOMKills = AddOOMKill(podMetrics.OOMKills, OOMKill{
+				Time:        killTime,
+				Container:   string(series.Metric["container"]),
+				MemoryLimit: limitAt(containerLimits, killTime),
+			})
+		}
+	}
 
-			if _, exists := podMetricsMap[podName]; !exists {
-				podMetricsMap[podName] = &PodMetrics{
-					PodName:   podName,
-					Namespace: namespace,
-					StartTime: startTime,
-					EndTime:   endTime,
-				}
-			}
+	return nil
+}
+
+// limitAt returns the last value reported at or before t, or the first value when all are later
+func limitAt(values []model.SamplePair, t time.Time) float64 {
+	if len(values) == 0 {
+		return 0
+	}
 
-			podMetricsMap[podName].MemUsageHistory = p.convertSamplePairToUsageHistory(series.Values, "bytes")
+	limit := float64(values[0].Value)
+	for _, value := range values {
+		if value.Timestamp.Time().After(t) {
+			break
 		}
+		limit = float64(value.Value)
 	}
+	return limit
+}
 
-	// Convert map to slice
-	for _, podMetrics := range podMetricsMap {
-		workloadMetrics.Pods = append(workloadMetrics.Pods, *podMetrics)
+// AddOOMKill inserts an OOM kill into a list ordered by time, ignoring kills already recorded
+func AddOOMKill(kills []OOMKill, kill OOMKill) []OOMKill {
+	i := sort.Search(len(kills), func(i int) bool { return !kills[i].Time.Before(kill.Time) })
+	for ; i < len(kills) && kills[i].Time.Equal(kill.Time); i++ {
+		if kills[i].Container == kill.Container {
+			return kills
+		}
 	}
+	kills = append(kills, OOMKill{})
+	copy(kills[i+1:], kills[i:])
+	kills[i] = kill
+	return kills
+}
 
-	return workloadMetrics, nil
+// podSelector matches the pods of podMetricsMap by name
+func podSelector(podMetricsMap map[string]*PodMetrics) string {
+	names := make([]string, 0, len(podMetricsMap))
+	for name := range podMetricsMap {
+		// The escapes of the regular expression are escaped again inside the PromQL string
+		names = append(names, strings.ReplaceAll(regexp.QuoteMeta(name), `\`, `\\`))
+	}
+	sort.Strings(names)
+	return fmt.Sprintf(`pod=~"%s"`, strings.Join(names, "|"))
+}
+
+// initContainerInfo selects the kube-state-metrics series that identify init containers, including
+// native sidecars. Without kube-state-metrics all containers are treated as regular containers.
+func initContainerInfo(namespace string) string {
+	return fmt.Sprintf(`kube_pod_init_container_info{namespace="%s"}`, namespace)
 }
 
 // Helper methods
@@ -212,20 +545,72 @@ func (p *PrometheusClient) buildWorkloadSelector(workloadName, workloadType stri
 	}
 }
 
-func (p *PrometheusClient) convertMatrixToUsageHistory(result model.Value, unit string) []ResourceUsage {
-	var history []ResourceUsage
-	if matrix, ok := result.(model.Matrix); ok {
-		for _, series := range matrix {
-			for _, value := range series.Values {
-				history = append(history, ResourceUsage{
-					Timestamp: value.Timestamp.Time(),
-					Value:     float64(value.Value),
-					Unit:      unit,
-				})
+// containerSeries converts the series of a range query into usage histories keyed by pod and container
+// name. Series without a pod or container label are skipped.
+func (p *PrometheusClient) containerSeries(result model.Value, unit string) map[string]map[string][]ResourceUsage {
+	pods := make(map[string]map[string][]ResourceUsage)
+	matrix, ok := result.(model.Matrix)
+	if !ok {
+		return pods
+	}
+	for _, series := range matrix {
+		podName := string(series.Metric["pod"])
+		containerName := string(series.Metric["container"])
+		if podName == "" || containerName == "" {
+			continue
+		}
+		if pods[podName] == nil {
+			pods[podName] = make(map[string][]ResourceUsage)
+		}
+		pods[podName][containerName] = p.convertSamplePairToUsageHistory(series.Values, unit)
+	}
+	return pods
+}
+
+// setContainerHistories stores the history of each container with set
+func setContainerHistories(
+	containers map[string]ContainerMetrics,
+	histories map[string][]ResourceUsage,
+	set func(*ContainerMetrics, []ResourceUsage),
+) {
+	for name, history := range histories {
+		container := containers[name]
+		container.ContainerName = name
+		set(&container, history)
+		containers[name] = container
+	}
+}
+
+func setCPUUsage(c *ContainerMetrics, h []ResourceUsage)      { c.CPUUsageHistory = h }
+func setMemoryUsage(c *ContainerMetrics, h []ResourceUsage)   { c.MemUsageHistory = h }
+func setStorageUsage(c *ContainerMetrics, h []ResourceUsage)  { c.StorageUsageHistory = h }
+func setCPUThrottling(c *ContainerMetrics, h []ResourceUsage) { c.CPUThrottlingHistory = h }
+
+// sumContainerUsage sets the pod-level CPU, memory and ephemeral-storage histories to the sum of the
+// container histories at each timestamp, and the CPU throttling history to the highest ratio of any container
+func sumContainerUsage(podMetrics *PodMetrics) {
+	combine := func(history func(ContainerMetrics) []ResourceUsage, merge func(a, b float64) float64) []ResourceUsage {
+		totals := make(map[int64]int)
+		var combined []ResourceUsage
+		for _, container := range podMetrics.Containers {
+			for _, usage := range history(container) {
+				if i, exists := totals[usage.Timestamp.UnixNano()]; exists {
+					combined[i].Value = merge(combined[i].Value, usage.Value)
+					continue
+				}
+				totals[usage.Timestamp.UnixNano()] = len(combined)
+				combined = append(combined, usage)
 			}
 		}
+		sort.Slice(combined, func(i, j int) bool { return combined[i].Timestamp.Before(combined[j].Timestamp) })
+		return combined
 	}
-	return history
+	sum := func(a, b float64) float64 { return a + b }
+
+	podMetrics.CPUUsageHistory = combine(func(c ContainerMetrics) []ResourceUsage { return c.CPUUsageHistory }, sum)
+	podMetrics.MemUsageHistory = combine(func(c ContainerMetrics) []ResourceUsage { return c.MemUsageHistory }, sum)
+	podMetrics.StorageUsageHistory = combine(func(c ContainerMetrics) []ResourceUsage { return c.StorageUsageHistory }, sum)
+	podMetrics.CPUThrottlingHistory = combine(func(c ContainerMetrics) []ResourceUsage { return c.CPUThrottlingHistory }, math.Max)
 }
 
 func (p *PrometheusClient) convertSamplePairToUsageHistory(values []model.SamplePair, unit string) []ResourceUsage {
@@ -0,0 +1,224 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// containerRoundTripper answers Prometheus range queries for container usage and throttling with one series per
+// container of pod web-0, and every other query with an empty matrix
+type containerRoundTripper struct {
+	start   time.Time
+	queries []string
+	steps   []string
+}
+
+func (c *containerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.ParseForm(); err != nil {
+		return nil, err
+	}
+	query := req.Form.Get("query")
+	c.queries = append(c.queries, query)
+	c.steps = append(c.steps, req.Form.Get("step"))
+
+	// Usage and throttled ratio of the app and proxy containers by metric
+	usage := map[string][2]float64{
+		"container_cpu_usage_seconds_total":         {0.2, 0.05},
+		"container_memory_working_set_bytes":        {256, 64},
+		"container_fs_usage_bytes":                  {10, 5},
+		"container_cpu_cfs_throttled_periods_total": {0.3, 0.1},
+	}
+	result := []map[string]any{}
+	for metric, values := range usage {
+		if !strings.Contains(query, metric) || strings.Contains(query, "and on") ||
+			(strings.Contains(query, " / ") && !strings.Contains(metric, "throttled")) {
+			continue
+		}
+		for i, container := range []string{"app", "proxy"} {
+			var samples [][]any
+			for step := 0; step < 3; step++ {
+				// The proxy container misses the last sample
+				if container == "proxy" && step == 2 {
+					continue
+				}
+				timestamp := float64(c.start.Add(time.Duration(step) * time.Minute).Unix())
+				samples = append(samples, []any{timestamp, strconv.FormatFloat(values[i], 'f', -1, 64)})
+			}
+			result = append(result, map[string]any{
+				"metric": map[string]string{"pod": "web-0", "container": container},
+				"values": samples,
+			})
+		}
+	}
+
+	return matrixResponse(req, result)
+}
+
+// matrixResponse answers a range query with the given series
+func matrixResponse(req *http.Request, result []map[string]any) (*http.Response, error) {
+	body, err := json.Marshal(map[string]any{
+		"status": "success",
+		"data":   map[string]any{"resultType": "matrix", "result": result},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(string(body))),
+		Request:    req,
+	}, nil
+}
+
+func newContainerClient(t *testing.T) (*PrometheusClient, *containerRoundTripper) {
+	roundTripper := &containerRoundTripper{start: time.Now().Add(-10 * time.Minute).Truncate(time.Minute)}
+	client, err := NewPrometheusClient("http://prometheus:9090", roundTripper)
+	require.NoError(t, err)
+	return client, roundTripper
+}
+
+func TestPrometheusClient_GetPodMetrics_PerContainer(t *testing.T) {
+	client, roundTripper := newContainerClient(t)
+
+	podMetrics, err := client.GetPodMetrics(context.Background(), "default", "web-0", time.Hour)
+	require.NoError(t, err)
+
+	// Usage queries merge the series of each container, such as those of a restart, by container
+	for _, query := range roundTripper.queries[:3] {
+		assert.True(t, strings.HasPrefix(query, "sum by (pod, container) ("), query)
+	}
+
+	require.Len(t, podMetrics.Containers, 2)
+	app := podMetrics.Containers["app"]
+	assert.Equal(t, "app", app.ContainerName)
+	assert.Len(t, app.CPUUsageHistory, 3)
+	assert.Len(t, podMetrics.Containers["proxy"].MemUsageHistory, 2)
+
+	// Pod-level usage is summed per timestamp instead of interleaving the containers
+	require.Len(t, podMetrics.CPUUsageHistory, 3)
+	assert.InDelta(t, 0.25, podMetrics.CPUUsageHistory[0].Value, 1e-9)
+	assert.InDelta(t, 0.2, podMetrics.CPUUsageHistory[2].Value, 1e-9)
+	assert.Equal(t, roundTripper.start, podMetrics.CPUUsageHistory[0].Timestamp)
+	assert.InDelta(t, 320, podMetrics.MemUsageHistory[1].Value, 1e-9)
+	assert.InDelta(t, 15, podMetrics.StorageUsageHistory[0].Value, 1e-9)
+	assert.Equal(t, "bytes", podMetrics.StorageUsageHistory[0].Unit)
+
+	// Throttling is kept per container, the pod-level history holds the most throttled one
+	assert.Contains(t, roundTripper.queries[3], "sum by (pod, container) (rate(container_cpu_cfs_throttled_periods_total")
+	assert.InDelta(t, 0.1, podMetrics.Containers["proxy"].CPUThrottlingHistory[0].Value, 1e-9)
+	require.Len(t, podMetrics.CPUThrottlingHistory, 3)
+	assert.InDelta(t, 0.3, podMetrics.CPUThrottlingHistory[0].Value, 1e-9)
+	assert.Equal(t, "ratio", podMetrics.CPUThrottlingHistory[0].Unit)
+}
+
+func TestPrometheusClient_GetWorkloadMetrics_PerContainer(t *testing.T) {
+	client, roundTripper := newContainerClient(t)
+
+	workloadMetrics, err := client.GetWorkloadMetrics(context.Background(), "default", "web", "Deployment", time.Hour)
+	require.NoError(t, err)
+
+	// Usage queries keep the container label
+	for _, query := range roundTripper.queries[:3] {
+		assert.Contains(t, query, "sum by (pod, container)")
+	}
+
+	require.Len(t, workloadMetrics.Pods, 1)
+	podMetrics := workloadMetrics.Pods[0]
+	assert.Equal(t, "web-0", podMetrics.PodName)
+	require.Len(t, podMetrics.Containers, 2)
+	assert.InDelta(t, 64, podMetrics.Containers["proxy"].MemUsageHistory[0].Value, 1e-9)
+	assert.InDelta(t, 5, podMetrics.Containers["proxy"].StorageUsageHistory[0].Value, 1e-9)
+	require.Len(t, podMetrics.CPUUsageHistory, 3)
+	assert.InDelta(t, 0.25, podMetrics.CPUUsageHistory[0].Value, 1e-9)
+	assert.InDelta(t, 0.3, podMetrics.Containers["app"].CPUThrottlingHistory[0].Value, 1e-9)
+	assert.InDelta(t, 0.3, podMetrics.CPUThrottlingHistory[2].Value, 1e-9)
+	assert.Empty(t, podMetrics.InitContainers)
+}
+
+func TestPrometheusClient_GetWorkloadMetrics_LongWindowRanges(t *testing.T) {
+	client, roundTripper := newContainerClient(t)
+
+	// 90 days are queried at 778s steps
+	_, err := client.GetWorkloadMetrics(context.Background(), "default", "web", "Deployment", 90*24*time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, "778", roundTripper.steps[0])
+
+	// Rates cover the whole step and gauges keep the peak of each step
+	assert.Contains(t, roundTripper.queries[0], `rate(container_cpu_usage_seconds_total{namespace="default",deployment="web",container!="POD",container!=""}[12m58s])`)
+	assert.Contains(t, roundTripper.queries[1], `max_over_time(container_memory_working_set_bytes{namespace="default",deployment="web",container!="POD",container!=""}[12m58s])`)
+	assert.Contains(t, roundTripper.queries[2], `max_over_time(container_fs_usage_bytes{namespace="default",deployment="web",container!="POD",container!=""}[12m58s])`)
+	assert.Contains(t, roundTripper.queries[3], `rate(container_cpu_cfs_throttled_periods_total{namespace="default",deployment="web",container!="POD",container!=""}[12m58s])`)
+	assert.Contains(t, roundTripper.queries[3], `rate(container_cpu_cfs_periods_total{namespace="default",deployment="web",container!="POD",container!=""}[12m58s])`)
+
+	// Short windows take rates over five minutes and gauges over their one-minute steps
+	roundTripper.queries, roundTripper.steps = nil, nil
+	_, err = client.GetWorkloadMetrics(context.Background(), "default", "web", "Deployment", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, "60", roundTripper.steps[0])
+	assert.Contains(t, roundTripper.queries[0], `container!=""}[5m])`)
+	assert.Contains(t, roundTripper.queries[1], `container!=""}[1m])`)
+}
+
+// oomRoundTripper answers like containerRoundTripper and reports an OOM kill of the app container at a
+// 512Mi limit, for series without workload labels as kube-state-metrics exports them
+type oomRoundTripper struct {
+	containerRoundTripper
+}
+
+func (o *oomRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.ParseForm(); err != nil {
+		return nil, err
+	}
+	query := req.Form.Get("query")
+	value := ""
+	switch {
+	case strings.Contains(query, "kube_pod_container_status_last_terminated_timestamp"):
+		value = strconv.FormatInt(o.start.Add(time.Minute).Unix(), 10)
+	case strings.Contains(query, "kube_pod_container_resource_limits"):
+		value = strconv.Itoa(512 * 1024 * 1024)
+	default:
+		return o.containerRoundTripper.RoundTrip(req)
+	}
+	o.queries = append(o.queries, query)
+
+	var result []map[string]any
+	if strings.Contains(query, `pod=~"web-0"`) && !strings.Contains(query, "deployment=") {
+		result = append(result, map[string]any{
+			"metric": map[string]string{"pod": "web-0", "container": "app"},
+			"values": [][]any{{float64(o.start.Add(2 * time.Minute).Unix()), value}},
+		})
+	}
+	return matrixResponse(req, result)
+}
+
+func TestPrometheusClient_GetWorkloadMetrics_OOMKills(t *testing.T) {
+	roundTripper := &oomRoundTripper{containerRoundTripper{start: time.Now().Add(-10 * time.Minute).Truncate(time.Minute)}}
+	client, err := NewPrometheusClient("http://prometheus:9090", roundTripper)
+	require.NoError(t, err)
+
+	workloadMetrics, err := client.GetWorkloadMetrics(context.Background(), "default", "web", "Deployment", time.Hour)
+	require.NoError(t, err)
+
+	// kube-state-metrics series are selected by the pods found instead of the workload label
+	require.Len(t, workloadMetrics.Pods, 1)
+	kills := workloadMetrics.Pods[0].OOMKills
+	require.Len(t, kills, 1)
+	assert.Equal(t, "app", kills[0].Container)
+	assert.Equal(t, roundTripper.start.Add(time.Minute), kills[0].Time)
+	assert.InDelta(t, 512*1024*1024, kills[0].MemoryLimit, 1e-9)
+}
+
+func TestPodSelector(t *testing.T) {
+	selector := podSelector(map[string]*PodMetrics{"web-1": nil, "web.0": nil})
+	assert.Equal(t, `pod=~"web-1|web\\.0"`, selector)
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultQueriesPerSecond = 10
+	defaultQueryBurst       = 20
+)
+
+// rateLimitedRoundTripper takes a token from a token-bucket limiter for every request to the
+// metrics backend, so that concurrent workload analysis cannot overload it. Limiting at the HTTP
+// level counts each PromQL query, however many queries one workload fetch fans out to.
+type rateLimitedRoundTripper struct {
+	next    http.RoundTripper
+	limiter *rate.Limiter
+}
+
+// NewRateLimitedRoundTripper wraps a round tripper so that it sends at most qps requests per second
+// with the given burst. Non-positive values fall back to the defaults. A single instance is meant
+// to be shared by every reconcile worker.
+func NewRateLimitedRoundTripper(next http.RoundTripper, qps float64, burst int) http.RoundTripper {
+	if qps <= 0 {
+		qps = defaultQueriesPerSecond
+	}
+	if burst <= 0 {
+		burst = defaultQueryBurst
+	}
+
+	return &rateLimitedRoundTripper{
+		next:    next,
+		limiter: rate.NewLimiter(rate.Limit(qps), burst),
+	}
+}
+
+// RoundTrip waits for a token and then sends the request
+func (t *rateLimitedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, fmt.Errorf("metrics rate limiter: %w", err)
+	}
+	return t.next.RoundTrip(req)
+}
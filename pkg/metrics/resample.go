@@ -0,0 +1,260 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// DefaultMaxGapSteps is the longest run of missing intervals Resample fills by interpolation
+const DefaultMaxGapSteps = 5
+
+// Series is a usage history resampled onto a fixed grid. Interval i is centred on Start + i*Step, so
+// samples with jittery timestamps still land in the interval of the grid point they belong to.
+type Series struct {
+	// Start is the first grid point
+	Start time.Time
+	// Step is the distance between grid points
+	Step time.Duration
+	// Values holds the average usage of every interval. Gaps of up to the maximum length are
+	// interpolated between their neighbours, longer gaps hold NaN.
+	Values []float64
+	// Observed reports which intervals held at least one sample
+	Observed []bool
+	// Gaps lists the runs of intervals without samples, in time order
+	Gaps []Gap
+	// Coverage is the share of intervals that held at least one sample, from 0 to 1
+	Coverage float64
+}
+
+// Gap is a run of intervals without samples, such as a scrape outage or a pod restart
+type Gap struct {
+	Start time.Time
+	End   time.Time
+	// Filled reports whether the gap was short enough to be interpolated
+	Filled bool
+}
+
+// Coverage reports how much of the window a pod's usage histories covered before normalization
+type Coverage struct {
+	// CPU and Memory are the shares of the window in which the histories held samples, from 0 to 1
+	CPU    float64
+	Memory float64
+	// Gaps counts the CPU and memory gaps too long to be interpolated
+	Gaps int
+}
+
+// Min returns the lower of the CPU and memory coverage
+func (c Coverage) Min() float64 {
+	return math.Min(c.CPU, c.Memory)
+}
+
+// InferStep returns the typical interval between samples: the median of the median intervals of the
+// histories, rounded to whole seconds to absorb timestamp jitter. It returns zero when no history holds
+// two samples at different times.
+func InferStep(histories ...[]ResourceUsage) time.Duration {
+	var steps []time.Duration
+	for _, history := range histories {
+		timestamps := make([]time.Time, len(history))
+		for i, usage := range history {
+			timestamps[i] = usage.Timestamp
+		}
+		sort.Slice(timestamps, func(i, j int) bool { return timestamps[i].Before(timestamps[j]) })
+
+		var intervals []time.Duration
+		for i := 1; i < len(timestamps); i++ {
+			if interval := timestamps[i].Sub(timestamps[i-1]); interval > 0 {
+				intervals = append(intervals, interval)
+			}
+		}
+		if len(intervals) > 0 {
+			steps = append(steps, medianDuration(intervals))
+		}
+	}
+	if len(steps) == 0 {
+		return 0
+	}
+	step := medianDuration(steps)
+	if step >= time.Second {
+		step = step.Round(time.Second)
+	}
+	return step
+}
+
+// Resample averages the samples of a history into intervals of step covering the window from start to
+// end; a zero start or end stands for the oldest or latest sample. Samples before start move the grid back
+// by whole steps, samples after end extend it. Gaps of up to maxGapSteps intervals between two observed
+// intervals are filled by linear interpolation.
+func Resample(history []ResourceUsage, start, end time.Time, step time.Duration, maxGapSteps int) Series {
+	if len(history) == 0 || step <= 0 {
+		return Series{Start: start, Step: step}
+	}
+
+	first, last := history[0].Timestamp, history[0].Timestamp
+	for _, usage := range history {
+		if usage.Timestamp.Before(first) {
+			first = usage.Timestamp
+		}
+		if usage.Timestamp.After(last) {
+			last = usage.Timestamp
+		}
+	}
+	if start.IsZero() {
+		start = first
+	}
+	if first.Before(start) {
+		start = start.Add(-step * ((start.Sub(first) + step/2) / step))
+	}
+	if end.IsZero() {
+		end = last
+	}
+
+	index := func(timestamp time.Time) int {
+		return int((timestamp.Sub(start) + step/2) / step)
+	}
+	intervals := max(int((end.Sub(start)+step-1)/step), index(last)+1)
+	sums := make([]float64, intervals)
+	counts := make([]int, intervals)
+	for _, usage := range history {
+		i := index(usage.Timestamp)
+		sums[i] += usage.Value
+		counts[i]++
+	}
+
+	series := Series{
+		Start:    start,
+		Step:     step,
+		Values:   make([]float64, intervals),
+		Observed: make([]bool, intervals),
+	}
+	observed := 0
+	for i := range series.Values {
+		if counts[i] == 0 {
+			series.Values[i] = math.NaN()
+			continue
+		}
+		series.Values[i] = sums[i] / float64(counts[i])
+		series.Observed[i] = true
+		observed++
+	}
+	series.Coverage = float64(observed) / float64(intervals)
+
+	// Mark every run of empty intervals and interpolate the bounded ones between observed neighbours
+	for i := 0; i < intervals; {
+		if series.Observed[i] {
+			i++
+			continue
+		}
+		j := i
+		for j < intervals && !series.Observed[j] {
+			j++
+		}
+
+		gap := Gap{Start: series.gridPoint(i), End: series.gridPoint(j)}
+		if i > 0 && j < intervals && j-i <= maxGapSteps {
+			from, to := series.Values[i-1], series.Values[j]
+			for k := i; k < j; k++ {
+				fraction := float64(k-i+1) / float64(j-i+1)
+				series.Values[k] = from + fraction*(to-from)
+			}
+			gap.Filled = true
+		}
+		series.Gaps = append(series.Gaps, gap)
+		i = j
+	}
+
+	return series
+}
+
+// Usage returns one sample per interval holding a value, observed or interpolated, at its grid point
+func (s Series) Usage(unit string) []ResourceUsage {
+	usage := make([]ResourceUsage, 0, len(s.Values))
+	for i, value := range s.Values {
+		if !math.IsNaN(value) {
+			usage = append(usage, ResourceUsage{Timestamp: s.gridPoint(i), Value: value, Unit: unit})
+		}
+	}
+	return usage
+}
+
+// UnfilledGaps counts the gaps too long to be interpolated
+func (s Series) UnfilledGaps() int {
+	count := 0
+	for _, gap := range s.Gaps {
+		if !gap.Filled {
+			count++
+		}
+	}
+	return count
+}
+
+// gridPoint returns the time interval i is centred on
+func (s Series) gridPoint(i int) time.Time {
+	return s.Start.Add(time.Duration(i) * s.Step)
+}
+
+// NormalizePodMetrics resamples every usage history of a pod onto a common grid at the pod's typical
+// sample interval over its window, filling gaps of up to maxGapSteps intervals, and records the coverage
+// of the CPU and memory histories. Pods whose step cannot be inferred are returned unchanged.
+func NormalizePodMetrics(pod PodMetrics, maxGapSteps int) PodMetrics {
+	step := InferStep(pod.CPUUsageHistory, pod.MemUsageHistory)
+	if step <= 0 {
+		return pod
+	}
+
+	// Without a window the grid starts at the oldest CPU or memory sample
+	start := pod.StartTime
+	if start.IsZero() {
+		for _, history := range [][]ResourceUsage{pod.CPUUsageHistory, pod.MemUsageHistory} {
+			for _, usage := range history {
+				if start.IsZero() || usage.Timestamp.Before(start) {
+					start = usage.Timestamp
+				}
+			}
+		}
+	}
+
+	resample := func(history []ResourceUsage) ([]ResourceUsage, Series) {
+		if len(history) == 0 {
+			return history, Series{}
+		}
+		series := Resample(history, start, pod.EndTime, step, maxGapSteps)
+		return series.Usage(history[0].Unit), series
+	}
+
+	var cpu, memory Series
+	pod.CPUUsageHistory, cpu = resample(pod.CPUUsageHistory)
+	pod.MemUsageHistory, memory = resample(pod.MemUsageHistory)
+	pod.StorageUsageHistory, _ = resample(pod.StorageUsageHistory)
+	pod.CPUThrottlingHistory, _ = resample(pod.CPUThrottlingHistory)
+
+	resampleContainers := func(containers map[string]ContainerMetrics) map[string]ContainerMetrics {
+		if containers == nil {
+			return nil
+		}
+		resampled := make(map[string]ContainerMetrics, len(containers))
+		for name, container := range containers {
+			container.CPUUsageHistory, _ = resample(container.CPUUsageHistory)
+			container.MemUsageHistory, _ = resample(container.MemUsageHistory)
+			container.StorageUsageHistory, _ = resample(container.StorageUsageHistory)
+			container.CPUThrottlingHistory, _ = resample(container.CPUThrottlingHistory)
+			resampled[name] = container
+		}
+		return resampled
+	}
+	pod.Containers = resampleContainers(pod.Containers)
+	pod.InitContainers = resampleContainers(pod.InitContainers)
+
+	pod.Coverage = &Coverage{
+		CPU:    cpu.Coverage,
+		Memory: memory.Coverage,
+		Gaps:   cpu.UnfilledGaps() + memory.UnfilledGaps(),
+	}
+	return pod
+}
+
+// medianDuration returns the median of durations, which must not be empty
+func medianDuration(durations []time.Duration) time.Duration {
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return durations[len(durations)/2]
+}
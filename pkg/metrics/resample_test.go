@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var resampleStart = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// usageHistory samples value(i) every step from resampleStart for n steps, skipping the steps for which it
+// returns NaN
+func usageHistory(n int, step time.Duration, value func(i int) float64, unit string) []ResourceUsage {
+	var usage []ResourceUsage
+	for i := 0; i < n; i++ {
+		if v := value(i); !math.IsNaN(v) {
+			usage = append(usage, ResourceUsage{Timestamp: resampleStart.Add(time.Duration(i) * step), Value: v, Unit: unit})
+		}
+	}
+	return usage
+}
+
+func TestInferStep(t *testing.T) {
+	usage := usageHistory(10, time.Minute, func(i int) float64 { return 1 }, "cores")
+	assert.Equal(t, time.Minute, InferStep(usage))
+
+	// A few long gaps do not change the typical interval
+	sparse := append(usage[:5:5], usage[8:]...)
+	assert.Equal(t, time.Minute, InferStep(sparse, nil))
+
+	assert.Zero(t, InferStep(nil, usage[:1]))
+}
+
+func TestResample_Gaps(t *testing.T) {
+	// Minutes 3 and 4 are missing, as are minutes 10 to 19
+	usage := usageHistory(30, time.Minute, func(i int) float64 {
+		if i == 3 || i == 4 || (i >= 10 && i < 20) {
+			return math.NaN()
+		}
+		return float64(i)
+	}, "cores")
+
+	series := Resample(usage, resampleStart, resampleStart.Add(30*time.Minute), time.Minute, DefaultMaxGapSteps)
+	require.Len(t, series.Values, 30)
+	assert.InDelta(t, 18.0/30, series.Coverage, 1e-9)
+
+	require.Len(t, series.Gaps, 2)
+	assert.True(t, series.Gaps[0].Filled)
+	assert.Equal(t, resampleStart.Add(3*time.Minute), series.Gaps[0].Start)
+	assert.Equal(t, resampleStart.Add(5*time.Minute), series.Gaps[0].End)
+	assert.False(t, series.Gaps[1].Filled)
+	assert.Equal(t, 1, series.UnfilledGaps())
+
+	// The short gap is interpolated, the long one is left empty
+	assert.InDelta(t, 3, series.Values[3], 1e-9)
+	assert.InDelta(t, 4, series.Values[4], 1e-9)
+	assert.False(t, series.Observed[3])
+	assert.True(t, math.IsNaN(series.Values[15]))
+	assert.Len(t, series.Usage("cores"), 20)
+}
+
+func TestResample_Jitter(t *testing.T) {
+	// Timestamps taken a little off the grid still land in one interval each
+	usage := usageHistory(10, time.Minute, func(i int) float64 { return float64(i) }, "cores")
+	for i := range usage {
+		usage[i].Timestamp = usage[i].Timestamp.Add(time.Duration(i%3-1) * 300 * time.Millisecond)
+	}
+	assert.Equal(t, time.Minute, InferStep(usage))
+
+	series := Resample(usage, time.Time{}, time.Time{}, InferStep(usage), DefaultMaxGapSteps)
+	require.Len(t, series.Values, 10)
+	assert.Equal(t, 1.0, series.Coverage)
+	assert.Empty(t, series.Gaps)
+	for i, value := range series.Values {
+		assert.InDelta(t, float64(i), value, 1e-9)
+	}
+}
+
+func TestResample_WindowBeyondSamples(t *testing.T) {
+	// Usage only in the second half of the window covers half of it, with the leading gap left empty
+	usage := usageHistory(60, time.Minute, func(i int) float64 {
+		if i < 30 {
+			return math.NaN()
+		}
+		return 1
+	}, "cores")
+
+	series := Resample(usage, resampleStart, resampleStart.Add(time.Hour), time.Minute, DefaultMaxGapSteps)
+	assert.InDelta(t, 0.5, series.Coverage, 1e-9)
+	require.Len(t, series.Gaps, 1)
+	assert.False(t, series.Gaps[0].Filled, "gaps at the edge of the window have no neighbour to interpolate from")
+
+	assert.Empty(t, Resample(nil, resampleStart, resampleStart.Add(time.Hour), time.Minute, DefaultMaxGapSteps).Values)
+}
+
+func TestNormalizePodMetrics(t *testing.T) {
+	pod := PodMetrics{
+		PodName:   "test-pod",
+		StartTime: resampleStart,
+		EndTime:   resampleStart.Add(time.Hour),
+		CPUUsageHistory: usageHistory(60, time.Minute, func(i int) float64 {
+			if i >= 20 && i < 40 {
+				return math.NaN()
+			}
+			return 0.5
+		}, "cores"),
+		MemUsageHistory: usageHistory(60, time.Minute, func(i int) float64 { return 1024 }, "bytes"),
+		InitContainers: map[string]ContainerMetrics{
+			"init": {CPUUsageHistory: usageHistory(5, time.Minute, func(i int) float64 { return 1 }, "cores")},
+		},
+	}
+	original := len(pod.CPUUsageHistory)
+
+	normalized := NormalizePodMetrics(pod, DefaultMaxGapSteps)
+	require.NotNil(t, normalized.Coverage)
+	assert.InDelta(t, 40.0/60, normalized.Coverage.CPU, 1e-9)
+	assert.Equal(t, 1.0, normalized.Coverage.Memory)
+	assert.InDelta(t, 40.0/60, normalized.Coverage.Min(), 1e-9)
+	assert.Equal(t, 1, normalized.Coverage.Gaps)
+	assert.Len(t, normalized.CPUUsageHistory, 40)
+	assert.Len(t, normalized.MemUsageHistory, 60)
+	assert.Len(t, normalized.InitContainers["init"].CPUUsageHistory, 5)
+
+	// The original metrics are left untouched
+	assert.Nil(t, pod.Coverage)
+	assert.Len(t, pod.CPUUsageHistory, original)
+
+	// Without two samples there is no step to resample at
+	single := PodMetrics{CPUUsageHistory: pod.CPUUsageHistory[:1]}
+	assert.Nil(t, NormalizePodMetrics(single, DefaultMaxGapSteps).Coverage)
+}
@@ -1,17 +1,73 @@
 package metrics
 
 import (
+	"context"
 	"time"
 )
 
+// Client is implemented by every metrics backend
+type Client interface {
+	GetPodMetrics(ctx context.Context, namespace, podName string, window time.Duration) (*PodMetrics, error)
+	GetWorkloadMetrics(
+		ctx context.Context,
+		namespace, workloadName, workloadType string,
+		window time.Duration,
+	) (*WorkloadMetrics, error)
+}
+
 // PodMetrics represents resource usage metrics for a pod
 type PodMetrics struct {
-	PodName         string
-	Namespace       string
+	PodName   string
+	Namespace string
+	// CPUUsageHistory and MemUsageHistory hold the usage of the regular containers summed per sample
 	CPUUsageHistory []ResourceUsage
 	MemUsageHistory []ResourceUsage
-	StartTime       time.Time
-	EndTime         time.Time
+	// StorageUsageHistory holds ephemeral-storage usage of the container filesystems in bytes
+	StorageUsageHistory []ResourceUsage
+	// Containers holds the usage of each regular container keyed by container name, which the
+	// pod-level histories above are the sum of. Backends without container detail leave it nil.
+	Containers map[string]ContainerMetrics
+	// CPUThrottlingHistory holds the highest share of CFS periods in which any regular container was
+	// throttled, as a ratio between 0 and 1. Containers without a CPU limit report no samples.
+	CPUThrottlingHistory []ResourceUsage
+	// InitContainers holds init and sidecar container usage keyed by container name.
+	// It is not included in CPUUsageHistory and MemUsageHistory.
+	InitContainers map[string]ContainerMetrics
+	// ContainerStarts holds the starts of the regular containers, in ascending time order
+	ContainerStarts []ContainerStart
+	// OOMKills holds OOM kills of the regular containers, in ascending time order
+	OOMKills  []OOMKill
+	StartTime time.Time
+	EndTime   time.Time
+	// Coverage reports how much of the window the usage histories covered, nil until NormalizePodMetrics
+	// resampled them
+	Coverage *Coverage
+}
+
+// ContainerStart records the start of a container, including restarts
+type ContainerStart struct {
+	Time time.Time
+	// Container is the name of the started container, empty when unknown
+	Container string
+}
+
+// OOMKill records a container OOM kill and the memory limit in force at the time
+type OOMKill struct {
+	Time time.Time
+	// Container is the name of the killed container, empty when unknown
+	Container string
+	// MemoryLimit is the container's memory limit in bytes, zero when unknown
+	MemoryLimit float64
+}
+
+// ContainerMetrics represents resource usage metrics for a single container
+type ContainerMetrics struct {
+	ContainerName       string
+	CPUUsageHistory     []ResourceUsage
+	MemUsageHistory     []ResourceUsage
+	StorageUsageHistory []ResourceUsage
+	// CPUThrottlingHistory holds the share of CFS periods in which the container was throttled
+	CPUThrottlingHistory []ResourceUsage
 }
 
 // WorkloadMetrics represents aggregated metrics for a workload
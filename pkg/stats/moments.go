@@ -0,0 +1,64 @@
+// pkg/stats/moments.go
+package stats
+
+import "math"
+
+// Moments accumulates the count, mean and variance of a stream of values in constant memory with
+// Welford's algorithm. Moments of separate streams, such as the replicas of a workload, merge exactly.
+// The zero value is ready to use.
+type Moments struct {
+	count float64
+	mean  float64
+	// m2 is the sum of squared deviations from the mean
+	m2 float64
+}
+
+// Add adds a value. NaN values are ignored.
+func (m *Moments) Add(value float64) {
+	if math.IsNaN(value) {
+		return
+	}
+	m.count++
+	delta := value - m.mean
+	m.mean += delta / m.count
+	m.m2 += delta * (value - m.mean)
+}
+
+// Merge adds the values of other, as Chan et al.'s parallel algorithm combines partial moments
+func (m *Moments) Merge(other Moments) {
+	if other.count == 0 {
+		return
+	}
+	if m.count == 0 {
+		*m = other
+		return
+	}
+	count := m.count + other.count
+	delta := other.mean - m.mean
+	m.mean += delta * other.count / count
+	m.m2 += other.m2 + delta*delta*m.count*other.count/count
+	m.count = count
+}
+
+// Count returns the number of values added
+func (m Moments) Count() int {
+	return int(m.count)
+}
+
+// Mean returns the arithmetic mean, or zero without values
+func (m Moments) Mean() float64 {
+	return m.mean
+}
+
+// Variance returns the sample variance, or zero with fewer than two values
+func (m Moments) Variance() float64 {
+	if m.count < 2 {
+		return 0
+	}
+	return m.m2 / (m.count - 1)
+}
+
+// StdDev returns the sample standard deviation, or zero with fewer than two values
+func (m Moments) StdDev() float64 {
+	return math.Sqrt(m.Variance())
+}
@@ -0,0 +1,43 @@
+package stats
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoments(t *testing.T) {
+	var moments Moments
+	assert.Zero(t, moments.Mean())
+	assert.Zero(t, moments.StdDev())
+
+	for _, value := range []float64{2, 4, 4, 4, 5, 5, 7, 9, math.NaN()} {
+		moments.Add(value)
+	}
+	assert.Equal(t, 8, moments.Count())
+	assert.InDelta(t, 5, moments.Mean(), 1e-12)
+	assert.InDelta(t, 32.0/7, moments.Variance(), 1e-12)
+	assert.InDelta(t, math.Sqrt(32.0/7), moments.StdDev(), 1e-12)
+}
+
+func TestMoments_Merge(t *testing.T) {
+	var all, first, second Moments
+	for i := 0; i < 100; i++ {
+		value := math.Sin(float64(i)) + float64(i)/10
+		all.Add(value)
+		if i < 30 {
+			first.Add(value)
+		} else {
+			second.Add(value)
+		}
+	}
+
+	var merged Moments
+	merged.Merge(first)
+	merged.Merge(second)
+	merged.Merge(Moments{})
+	assert.Equal(t, all.Count(), merged.Count())
+	assert.InDelta(t, all.Mean(), merged.Mean(), 1e-9)
+	assert.InDelta(t, all.Variance(), merged.Variance(), 1e-9)
+}
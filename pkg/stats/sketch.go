@@ -0,0 +1,244 @@
+// pkg/stats/sketch.go
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	// DefaultRelativeAccuracy bounds the relative error of quantiles once a sketch keeps bins
+	DefaultRelativeAccuracy = 0.01
+	// DefaultExactCapacity is the number of values a sketch keeps as they are before binning them
+	DefaultExactCapacity = 2048
+	// zeroThreshold is the magnitude below which values count as zero, since they have no bin
+	zeroThreshold = 1e-12
+)
+
+// Sketch is a mergeable quantile sketch. It keeps its first values as they are, so short series get
+// exact quantiles, and then collapses them into logarithmic bins as DDSketch does: every quantile
+// is within the relative accuracy of the true value, and memory grows with the range of the values
+// instead of their number. A week of usage at one-minute steps spans a few hundred bins.
+type Sketch struct {
+	gamma    float64
+	logGamma float64
+	capacity int
+
+	// exact holds the values until there are more than capacity of them, then it is nil
+	exact  []float64
+	sorted bool
+
+	// positive and negative count values by bin, zero counts values too close to zero for a bin
+	positive map[int]uint64
+	negative map[int]uint64
+	zero     uint64
+
+	count    uint64
+	min, max float64
+}
+
+// NewSketch returns an empty sketch with the default relative accuracy and exact capacity
+func NewSketch() *Sketch {
+	sketch, _ := NewSketchWithAccuracy(DefaultRelativeAccuracy, DefaultExactCapacity)
+	return sketch
+}
+
+// NewSketchWithAccuracy returns an empty sketch whose quantiles are within relativeAccuracy of the
+// true value once it holds more than exactCapacity values
+func NewSketchWithAccuracy(relativeAccuracy float64, exactCapacity int) (*Sketch, error) {
+	if relativeAccuracy <= 0 || relativeAccuracy >= 1 {
+		return nil, fmt.Errorf("relative accuracy must be between 0 and 1, got %g", relativeAccuracy)
+	}
+	if exactCapacity < 0 {
+		return nil, fmt.Errorf("exact capacity must not be negative, got %d", exactCapacity)
+	}
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+	return &Sketch{
+		gamma:    gamma,
+		logGamma: math.Log(gamma),
+		capacity: exactCapacity,
+		min:      math.Inf(1),
+		max:      math.Inf(-1),
+	}, nil
+}
+
+// Add adds a value to the sketch. NaN values are ignored.
+func (s *Sketch) Add(value float64) {
+	if math.IsNaN(value) {
+		return
+	}
+	s.count++
+	s.min = math.Min(s.min, value)
+	s.max = math.Max(s.max, value)
+
+	if s.positive == nil && len(s.exact) < s.capacity {
+		s.exact = append(s.exact, value)
+		s.sorted = false
+		return
+	}
+	s.collapse()
+	s.addToBin(value, 1)
+}
+
+// Merge adds the values of other to the sketch. Both sketches must have the same relative accuracy.
+func (s *Sketch) Merge(other *Sketch) error {
+	if other == nil || other.count == 0 {
+		return nil
+	}
+	if s.gamma != other.gamma {
+		return fmt.Errorf("cannot merge sketches of different relative accuracy")
+	}
+
+	s.count += other.count
+	s.min = math.Min(s.min, other.min)
+	s.max = math.Max(s.max, other.max)
+
+	if s.positive == nil && other.positive == nil && len(s.exact)+len(other.exact) <= s.capacity {
+		s.exact = append(s.exact, other.exact...)
+		s.sorted = false
+		return nil
+	}
+
+	s.collapse()
+	for _, value := range other.exact {
+		s.addToBin(value, 1)
+	}
+	for key, count := range other.positive {
+		s.positive[key] += count
+	}
+	for key, count := range other.negative {
+		s.negative[key] += count
+	}
+	s.zero += other.zero
+	return nil
+}
+
+// Count returns the number of values added
+func (s *Sketch) Count() int {
+	return int(s.count)
+}
+
+// Min returns the smallest value added, or zero for an empty sketch
+func (s *Sketch) Min() float64 {
+	if s.count == 0 {
+		return 0
+	}
+	return s.min
+}
+
+// Max returns the largest value added, or zero for an empty sketch
+func (s *Sketch) Max() float64 {
+	if s.count == 0 {
+		return 0
+	}
+	return s.max
+}
+
+// Quantile returns the q-quantile of the values, for q from 0 to 1, interpolating linearly between
+// the two closest ranks. It returns zero for an empty sketch.
+func (s *Sketch) Quantile(q float64) float64 {
+	if s.count == 0 {
+		return 0
+	}
+	if q <= 0 {
+		return s.min
+	}
+	if q >= 1 {
+		return s.max
+	}
+
+	rank := q * float64(s.count-1)
+	lower, upper := math.Floor(rank), math.Ceil(rank)
+	lowerValue := s.valueAt(uint64(lower))
+	if lower == upper {
+		return lowerValue
+	}
+	weight := rank - lower
+	return lowerValue*(1-weight) + s.valueAt(uint64(upper))*weight
+}
+
+// valueAt returns the value of the given rank, counted from zero for the smallest value
+func (s *Sketch) valueAt(rank uint64) float64 {
+	if s.exact != nil || s.positive == nil {
+		if !s.sorted {
+			sort.Float64s(s.exact)
+			s.sorted = true
+		}
+		return s.exact[rank]
+	}
+	if rank == 0 {
+		return s.min
+	}
+	if rank == s.count-1 {
+		return s.max
+	}
+
+	// Negative values come first, the largest magnitude first, then zeros, then positive values
+	var seen uint64
+	keys := sortedKeys(s.negative)
+	for i := len(keys) - 1; i >= 0; i-- {
+		if seen += s.negative[keys[i]]; seen > rank {
+			return s.clamp(-s.binValue(keys[i]))
+		}
+	}
+	if seen += s.zero; seen > rank {
+		return 0
+	}
+	for _, key := range sortedKeys(s.positive) {
+		if seen += s.positive[key]; seen > rank {
+			return s.clamp(s.binValue(key))
+		}
+	}
+	return s.max
+}
+
+// collapse moves the exact values into bins
+func (s *Sketch) collapse() {
+	if s.positive != nil {
+		return
+	}
+	s.positive = make(map[int]uint64)
+	s.negative = make(map[int]uint64)
+	for _, value := range s.exact {
+		s.addToBin(value, 1)
+	}
+	s.exact = nil
+}
+
+// addToBin counts a value in the bin covering its magnitude
+func (s *Sketch) addToBin(value float64, count uint64) {
+	switch {
+	case value > zeroThreshold:
+		s.positive[s.binKey(value)] += count
+	case value < -zeroThreshold:
+		s.negative[s.binKey(-value)] += count
+	default:
+		s.zero += count
+	}
+}
+
+// binKey returns the bin of a positive value: bin k covers the values from gamma^(k-1) to gamma^k
+func (s *Sketch) binKey(value float64) int {
+	return int(math.Ceil(math.Log(value) / s.logGamma))
+}
+
+// binValue returns the value representing bin k, within the relative accuracy of every value in it
+func (s *Sketch) binValue(key int) float64 {
+	return 2 * math.Pow(s.gamma, float64(key)) / (s.gamma + 1)
+}
+
+// clamp keeps a bin value within the observed range
+func (s *Sketch) clamp(value float64) float64 {
+	return math.Min(math.Max(value, s.min), s.max)
+}
+
+// sortedKeys returns the bins of a store in ascending order
+func sortedKeys(bins map[int]uint64) []int {
+	keys := make([]int, 0, len(bins))
+	for key := range bins {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
@@ -0,0 +1,148 @@
+package stats
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exactQuantile interpolates the q-quantile of sorted values between the two closest ranks
+func exactQuantile(sorted []float64, q float64) float64 {
+	rank := q * float64(len(sorted)-1)
+	lower, upper := int(math.Floor(rank)), int(math.Ceil(rank))
+	weight := rank - float64(lower)
+	return sorted[lower]*(1-weight) + sorted[upper]*weight
+}
+
+func TestSketch_ExactBelowCapacity(t *testing.T) {
+	sketch := NewSketch()
+	for _, value := range []float64{10, 1, 9, 2, 8, 3, 7, 4, 6, 5, math.NaN()} {
+		sketch.Add(value)
+	}
+
+	assert.Equal(t, 10, sketch.Count())
+	assert.Equal(t, 1.0, sketch.Min())
+	assert.Equal(t, 10.0, sketch.Max())
+	assert.InDelta(t, 5.5, sketch.Quantile(0.5), 1e-9)
+	assert.InDelta(t, 9.1, sketch.Quantile(0.9), 1e-9)
+	assert.InDelta(t, 9.55, sketch.Quantile(0.95), 1e-9)
+
+	empty := NewSketch()
+	assert.Zero(t, empty.Quantile(0.5))
+	assert.Zero(t, empty.Max())
+}
+
+func TestSketch_QuantileEdgeCases(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		q      float64
+		want   float64
+	}{
+		{name: "empty", q: 0.95, want: 0},
+		{name: "single sample at median", values: []float64{0.5}, q: 0.5, want: 0.5},
+		{name: "single sample at p99", values: []float64{0.5}, q: 0.99, want: 0.5},
+		{name: "below zero is the minimum", values: []float64{3, 1, 2}, q: -0.5, want: 1},
+		{name: "above one is the maximum", values: []float64{3, 1, 2}, q: 1.5, want: 3},
+		{name: "exact rank", values: []float64{1, 2, 3, 4, 5}, q: 0.75, want: 4},
+		{name: "between two samples", values: []float64{1, 2}, q: 0.25, want: 1.25},
+		{name: "between ranks", values: []float64{10, 20, 30, 40}, q: 0.5, want: 25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Exact values and values collapsed into bins agree within the bins' accuracy
+			binned, err := NewSketchWithAccuracy(DefaultRelativeAccuracy, 0)
+			require.NoError(t, err)
+			exact := NewSketch()
+			for _, value := range tt.values {
+				exact.Add(value)
+				binned.Add(value)
+			}
+
+			assert.InDelta(t, tt.want, exact.Quantile(tt.q), 1e-9)
+			assert.InDelta(t, tt.want, binned.Quantile(tt.q), tt.want*DefaultRelativeAccuracy)
+		})
+	}
+}
+
+func TestSketch_RelativeAccuracy(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	sketch := NewSketch()
+
+	// A month of one-minute samples spanning several orders of magnitude
+	values := make([]float64, 30*24*60)
+	for i := range values {
+		values[i] = math.Exp(random.NormFloat64()*2) * 0.1
+		sketch.Add(values[i])
+	}
+	sort.Float64s(values)
+
+	assert.Nil(t, sketch.exact)
+	assert.Less(t, len(sketch.positive), 2000, "bins grow with the range of the values, not their number")
+	for _, q := range []float64{0.01, 0.25, 0.5, 0.9, 0.95, 0.99, 0.999} {
+		want := exactQuantile(values, q)
+		assert.InEpsilon(t, want, sketch.Quantile(q), DefaultRelativeAccuracy*1.5, "q=%v", q)
+	}
+	assert.Equal(t, values[0], sketch.Quantile(0))
+	assert.Equal(t, values[len(values)-1], sketch.Quantile(1))
+}
+
+func TestSketch_NegativeAndZeroValues(t *testing.T) {
+	sketch, err := NewSketchWithAccuracy(0.01, 0)
+	require.NoError(t, err)
+	for _, value := range []float64{-100, -10, 0, 0, 10, 100} {
+		sketch.Add(value)
+	}
+
+	assert.Equal(t, -100.0, sketch.Quantile(0))
+	assert.InEpsilon(t, -10, sketch.Quantile(0.2), 0.02)
+	assert.Zero(t, sketch.Quantile(0.5))
+	assert.InEpsilon(t, 10, sketch.Quantile(0.8), 0.02)
+	assert.Equal(t, 100.0, sketch.Quantile(1))
+
+	_, err = NewSketchWithAccuracy(0, 10)
+	assert.Error(t, err)
+	_, err = NewSketchWithAccuracy(0.01, -1)
+	assert.Error(t, err)
+}
+
+func TestSketch_Merge(t *testing.T) {
+	random := rand.New(rand.NewSource(2))
+
+	// Replicas merged into a workload sketch agree with a sketch of all their values
+	merged, all := NewSketch(), NewSketch()
+	for replica := 0; replica < 5; replica++ {
+		sketch := NewSketch()
+		for i := 0; i < 1000; i++ {
+			value := 0.5 + 0.1*float64(replica) + 0.05*random.NormFloat64()
+			sketch.Add(value)
+			all.Add(value)
+		}
+		require.NoError(t, merged.Merge(sketch))
+	}
+
+	assert.Equal(t, 5000, merged.Count())
+	assert.Equal(t, all.Min(), merged.Min())
+	assert.Equal(t, all.Max(), merged.Max())
+	for _, q := range []float64{0.05, 0.5, 0.95, 0.99} {
+		assert.InEpsilon(t, all.Quantile(q), merged.Quantile(q), 2*DefaultRelativeAccuracy)
+	}
+
+	// Small sketches stay exact when merged
+	small, other := NewSketch(), NewSketch()
+	small.Add(1)
+	other.Add(3)
+	require.NoError(t, small.Merge(other))
+	require.NoError(t, small.Merge(nil))
+	assert.Equal(t, 2.0, small.Quantile(0.5))
+
+	coarse, err := NewSketchWithAccuracy(0.05, 0)
+	require.NoError(t, err)
+	coarse.Add(1)
+	assert.Error(t, small.Merge(coarse))
+}